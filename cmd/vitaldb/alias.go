@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+// addAliasFlags registers the canonical signal selection flags.
+func addAliasFlags(fs *flag.FlagSet, config *Config) {
+	fs.StringVar(&config.Aliases, "aliases", "", "트랙 별칭 표 (YAML/JSON): 트랙을 표준 신호 이름으로 표시")
+	fs.StringVar(&config.Signals, "signals", "", "표준 신호 이름으로 트랙 선택, 출력 이름도 신호 이름으로 변경 (쉼표로 구분, -aliases 필요)")
+}
+
+// prepareFilters loads the -aliases table, checks the -signals names
+// against it and parses -units, -filter, -resample-filter and -artifacts.
+func prepareFilters(config *Config) error {
+	if err := prepareUnits(config); err != nil {
+		return err
+	}
+	if err := prepareWaves(config); err != nil {
+		return err
+	}
+	if config.Aliases == "" {
+		if config.Signals != "" {
+			return errors.New("-signals requires -aliases")
+		}
+		return nil
+	}
+	table, err := vital.LoadAliasTable(config.Aliases)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(table.Signals))
+	for _, sig := range table.Signals {
+		known[sig.Name] = true
+	}
+	for _, name := range splitList(config.Signals) {
+		if !known[name] {
+			return fmt.Errorf("-signals: %q is not defined in %s", name, config.Aliases)
+		}
+	}
+	config.aliases = table
+	return nil
+}
+
+// trackSelection is a track to output and the name it is output under.
+type trackSelection struct {
+	name  string
+	track *vital.Track
+}
+
+// selectTracks returns the tracks of vf to output: with -signals the
+// preferred track of each signal under the signal's name, otherwise every
+// track under its own name.
+func selectTracks(vf *vital.VitalFile, config *Config) []trackSelection {
+	var selected []trackSelection
+	if signals := splitList(config.Signals); len(signals) > 0 {
+		for _, name := range signals {
+			if trk := vf.CanonicalTrack(config.aliases, name); trk != nil {
+				selected = append(selected, trackSelection{name, trk})
+			}
+		}
+		return selected
+	}
+	for name, trk := range vf.Trks {
+		selected = append(selected, trackSelection{name, trk})
+	}
+	return selected
+}
+
+// splitList splits a comma separated flag value, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
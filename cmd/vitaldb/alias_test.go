@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const aliasFixture = "../../vital/testdata/aliases.yaml"
+
+func TestSignalsRenameTracks(t *testing.T) {
+	code, stdout, stderr := runCLI("export", "-quiet", "-format", "json", "-max-samples", "1",
+		"-aliases", aliasFixture, "-signals", "HR,ABP,SpO2_MISSING", fixture)
+	if code != exitUsage || !strings.Contains(stderr, "SpO2_MISSING") {
+		t.Errorf("unknown signal: exit %d, stderr %q", code, stderr)
+	}
+
+	code, stdout, stderr = runCLI("export", "-quiet", "-format", "json", "-max-samples", "1",
+		"-aliases", aliasFixture, "-signals", "HR,ABP", fixture)
+	if code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	var output OutputData
+	if err := json.Unmarshal([]byte(stdout), &output); err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Tracks) != 2 {
+		t.Fatalf("tracks = %v", sortedKeys(output.Tracks))
+	}
+	// HR은 별칭 표의 첫 패턴인 Bx50/HR을 선택
+	if hr := output.Tracks["HR"]; hr.Name != "HR" || hr.SourceName != "Bx50/HR" || hr.Signal != "HR" || len(hr.Records) != 1 {
+		t.Errorf("HR = %+v", hr)
+	}
+	if abp := output.Tracks["ABP"]; abp.SourceName != "Intellivue/ART" || abp.Unit != "mmHg" {
+		t.Errorf("ABP = %+v", abp)
+	}
+
+	code, stdout, _ = runCLI("export", "-quiet", "-format", "csv", "-aliases", aliasFixture, "-signals", "HR", fixture)
+	if code != exitOK || !strings.Contains(stdout, "\nHR,1721811600.000000,60,/min\n") {
+		t.Errorf("csv: exit %d, output %q", code, stdout)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+// anonymizeFlags holds the flags of the anonymize command.
+type anonymizeFlags struct {
+	ShiftDays    float64
+	NoShift      bool
+	KeyFile      string
+	CaseID       string
+	MaxShiftDays int
+	ScrubDevices bool
+	ScrubPorts   bool
+	RenameDevice stringList // old=new
+	Redact       stringList // 정규식
+	DropTracks   stringList // 트랙 이름 정규식
+	Audit        string
+}
+
+// stringList is a flag that may be repeated.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// auditRecord is the audit log file written by -audit. The input path is
+// left out: recorder file names hold the bed and the recording time.
+type auditRecord struct {
+	Output    string    `json:"output,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	*vital.AuditLog
+}
+
+func addAnonymizeFlags(fs *flag.FlagSet, config *Config) {
+	a := &config.Anonymize
+	fs.Float64Var(&a.ShiftDays, "shift-days", 0, "고정 날짜 이동 (일, 0 = -key-file 또는 무작위로 결정)")
+	fs.BoolVar(&a.NoShift, "no-shift", false, "시간을 이동하지 않음")
+	fs.StringVar(&a.KeyFile, "key-file", "", "키 파일: 같은 키와 -case-id는 항상 같은 날짜 이동")
+	fs.StringVar(&a.CaseID, "case-id", "", "케이스 ID (-key-file과 함께 필수)")
+	fs.IntVar(&a.MaxShiftDays, "max-shift-days", 365, "무작위/키 기반 이동의 최대 일수")
+	fs.BoolVar(&a.ScrubDevices, "scrub-devices", false, "디바이스 이름을 DEV1, DEV2, ...로 변경 (트랙 이름 포함)")
+	fs.BoolVar(&a.ScrubPorts, "scrub-ports", false, "디바이스 포트 정보 삭제")
+	fs.Var(&a.RenameDevice, "rename-device", "디바이스 이름 변경 old=new (반복 가능)")
+	fs.Var(&a.Redact, "redact", "STRING 트랙 값에서 지울 정규식 (반복 가능)")
+	fs.Var(&a.DropTracks, "drop-track", "삭제할 STRING 트랙 이름 정규식 (반복 가능)")
+	fs.StringVar(&a.Audit, "audit", "", "변경 내역(audit log) JSON 파일 (원본과 함께 보관)")
+}
+
+// checkAnonymize requires -case-id with -key-file before the file is read.
+// A default taken from the file name would give each part of a split case
+// its own keyed shift.
+func checkAnonymize(config *Config) error {
+	if a := &config.Anonymize; a.KeyFile != "" && a.CaseID == "" {
+		return errors.New("-key-file requires -case-id")
+	}
+	return nil
+}
+
+// anonymizeOptions turns the flags into vital.AnonymizeOptions.
+func (a *anonymizeFlags) anonymizeOptions() (vital.AnonymizeOptions, error) {
+	opts := vital.AnonymizeOptions{
+		Shift:            a.ShiftDays * 86400,
+		CaseID:           a.CaseID,
+		MaxShiftDays:     a.MaxShiftDays,
+		NoShift:          a.NoShift,
+		ScrubDeviceNames: a.ScrubDevices,
+		ScrubPorts:       a.ScrubPorts,
+		DeviceNames:      make(map[string]string),
+	}
+	if a.KeyFile != "" {
+		key, err := os.ReadFile(a.KeyFile)
+		if err != nil {
+			return opts, err
+		}
+		if opts.Key = bytes.TrimSpace(key); len(opts.Key) == 0 {
+			return opts, fmt.Errorf("key file %s is empty", a.KeyFile)
+		}
+	}
+	for _, pair := range a.RenameDevice {
+		from, to, ok := strings.Cut(pair, "=")
+		if !ok || from == "" || to == "" {
+			return opts, fmt.Errorf("-rename-device %q: want old=new", pair)
+		}
+		opts.DeviceNames[from] = to
+	}
+	for _, expr := range a.Redact {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return opts, fmt.Errorf("-redact: %w", err)
+		}
+		opts.Redact = append(opts.Redact, vital.RedactRule{Pattern: re})
+	}
+	for _, expr := range a.DropTracks {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return opts, fmt.Errorf("-drop-track: %w", err)
+		}
+		opts.Redact = append(opts.Redact, vital.RedactRule{Track: re, Drop: true})
+	}
+	return opts, nil
+}
+
+// runAnonymize writes a de-identified copy of vf and, with -audit, the log
+// of what was changed.
+func runAnonymize(vf *vital.VitalFile, path string, config *Config, stdout io.Writer) error {
+	opts, err := config.Anonymize.anonymizeOptions()
+	if err != nil {
+		return err
+	}
+	anon, audit, err := vital.Anonymize(vf, opts)
+	if err != nil {
+		return err
+	}
+
+	if config.Anonymize.Audit != "" {
+		record := auditRecord{Output: config.Output, CreatedAt: time.Now().UTC(), AuditLog: audit}
+		err := writeFileAtomic(config.Anonymize.Audit, func(w io.Writer) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(record)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+	}
+	return writeRecording(anon, path, config, stdout)
+}
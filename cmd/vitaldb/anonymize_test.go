@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+func TestAnonymizeCommand(t *testing.T) {
+	dir := t.TempDir()
+	parts := []string{
+		writeSplitPart(t, dir, "MICUA01_240724_180000.vital", 1721844000, 3),
+		writeSplitPart(t, dir, "MICUA01_240724_180003.vital", 1721844003, 2),
+	}
+	key := filepath.Join(dir, "key")
+	if err := os.WriteFile(key, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// 같은 키와 케이스 ID는 분할 파일마다 같은 이동량
+	var shifts []float64
+	for i, in := range parts {
+		out := filepath.Join(dir, fmt.Sprintf("anon%d.vital", i))
+		audit := out + ".audit.json"
+		code, _, stderr := runCLI("anonymize", "-quiet", "-key-file", key, "-case-id", "case-1",
+			"-rename-device", "Bx50=Monitor", "-scrub-ports", "-audit", audit, "-o", out, in)
+		if code != exitOK {
+			t.Fatalf("run %d: exit %d, stderr %q", i, code, stderr)
+		}
+		var record auditRecord
+		data, err := os.ReadFile(audit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &record); err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(data, []byte("MICUA01")) || record.ShiftMethod != vital.ShiftKeyed || len(record.Changes) != 2 {
+			t.Errorf("audit = %s", data)
+		}
+		shifts = append(shifts, record.ShiftSeconds)
+
+		vf, err := vital.NewVitalFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if vf.DtStart != 1721844000+float64(3*i)+record.ShiftSeconds || vf.Devs["Monitor"].Port != "" || vf.Trks["Monitor/HR"] == nil {
+			t.Errorf("anonymized file: start %f, devices %v", vf.DtStart, vf.Devs)
+		}
+	}
+	if shifts[0] != shifts[1] {
+		t.Errorf("keyed shifts differ: %v", shifts)
+	}
+
+	// 파일 이름을 케이스 ID로 쓰면 분할 파일마다 이동량이 달라지므로 -case-id 필수
+	if code, _, stderr := runCLI("anonymize", "-quiet", "-key-file", key, "-o", filepath.Join(dir, "x.vital"), parts[0]); code != exitUsage || !strings.Contains(stderr, "-case-id") {
+		t.Errorf("-key-file without -case-id: exit %d, stderr %q", code, stderr)
+	}
+	if code, _, _ := runCLI("anonymize", "-quiet", "-redact", "(", parts[0]); code != exitError {
+		t.Errorf("bad -redact: exit %d, want %d", code, exitError)
+	}
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+const batchSummary = "여러 파일을 병렬로 변환하고 manifest 작성 (디렉토리, glob, @목록 파일)"
+
+// Batch file statuses recorded in the manifest.
+const (
+	statusConverted = "converted"
+	statusSkipped   = "skipped" // 출력 파일이 이미 있음
+	statusFailed    = "failed"
+)
+
+// batchOptions holds the flags that only batch has.
+type batchOptions struct {
+	OutDir    string
+	Jobs      int
+	Name      string // 출력 파일 이름 템플릿
+	Overwrite bool
+	Manifest  string
+}
+
+// BatchManifest summarizes a batch run. It is written as JSON next to the
+// outputs so that an interrupted run can be inspected and resumed.
+type BatchManifest struct {
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+	Format     string        `json:"format"`
+	OutDir     string        `json:"out_dir"`
+	Total      int           `json:"total"`
+	Converted  int           `json:"converted"`
+	Skipped    int           `json:"skipped"`
+	Failed     int           `json:"failed"`
+	Files      []BatchResult `json:"files"`
+}
+
+// BatchResult is the outcome of one input file.
+type BatchResult struct {
+	Input    string  `json:"input"`
+	Output   string  `json:"output,omitempty"`
+	Status   string  `json:"status"`
+	Error    string  `json:"error,omitempty"`
+	Tracks   int     `json:"tracks,omitempty"`
+	Duration float64 `json:"duration_sec"`
+}
+
+func newBatchFlagSet(output io.Writer) (*flag.FlagSet, *Config, *batchOptions) {
+	config := &Config{}
+	opts := &batchOptions{}
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&config.Format, "format", exportFormats[0], "출력 형식 ("+strings.Join(exportFormats, ", ")+")")
+	addLoadFlags(fs, config)
+	addFilterFlags(fs, config)
+	addRecordFlags(fs, config, 0)
+	fs.StringVar(&config.Compress, "compress", "none", "출력 압축 (none, gzip, zstd)")
+	fs.StringVar(&opts.OutDir, "out-dir", "", "출력 디렉토리 (필수)")
+	fs.IntVar(&opts.Jobs, "jobs", runtime.NumCPU(), "동시에 처리할 파일 수")
+	fs.StringVar(&opts.Name, "name", "{name}.{ext}", "출력 파일 이름 템플릿 ({name}: 입력 파일 이름, {dir}: 입력 디렉토리 이름, {ext}: 형식 확장자)")
+	fs.BoolVar(&opts.Overwrite, "overwrite", false, "이미 변환된 파일도 다시 변환")
+	fs.StringVar(&opts.Manifest, "manifest", "", "manifest 파일 경로 (기본: <out-dir>/manifest.json)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: vitaldb batch -out-dir <dir> [flags] <dir|glob|@list>...\n\n%s\n\n", batchSummary)
+		fmt.Fprintf(fs.Output(), "디렉토리는 하위 디렉토리까지 .vital 파일을 찾고, @list는 한 줄에 하나씩 경로를 읽습니다.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	return fs, config, opts
+}
+
+// runBatch converts every input file and writes the manifest. It returns
+// exitError if any file failed.
+func runBatch(args []string, stdout, stderr io.Writer) int {
+	fs, config, opts := newBatchFlagSet(stderr)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	switch {
+	case fs.NArg() == 0:
+		fmt.Fprintf(stderr, "vitaldb batch: no input files\n\n")
+		fs.Usage()
+		return exitUsage
+	case opts.OutDir == "":
+		fmt.Fprintf(stderr, "vitaldb batch: -out-dir is required\n")
+		return exitUsage
+	case !contains(exportFormats, config.Format):
+		fmt.Fprintf(stderr, "vitaldb batch: unknown format %q (supported: %s)\n", config.Format, strings.Join(exportFormats, ", "))
+		return exitUsage
+	}
+	if err := checkCompression(config); err != nil {
+		fmt.Fprintf(stderr, "vitaldb batch: %v\n", err)
+		return exitUsage
+	}
+	if err := prepareFilters(config); err != nil {
+		fmt.Fprintf(stderr, "vitaldb batch: %v\n", err)
+		return exitUsage
+	}
+	if opts.Jobs < 1 {
+		opts.Jobs = 1
+	}
+	if opts.Manifest == "" {
+		opts.Manifest = filepath.Join(opts.OutDir, "manifest.json")
+	}
+
+	inputs, err := expandInputs(fs.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "vitaldb batch: %v\n", err)
+		return exitError
+	}
+	if len(inputs) == 0 {
+		fmt.Fprintf(stderr, "vitaldb batch: no .vital files found\n")
+		return exitError
+	}
+	if err := os.MkdirAll(opts.OutDir, 0o755); err != nil {
+		fmt.Fprintf(stderr, "vitaldb batch: %v\n", err)
+		return exitError
+	}
+
+	config.warn = &syncWriter{w: stderr}
+	manifest := BatchManifest{
+		StartedAt: time.Now().UTC(),
+		Format:    config.Format,
+		OutDir:    opts.OutDir,
+		Total:     len(inputs),
+		Files:     processBatch(inputs, config, opts, stderr),
+	}
+	manifest.FinishedAt = time.Now().UTC()
+	for _, result := range manifest.Files {
+		switch result.Status {
+		case statusConverted:
+			manifest.Converted++
+		case statusSkipped:
+			manifest.Skipped++
+		case statusFailed:
+			manifest.Failed++
+		}
+	}
+
+	if err := writeFileAtomic(opts.Manifest, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(manifest)
+	}); err != nil {
+		fmt.Fprintf(stderr, "vitaldb batch: failed to write manifest: %v\n", err)
+		return exitError
+	}
+	fmt.Fprintf(stdout, "%d converted, %d skipped, %d failed (manifest: %s)\n",
+		manifest.Converted, manifest.Skipped, manifest.Failed, opts.Manifest)
+
+	if manifest.Failed > 0 {
+		return exitError
+	}
+	return exitOK
+}
+
+// processBatch converts inputs with a pool of opts.Jobs workers. Results are
+// returned in input order.
+func processBatch(inputs []string, config *Config, opts *batchOptions, stderr io.Writer) []BatchResult {
+	results := make([]BatchResult, len(inputs))
+
+	// 출력 이름이 겹치면 먼저 나온 입력만 변환 (병렬 처리 중 서로 덮어쓰지 않도록)
+	owner := make(map[string]string, len(inputs))
+	jobs := make(chan int)
+	go func() {
+		for i, input := range inputs {
+			results[i] = BatchResult{Input: input, Output: outputPath(input, config, opts)}
+			if first, ok := owner[results[i].Output]; ok {
+				results[i].Status = statusFailed
+				results[i].Error = fmt.Sprintf("output %s is already written by %s", results[i].Output, first)
+				continue
+			}
+			owner[results[i].Output] = input
+			jobs <- i
+		}
+		close(jobs)
+	}()
+
+	var mu sync.Mutex // 진행 상황 출력 보호
+	done := 0
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				convertOne(&results[i], config, opts)
+
+				mu.Lock()
+				done++
+				if !config.Quiet || results[i].Status == statusFailed {
+					fmt.Fprintf(stderr, "[%d/%d] %s %s", done, len(inputs), results[i].Status, results[i].Input)
+					if results[i].Error != "" {
+						fmt.Fprintf(stderr, ": %s", results[i].Error)
+					}
+					fmt.Fprintln(stderr)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// syncWriter serializes the writes of the batch workers.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// convertOne converts result.Input to result.Output unless the output
+// already exists.
+func convertOne(result *BatchResult, config *Config, opts *batchOptions) {
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start).Seconds() }()
+
+	if !opts.Overwrite {
+		if _, err := os.Stat(result.Output); err == nil {
+			result.Status = statusSkipped
+			return
+		}
+	}
+
+	err := func() error {
+		vf, err := vital.NewVitalFileWithOptions(result.Input, vital.LoadOptions{
+			MemoryBudget: config.MemoryBudget << 20,
+			SpillDir:     config.SpillDir,
+		})
+		if err != nil {
+			return err
+		}
+		defer vf.Close()
+
+		if err := transformWaves(vf, config); err != nil {
+			return err
+		}
+		output, err := processVitalFile(vf, config)
+		if err != nil {
+			return err
+		}
+		result.Tracks = len(output.Tracks)
+		if err := os.MkdirAll(filepath.Dir(result.Output), 0o755); err != nil {
+			return err
+		}
+		return writeFileAtomic(result.Output, func(w io.Writer) error {
+			return writeCompressed(w, config.Compress, func(w io.Writer) error {
+				return writeOutput(w, output, vf, config)
+			})
+		})
+	}()
+	if err != nil {
+		result.Status = statusFailed
+		result.Error = err.Error()
+		return
+	}
+	result.Status = statusConverted
+}
+
+// outputPath expands the -name template for input.
+func outputPath(input string, config *Config, opts *batchOptions) string {
+	base := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+	name := strings.NewReplacer(
+		"{name}", base,
+		"{dir}", filepath.Base(filepath.Dir(input)),
+		"{ext}", formatExtension(config.Format)+compressionExtension(config.Compress),
+	).Replace(opts.Name)
+	return filepath.Join(opts.OutDir, name)
+}
+
+// expandInputs resolves directories (searched recursively for .vital files),
+// glob patterns and @list files into a de-duplicated list of paths.
+func expandInputs(args []string) ([]string, error) {
+	var inputs []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			inputs = append(inputs, path)
+		}
+	}
+
+	for _, arg := range args {
+		if list, ok := strings.CutPrefix(arg, "@"); ok {
+			paths, err := readFileList(list)
+			if err != nil {
+				return nil, err
+			}
+			for _, path := range paths {
+				add(path)
+			}
+			continue
+		}
+
+		var matches []string
+		if strings.ContainsAny(arg, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(arg); err != nil {
+				return nil, fmt.Errorf("bad pattern %q: %w", arg, err)
+			}
+		} else {
+			matches = []string{arg}
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				add(match)
+				continue
+			}
+			// WalkDir는 이름 순서로 방문하므로 결과 순서가 안정적
+			err = filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".vital") {
+					add(path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return inputs, nil
+}
+
+// readFileList reads one path per line, skipping blank lines and # comments.
+func readFileList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var paths []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	return paths, scanner.Err()
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// batchInputs copies the synthetic fixtures into a temporary archive with
+// one unreadable file and returns its directory.
+func batchInputs(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range []string{"all_formats", "multi_device", "malformed"} {
+		data, err := os.ReadFile(filepath.Join("../../vital/testdata/synthetic", name+".vital"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Join(dir, "day1"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "day1", name+".vital"), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.vital"), []byte("not a vital file"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func readManifest(t *testing.T, path string) BatchManifest {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest BatchManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	return manifest
+}
+
+func TestBatchConvertsAndSkips(t *testing.T) {
+	in := batchInputs(t)
+	out := t.TempDir()
+
+	code, _, stderr := runCLI("batch", "-quiet", "-jobs", "2", "-out-dir", out, in)
+	if code != exitError {
+		t.Fatalf("exit %d, want %d (broken input); stderr %q", code, exitError, stderr)
+	}
+	manifest := readManifest(t, filepath.Join(out, "manifest.json"))
+	if manifest.Total != 4 || manifest.Converted != 3 || manifest.Failed != 1 {
+		t.Fatalf("manifest counts = %+v", manifest)
+	}
+	for _, result := range manifest.Files {
+		if strings.HasSuffix(result.Input, "broken.vital") {
+			if result.Status != statusFailed || result.Error == "" {
+				t.Errorf("broken input: %+v", result)
+			}
+			continue
+		}
+		data, err := os.ReadFile(result.Output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(data), "track_name,timestamp,value,unit\n") {
+			t.Errorf("%s: unexpected output %q", result.Output, data)
+		}
+	}
+	if !strings.Contains(stderr, "failed "+filepath.Join(in, "broken.vital")) {
+		t.Errorf("quiet mode should still report failures, stderr %q", stderr)
+	}
+
+	// 두 번째 실행은 이미 변환된 파일을 건너뜀
+	os.Remove(filepath.Join(in, "broken.vital"))
+	code, stdout, _ := runCLI("batch", "-quiet", "-out-dir", out, in)
+	if code != exitOK || !strings.Contains(stdout, "0 converted, 3 skipped, 0 failed") {
+		t.Errorf("rerun: exit %d, output %q", code, stdout)
+	}
+}
+
+func TestBatchNameTemplateAndFileList(t *testing.T) {
+	in := batchInputs(t)
+	out := t.TempDir()
+	list := filepath.Join(t.TempDir(), "files.txt")
+	content := "# ICU archive\n" + filepath.Join(in, "day1", "multi_device.vital") + "\n\n"
+	if err := os.WriteFile(list, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"batch", "-quiet", "-format", "json", "-name", "{dir}/{name}.{ext}", "-out-dir", out,
+		"@" + list, filepath.Join(in, "day1", "all_*.vital")}
+	if code, _, stderr := runCLI(args...); code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	for _, name := range []string{"multi_device.json", "all_formats.json"} {
+		if _, err := os.Stat(filepath.Join(out, "day1", name)); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestBatchRejectsOutputCollisions(t *testing.T) {
+	in := batchInputs(t)
+	out := t.TempDir()
+	code, _, _ := runCLI("batch", "-quiet", "-name", "same.csv", "-out-dir", out, filepath.Join(in, "day1"))
+	if code != exitError {
+		t.Fatalf("exit %d, want %d", code, exitError)
+	}
+	manifest := readManifest(t, filepath.Join(out, "manifest.json"))
+	if manifest.Converted != 1 || manifest.Failed != 2 {
+		t.Errorf("manifest counts = %+v", manifest)
+	}
+}
+
+func TestBatchUsage(t *testing.T) {
+	for _, args := range [][]string{
+		{"batch", "in"},
+		{"batch", "-out-dir", t.TempDir()},
+		{"batch", "-format", "xml", "-out-dir", t.TempDir(), "in"},
+	} {
+		if code, _, _ := runCLI(args...); code != exitUsage {
+			t.Errorf("%v: exit %d, want %d", args, code, exitUsage)
+		}
+	}
+}
+
+func TestBatchResample(t *testing.T) {
+	in := t.TempDir()
+	data, err := os.ReadFile("../../vital/testdata/synthetic/all_formats.vital")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(in, "all_formats.vital"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := t.TempDir()
+
+	code, _, stderr := runCLI("batch", "-quiet", "-format", "json", "-tracks", "Monitor/WAVE_F32", "-resample", "50", "-out-dir", out, in)
+	if code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	var output OutputData
+	if err := json.Unmarshal([]byte(readOutput(t, filepath.Join(out, "all_formats.json"))), &output); err != nil {
+		t.Fatal(err)
+	}
+	if trk, ok := output.Tracks["Monitor/WAVE_F32"]; !ok || trk.SampleRate != 50 {
+		t.Errorf("tracks %+v", output.Tracks)
+	}
+}
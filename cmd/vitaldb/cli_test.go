@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mdsung/vitaldb_processor/vital/vitaltest"
+)
+
+const fixture = "../../vital/testdata/synthetic/multi_device.vital"
+
+// runCLI runs the CLI in-process and returns its exit code and output.
+func runCLI(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestSubcommands(t *testing.T) {
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"info", "-quiet", fixture}, "Number of Tracks: 4"},
+		{[]string{"tracks", "-quiet", fixture}, "- Bx50/ECG_II: WAVE (mV), Rate: 500.0 Hz"},
+		{[]string{"devices", "-quiet", "-format", "json", fixture}, `"port": "COM2"`},
+		{[]string{"stats", "-quiet", fixture}, "- Bx50/HR: /min, Rate: 0.0 Hz, Records: 4"},
+		{[]string{"export", "-quiet", "-format", "csv", fixture}, "Bx50/HR,1721811600.000000,60,/min"},
+		{[]string{"convert", "-quiet", "-format", "csv", "-tracks", "Bx50/HR", fixture}, "Bx50/HR,1721811603.000000"},
+		{[]string{"validate", "-quiet", fixture}, "OK (4 tracks, 2 devices)"},
+		// 이전 플래그 형식은 export 별칭
+		{[]string{"-quiet", "-format", "csv", fixture}, "track_name,timestamp,value,unit"},
+		{[]string{"-list-tracks", "-format", "text", "-quiet", fixture}, "=== Available Tracks ==="},
+	}
+	for _, tc := range cases {
+		code, stdout, stderr := runCLI(tc.args...)
+		if code != exitOK {
+			t.Errorf("%v: exit %d, stderr %q", tc.args, code, stderr)
+			continue
+		}
+		if !strings.Contains(stdout, tc.want) {
+			t.Errorf("%v: output does not contain %q:\n%s", tc.args, tc.want, stdout)
+		}
+	}
+}
+
+// TestLegacySummary checks that the old flag form's -summary still writes
+// what it did before the subcommands: testdata/legacy_summary.json is the
+// output of the pre-subcommand binary for the fixture.
+func TestLegacySummary(t *testing.T) {
+	want, err := os.ReadFile(filepath.Join("testdata", "legacy_summary.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, stdout, stderr := runCLI("-summary", "-quiet", "-format", "json", fixture); code != exitOK || stdout != string(want) {
+		t.Errorf("json: exit %d, stderr %q, output\n%s\nwant\n%s", code, stderr, stdout, want)
+	}
+	// 레코드를 출력하지 않으므로 CSV는 헤더만
+	if code, stdout, _ := runCLI("-summary", "-quiet", fixture); code != exitOK || stdout != "track_name,timestamp,value,unit\n" {
+		t.Errorf("csv: exit %d, output %q", code, stdout)
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	cases := []struct {
+		args []string
+		want int
+	}{
+		{nil, exitUsage},
+		{[]string{"infoo", fixture}, exitUsage},
+		{[]string{"info"}, exitUsage},
+		{[]string{"info", "-format", "csv", fixture}, exitUsage},
+		{[]string{"validate", "-max-samples", "1", fixture}, exitUsage},
+		{[]string{"info", "-quiet", "missing.vital"}, exitError},
+		{[]string{"validate", "-quiet", "cli_test.go"}, exitInvalid},
+		{[]string{"help"}, exitOK},
+		{[]string{"help", "export"}, exitOK},
+	}
+	for _, tc := range cases {
+		if code, _, stderr := runCLI(tc.args...); code != tc.want {
+			t.Errorf("%v: exit %d, want %d (stderr %q)", tc.args, code, tc.want, stderr)
+		}
+	}
+}
+
+func TestValidateReportsIssues(t *testing.T) {
+	// 레코드 시간이 역순인 트랙과 정의되지 않은 디바이스
+	b := vitaltest.New(1000)
+	b.Track(vitaltest.Track{ID: 1, Type: vitaltest.TypeNumeric, Fmt: vitaltest.FmtFloat32, Name: "HR", Unit: "/min", DevID: 7})
+	b.Numeric(1, 1002, float32(61))
+	b.Numeric(1, 1001, float32(60))
+	path := filepath.Join(t.TempDir(), "unordered.vital")
+	if err := b.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, _ := runCLI("validate", "-quiet", path)
+	if code != exitOK || !strings.Contains(stdout, "warning: HR: 1 records are out of time order") {
+		t.Errorf("warnings only: exit %d, output:\n%s", code, stdout)
+	}
+
+	code, stdout, _ = runCLI("validate", "-quiet", "-strict", "-format", "json", path)
+	if code != exitInvalid {
+		t.Errorf("-strict: exit %d, want %d", code, exitInvalid)
+	}
+	var report ValidationReport
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Valid || len(report.Issues) == 0 {
+		t.Errorf("report = %+v", report)
+	}
+
+	// 검사 실패 시에도 -o 보고서는 남김
+	out := filepath.Join(t.TempDir(), "report.txt")
+	if code, _, _ := runCLI("validate", "-quiet", "-strict", "-o", out, path); code != exitInvalid {
+		t.Errorf("-o: exit %d, want %d", code, exitInvalid)
+	}
+	if data, err := os.ReadFile(out); err != nil || !strings.Contains(string(data), "INVALID") {
+		t.Errorf("-o report = %q, %v", data, err)
+	}
+}
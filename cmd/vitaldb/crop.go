@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"math"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+// addCropFlags registers the time window flags of crop.
+func addCropFlags(fs *flag.FlagSet, config *Config) {
+	fs.Float64Var(&config.CropStart, "start", 0, "구간 시작 (Unix 초, 0 = 파일 처음부터)")
+	fs.Float64Var(&config.CropEnd, "end", 0, "구간 끝, 이 시각은 포함하지 않음 (Unix 초, 0 = 파일 끝까지)")
+	fs.BoolVar(&config.Relative, "relative", false, "-start, -end를 파일 시작 시각으로부터의 초로 해석")
+}
+
+// runCrop writes the part of vf within the -start/-end window.
+func runCrop(vf *vital.VitalFile, path string, config *Config, stdout io.Writer) error {
+	from, to := math.Inf(-1), math.Inf(1)
+	base := 0.0
+	if config.Relative {
+		base = vf.DtStart
+	}
+	if config.CropStart != 0 {
+		from = base + config.CropStart
+	}
+	if config.CropEnd != 0 {
+		to = base + config.CropEnd
+	}
+
+	cropped, err := vf.Crop(from, to)
+	if err != nil {
+		return err
+	}
+	return writeRecording(cropped, path, config, stdout)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+func TestCropCommand(t *testing.T) {
+	in := writeSplitPart(t, t.TempDir(), "MICUA01_240724_180000.vital", 1721844000, 5)
+	out := filepath.Join(t.TempDir(), "cropped.vital")
+
+	if code, _, stderr := runCLI("crop", "-quiet", "-relative", "-start", "1", "-end", "3", "-o", out, in); code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	vf, err := vital.NewVitalFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := vf.Trks["Bx50/HR"].NumRecs(); n != 2 || vf.DtStart != 1721844001 || vf.DtEnd != 1721844002 {
+		t.Errorf("cropped %d records over [%f, %f]", n, vf.DtStart, vf.DtEnd)
+	}
+
+	// 절대 시각, 끝 생략
+	code, stdout, _ := runCLI("crop", "-quiet", "-start", "1721844004", "-format", "csv", in)
+	if want := "track_name,timestamp,value,unit\nBx50/HR,1721844004.000000,64,/min\n"; code != exitOK || stdout != want {
+		t.Errorf("csv: exit %d, output %q", code, stdout)
+	}
+	if code, _, _ := runCLI("crop", "-quiet", "-start", "5", "-end", "5", in); code != exitError {
+		t.Errorf("empty window: exit %d, want %d", code, exitError)
+	}
+	// .vital 출력은 모든 트랙을 유지하므로 트랙 필터는 거부
+	// 파일을 읽기 전에 검사하므로 없는 입력이어도 사용법 오류
+	if code, _, stderr := runCLI("crop", "-quiet", "-tracks", "Bx50/HR", "-o", out, filepath.Join(t.TempDir(), "missing.vital")); code != exitUsage || !strings.Contains(stderr, "track filters") {
+		t.Errorf("-tracks with -format vital: exit %d, stderr %q", code, stderr)
+	}
+}
@@ -0,0 +1,569 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+	"github.com/parquet-go/parquet-go"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type OutputData struct {
+	FileInfo *FileInfo             `json:"file_info,omitempty"`
+	Devices  map[string]DeviceInfo `json:"devices,omitempty"`
+	Tracks   map[string]TrackInfo  `json:"tracks,omitempty"`
+}
+
+type FileInfo struct {
+	StartTime    float64 `json:"dt_start"`
+	EndTime      float64 `json:"dt_end"`
+	Duration     float64 `json:"duration"`
+	GMTOffset    int16   `json:"gmt_offset"`
+	TracksCount  int     `json:"tracks_count"`
+	DevicesCount int     `json:"devices_count"`
+}
+
+type DeviceInfo struct {
+	Name     string `json:"name"`
+	TypeName string `json:"type_name"`
+	Port     string `json:"port"`
+}
+
+type TrackInfo struct {
+	Name         string            `json:"name"`
+	Type         uint8             `json:"type"`
+	TypeName     string            `json:"type_name"`
+	Fmt          uint8             `json:"fmt"`
+	Unit         string            `json:"unit"`
+	SampleRate   float32           `json:"sample_rate"`
+	Gain         float64           `json:"gain"`
+	Offset       float64           `json:"offset"`
+	MinDisplay   float32           `json:"min_display"`
+	MaxDisplay   float32           `json:"max_display"`
+	Color        uint32            `json:"color"`
+	MonitorType  uint8             `json:"monitor_type"`
+	DeviceName   string            `json:"device_name"`
+	Signal       string            `json:"signal,omitempty"`      // -aliases의 표준 신호 이름
+	SourceName   string            `json:"source_name,omitempty"` // -signals로 이름을 바꾼 원래 트랙 이름
+	SourceUnit   string            `json:"source_unit,omitempty"` // -units로 변환하기 전 단위
+	UnitWarning  string            `json:"unit_warning,omitempty"`
+	RecordsCount int               `json:"records_count"`
+	Records      []RecordInfo      `json:"records,omitempty"`
+	Stats        *vital.TrackStats `json:"stats,omitempty"` // stats 모드만
+
+	conv *vital.Conversion // 단위 변환 (nil = 원본 값)
+}
+
+// sourceName returns the name of the track in the .vital file.
+func (t TrackInfo) sourceName() string {
+	if t.SourceName != "" {
+		return t.SourceName
+	}
+	return t.Name
+}
+
+type RecordInfo struct {
+	Time  float64     `json:"dt"`
+	Value interface{} `json:"val"`
+}
+
+// ParquetRow represents a single row in Parquet output
+type ParquetRow struct {
+	TrackName string  `parquet:"track_name,snappy"`
+	Timestamp float64 `parquet:"timestamp,snappy"`
+	Value     string  `parquet:"value,snappy"`
+	Unit      string  `parquet:"unit,snappy"`
+}
+
+// reportCommand returns the run function of the metadata commands (info,
+// tracks, devices, stats), which differ only in the sections they report.
+func reportCommand(mode Mode) func(*vital.VitalFile, string, *Config, io.Writer) error {
+	return func(vf *vital.VitalFile, path string, config *Config, stdout io.Writer) error {
+		config.Mode = mode
+		return runExport(vf, path, config, stdout)
+	}
+}
+
+// runExport writes the sections selected by config.Mode in config.Format.
+func runExport(vf *vital.VitalFile, path string, config *Config, stdout io.Writer) error {
+	if err := transformWaves(vf, config); err != nil {
+		return err
+	}
+	output, err := processVitalFile(vf, config)
+	if err != nil {
+		return err
+	}
+	return writeOutput(stdout, output, vf, config)
+}
+
+// writeOutput encodes output in config.Format. CSV and Parquet stream the
+// records from vf rather than from output.
+func writeOutput(w io.Writer, output *OutputData, vf *vital.VitalFile, config *Config) error {
+	switch config.Format {
+	case "csv":
+		if config.Mode == ModeStats {
+			return printStatsCSV(w, output)
+		}
+		return printCSVOutput(w, output, vf, config)
+	case "parquet":
+		return printParquetOutput(w, output, vf, config)
+	case "json":
+		encoder := json.NewEncoder(w)
+		if !config.Compact {
+			encoder.SetIndent("", "  ")
+		}
+		return encoder.Encode(output)
+	case "msgpack":
+		// 버퍼링된 writer 사용 (syscall 오버헤드 감소)
+		writer := bufio.NewWriterSize(w, 256*1024) // 256KB 버퍼
+		encoder := msgpack.NewEncoder(writer)
+		if err := encoder.Encode(output); err != nil {
+			return err
+		}
+		return writer.Flush()
+	case "text":
+		printTextOutput(w, output, config)
+		return nil
+	default:
+		return fmt.Errorf("unknown format: %s. Supported formats: %s", config.Format, strings.Join(exportFormats, ", "))
+	}
+}
+
+func processVitalFile(vf *vital.VitalFile, config *Config) (*OutputData, error) {
+	output := &OutputData{}
+	mode := config.Mode
+
+	// 파일 정보
+	if mode == ModeExport || mode == ModeInfo || mode == ModeStats || mode == ModeSummary {
+		output.FileInfo = &FileInfo{
+			StartTime:    vf.DtStart,
+			EndTime:      vf.DtEnd,
+			Duration:     vf.DtEnd - vf.DtStart,
+			GMTOffset:    vf.Dgmt,
+			TracksCount:  len(vf.Trks),
+			DevicesCount: len(vf.Devs),
+		}
+	}
+
+	// 디바이스 정보
+	if mode == ModeExport || mode == ModeDevices || mode == ModeStats || mode == ModeSummary {
+		output.Devices = make(map[string]DeviceInfo)
+		for name, device := range vf.Devs {
+			output.Devices[name] = DeviceInfo{
+				Name:     device.Name,
+				TypeName: device.TypeName,
+				Port:     device.Port,
+			}
+		}
+	}
+
+	// 트랙 정보
+	if mode == ModeExport || mode == ModeTracks || mode == ModeStats || mode == ModeSummary {
+		tracks, err := processTracks(vf, config)
+		if err != nil {
+			return nil, err
+		}
+		output.Tracks = tracks
+	}
+
+	return output, nil
+}
+
+// matchesAnyPattern checks if a name matches any of the provided glob patterns.
+// Returns true if patterns is empty (no filtering) or if name matches at least one pattern.
+// Supports wildcards (* and ?) that can match across path separators.
+func matchesAnyPattern(name string, patterns []string) bool {
+	// No patterns = no filtering, match everything
+	if len(patterns) == 0 {
+		return true
+	}
+
+	// Check if name matches any pattern
+	for _, pattern := range patterns {
+		// First try filepath.Match for exact path matching
+		matched, err := filepath.Match(pattern, name)
+		if err == nil && matched {
+			return true
+		}
+
+		// Also try matching against just the base name (after last /)
+		// This allows patterns like "*_HR" to match "Bx50/ART1_HR"
+		baseName := filepath.Base(name)
+		matched, err = filepath.Match(pattern, baseName)
+		if err == nil && matched {
+			return true
+		}
+
+		// Try matching the pattern with simple string contains for wildcards
+		// This handles cases like "*_HR" matching "Bx50/ART1_HR"
+		if strings.Contains(pattern, "*") || strings.Contains(pattern, "?") {
+			// Convert glob pattern to regex-like matching
+			if matchGlobPattern(name, pattern) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// matchGlobPattern performs simple glob matching that works across path separators
+func matchGlobPattern(str, pattern string) bool {
+	// Handle simple suffix patterns like "*_HR"
+	if strings.HasPrefix(pattern, "*") && !strings.Contains(pattern[1:], "*") {
+		suffix := pattern[1:]
+		return strings.HasSuffix(str, suffix)
+	}
+
+	// Handle simple prefix patterns like "ECG*"
+	if strings.HasSuffix(pattern, "*") && !strings.Contains(pattern[:len(pattern)-1], "*") {
+		prefix := pattern[:len(pattern)-1]
+		return strings.HasPrefix(str, prefix)
+	}
+
+	// Handle patterns with * in the middle
+	if strings.Contains(pattern, "*") {
+		parts := strings.Split(pattern, "*")
+		if len(parts) == 2 {
+			// Pattern like "Bx50/*_HR"
+			return strings.HasPrefix(str, parts[0]) && strings.HasSuffix(str, parts[1])
+		}
+	}
+
+	// Fall back to exact match
+	return str == pattern
+}
+
+func processTracks(vf *vital.VitalFile, config *Config) (map[string]TrackInfo, error) {
+	tracks := make(map[string]TrackInfo)
+
+	// 트랙 필터링
+	selectedTracks := make([]string, 0)
+	if config.Tracks != "" {
+		selectedTracks = strings.Split(config.Tracks, ",")
+		for i := range selectedTracks {
+			selectedTracks[i] = strings.TrimSpace(selectedTracks[i])
+		}
+	}
+
+	// 패턴 필터링
+	trackPatterns := make([]string, 0)
+	if config.TrackPattern != "" {
+		trackPatterns = strings.Split(config.TrackPattern, ",")
+		for i := range trackPatterns {
+			trackPatterns[i] = strings.TrimSpace(trackPatterns[i])
+		}
+	}
+
+	count := 0
+	for _, sel := range selectTracks(vf, config) {
+		name, track := sel.name, sel.track
+		// 트랙 개수 제한 확인
+		if config.MaxTracks > 0 && count >= config.MaxTracks {
+			break
+		}
+
+		// 특정 트랙 필터링
+		if len(selectedTracks) > 0 {
+			found := false
+			for _, selectedTrack := range selectedTracks {
+				if selectedTrack == track.Name {
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+
+		// 패턴 필터링
+		if !matchesAnyPattern(track.Name, trackPatterns) {
+			continue
+		}
+
+		// 트랙 타입 필터링
+		if config.TrackType != "" {
+			typeMatch := false
+			switch strings.ToUpper(config.TrackType) {
+			case "WAVE":
+				typeMatch = track.Type == 1
+			case "NUMERIC":
+				typeMatch = track.Type == 2
+			case "STRING":
+				typeMatch = track.Type == 5
+			}
+			if !typeMatch {
+				continue
+			}
+		}
+
+		trackInfo := TrackInfo{
+			Name:        name,
+			Type:        track.Type,
+			TypeName:    getTypeName(track.Type),
+			Fmt:         track.Fmt,
+			Unit:        track.Unit,
+			SampleRate:  track.SRate,
+			Gain:        track.Gain,
+			Offset:      track.Offset,
+			MinDisplay:  track.Mindisp,
+			MaxDisplay:  track.Maxdisp,
+			Color:       track.Col,
+			MonitorType: track.Montype,
+			DeviceName:  track.DName,
+		}
+		if name != track.Name {
+			trackInfo.SourceName = track.Name
+		}
+		if config.aliases != nil {
+			if sig, ok := config.aliases.Lookup(track.Name); ok {
+				trackInfo.Signal = sig.Name
+			}
+		}
+		if track.Type != 5 {
+			convertUnit(&trackInfo, track, config)
+		}
+
+		// 레코드 데이터 (export 모드만)
+		// CSV/Parquet는 출력 시 트랙에서 직접 스트리밍하므로 여기서 모으지 않음
+		if wantsRecords(config) && !streamsRecords(config.Format) {
+			// 메모리 프리할당: 최대 필요 용량 사전 확보
+			expectedSize := track.NumRecs()
+			if config.MaxSamples > 0 && config.MaxSamples < expectedSize {
+				expectedSize = config.MaxSamples
+			}
+			records := make([]RecordInfo, 0, expectedSize)
+			err := eachRecord(track, config, func(rec vital.Rec) error {
+				rec = trackInfo.convert(track, rec)
+				records = append(records, RecordInfo{
+					Time:  rec.Dt,
+					Value: rec.Val,
+				})
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			trackInfo.Records = records
+			trackInfo.RecordsCount = len(records)
+		} else {
+			// Summary mode: still count total records
+			trackInfo.RecordsCount = track.NumRecs()
+		}
+		if config.Mode == ModeStats {
+			stats, err := track.Stats()
+			if err != nil {
+				return nil, fmt.Errorf("track %q: %w", track.Name, err)
+			}
+			trackInfo.Stats = &stats
+		}
+
+		tracks[name] = trackInfo
+		count++
+	}
+
+	return tracks, nil
+}
+
+// errStopRecords ends record iteration early once the sample limit or end
+// time is reached.
+var errStopRecords = errors.New("stop")
+
+// wantsRecords reports whether record data is part of the output at all.
+func wantsRecords(config *Config) bool {
+	return config.Mode == ModeExport
+}
+
+// streamsRecords reports whether a format writes records straight from the
+// tracks instead of from OutputData, so spilled tracks are never fully
+// paged back into memory.
+func streamsRecords(format string) bool {
+	return format == "csv" || format == "parquet"
+}
+
+// eachRecord calls fn for the records of track that pass the -max-samples,
+// -start-time and -end-time filters.
+func eachRecord(track *vital.Track, config *Config, fn func(vital.Rec) error) error {
+	i := 0
+	err := track.ForEachRec(func(rec vital.Rec) error {
+		if config.MaxSamples > 0 && i >= config.MaxSamples {
+			return errStopRecords
+		}
+		i++
+
+		// 시간 범위 필터링
+		if config.StartTime > 0 && rec.Dt < config.StartTime {
+			return nil
+		}
+		if config.EndTime > 0 && rec.Dt > config.EndTime {
+			return errStopRecords
+		}
+		return fn(rec)
+	})
+	if err == errStopRecords {
+		return nil
+	}
+	return err
+}
+
+func getTypeName(trackType uint8) string {
+	switch trackType {
+	case 1:
+		return "WAVE"
+	case 2:
+		return "NUMERIC"
+	case 5:
+		return "STRING"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func printParquetOutput(w io.Writer, output *OutputData, vf *vital.VitalFile, config *Config) error {
+	// Create Parquet writer with buffering
+	writer := bufio.NewWriterSize(w, 256*1024) // 256KB buffer
+	parquetWriter := parquet.NewGenericWriter[ParquetRow](writer)
+
+	if wantsRecords(config) {
+		// 트랙 단위로 행을 모아 기록 (전체 레코드를 한 번에 메모리에 올리지 않음)
+		var rows []ParquetRow
+		for trackName, track := range output.Tracks {
+			rows = rows[:0]
+			source := vf.Trks[track.sourceName()]
+			err := eachRecord(source, config, func(rec vital.Rec) error {
+				rec = track.convert(source, rec)
+				rows = append(rows, ParquetRow{
+					TrackName: trackName,
+					Timestamp: rec.Dt,
+					Value:     fmt.Sprintf("%v", rec.Val),
+					Unit:      track.Unit,
+				})
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if _, err := parquetWriter.Write(rows); err != nil {
+				return fmt.Errorf("failed to write Parquet data: %w", err)
+			}
+		}
+	}
+
+	if err := parquetWriter.Close(); err != nil {
+		return fmt.Errorf("failed to write Parquet data: %w", err)
+	}
+	return writer.Flush()
+}
+
+func printCSVOutput(w io.Writer, output *OutputData, vf *vital.VitalFile, config *Config) error {
+	// CSV writer with buffering for performance
+	writer := bufio.NewWriterSize(w, 256*1024) // 256KB buffer
+	csvWriter := csv.NewWriter(writer)
+
+	// Write header
+	header := []string{"track_name", "timestamp", "value", "unit"}
+	if err := csvWriter.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	// Write data rows
+	if wantsRecords(config) {
+		for trackName, track := range output.Tracks {
+			source := vf.Trks[track.sourceName()]
+			err := eachRecord(source, config, func(rec vital.Rec) error {
+				rec = track.convert(source, rec)
+				row := []string{
+					trackName,
+					fmt.Sprintf("%.6f", rec.Dt),
+					fmt.Sprintf("%v", rec.Val),
+					track.Unit,
+				}
+				if err := csvWriter.Write(row); err != nil {
+					return fmt.Errorf("failed to write CSV row: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return writer.Flush()
+}
+
+func printTextOutput(w io.Writer, output *OutputData, config *Config) {
+	if output.FileInfo != nil {
+		fmt.Fprintf(w, "=== File Information ===\n")
+		fmt.Fprintf(w, "Start Time: %f\n", output.FileInfo.StartTime)
+		fmt.Fprintf(w, "End Time: %f\n", output.FileInfo.EndTime)
+		fmt.Fprintf(w, "Duration: %.2f seconds\n", output.FileInfo.Duration)
+		fmt.Fprintf(w, "GMT Offset: %d\n", output.FileInfo.GMTOffset)
+		fmt.Fprintf(w, "Number of Tracks: %d\n", output.FileInfo.TracksCount)
+		fmt.Fprintf(w, "Number of Devices: %d\n", output.FileInfo.DevicesCount)
+		fmt.Fprintln(w)
+	}
+
+	if len(output.Devices) > 0 {
+		fmt.Fprintf(w, "=== Devices ===\n")
+		for _, name := range sortedKeys(output.Devices) {
+			device := output.Devices[name]
+			fmt.Fprintf(w, "- %s: %s (Port: %s)\n", name, device.TypeName, device.Port)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(output.Tracks) > 0 {
+		if config.Mode == ModeTracks {
+			fmt.Fprintf(w, "=== Available Tracks ===\n")
+			for _, name := range sortedKeys(output.Tracks) {
+				track := output.Tracks[name]
+				fmt.Fprintf(w, "- %s: %s (%s), Rate: %.1f Hz%s\n",
+					name, track.TypeName, track.Unit, track.SampleRate, aliasNote(track))
+			}
+		} else {
+			fmt.Fprintf(w, "=== Tracks ===\n")
+			for _, name := range sortedKeys(output.Tracks) {
+				track := output.Tracks[name]
+				fmt.Fprintf(w, "- %s: %s, Rate: %.1f Hz, Records: %d%s\n",
+					name, track.Unit, track.SampleRate, track.RecordsCount, aliasNote(track))
+				if track.Stats != nil {
+					printTrackStats(w, track.Stats)
+				}
+
+				if config.Verbose && len(track.Records) > 0 {
+					fmt.Fprintf(w, "  Sample data:\n")
+					for i, rec := range track.Records {
+						if i >= 3 {
+							break
+						}
+						fmt.Fprintf(w, "    [%d] Time: %.6f, Value: %v\n", i+1, rec.Time, rec.Value)
+					}
+				}
+			}
+		}
+	}
+}
+
+// aliasNote describes the canonical signal of a track in text output.
+func aliasNote(track TrackInfo) string {
+	switch {
+	case track.SourceName != "":
+		return fmt.Sprintf(" (source: %s)", track.SourceName)
+	case track.Signal != "":
+		return fmt.Sprintf(" [signal: %s]", track.Signal)
+	}
+	return ""
+}
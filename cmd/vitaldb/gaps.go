@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+// gapFlags holds the flags of the gaps command.
+type gapFlags struct {
+	MinGap    float64
+	Tolerance float64
+	Interval  float64
+}
+
+// GapReport is the output of the gaps command.
+type GapReport struct {
+	File   string      `json:"file"`
+	MinGap float64     `json:"min_gap"`
+	Tracks []TrackGaps `json:"tracks"`
+}
+
+// TrackGaps lists the segments of a track and the gaps between them that
+// are at least GapReport.MinGap long.
+type TrackGaps struct {
+	Track    string          `json:"track"`
+	Type     string          `json:"type"`
+	Coverage float64         `json:"coverage"` // 세그먼트 길이의 합 (초)
+	Segments []vital.Segment `json:"segments"`
+	Gaps     []GapInfo       `json:"gaps"`
+}
+
+// GapInfo is a pause in a track.
+type GapInfo struct {
+	Start    float64 `json:"start"`
+	End      float64 `json:"end"`
+	Duration float64 `json:"duration"`
+}
+
+func addGapFlags(fs *flag.FlagSet, config *Config) {
+	g := &config.Gaps
+	fs.Float64Var(&g.MinGap, "min-gap", 1, "보고할 최소 gap 길이 (초)")
+	fs.Float64Var(&g.Tolerance, "tolerance", 0, "WAVE 청크를 연속으로 볼 최대 시각 차이 (초, 0 = 샘플 주기의 절반)")
+	fs.Float64Var(&g.Interval, "interval", 0, "NUMERIC/STRING 레코드의 예상 간격 (초, 0 = 트랙의 중앙값 간격)")
+}
+
+// runGaps reports the gaps of the selected tracks.
+func runGaps(vf *vital.VitalFile, path string, config *Config, stdout io.Writer) error {
+	config.Mode = ModeTracks
+	tracks, err := processTracks(vf, config)
+	if err != nil {
+		return err
+	}
+	opts := vital.SegmentOptions{Tolerance: config.Gaps.Tolerance, Interval: config.Gaps.Interval}
+
+	report := GapReport{File: path, MinGap: config.Gaps.MinGap, Tracks: []TrackGaps{}}
+	for _, name := range sortedKeys(tracks) {
+		info := tracks[name]
+		segs, err := vf.Trks[info.sourceName()].Segments(opts)
+		if err != nil {
+			return fmt.Errorf("track %s: %w", name, err)
+		}
+		tg := TrackGaps{Track: name, Type: info.TypeName, Segments: segs, Gaps: []GapInfo{}}
+		for i, seg := range segs {
+			tg.Coverage += seg.Duration()
+			if i > 0 && seg.Gap > 0 && seg.Gap >= config.Gaps.MinGap {
+				tg.Gaps = append(tg.Gaps, GapInfo{Start: seg.Start - seg.Gap, End: seg.Start, Duration: seg.Gap})
+			}
+		}
+		report.Tracks = append(report.Tracks, tg)
+	}
+
+	switch config.Format {
+	case "json":
+		encoder := json.NewEncoder(stdout)
+		if !config.Compact {
+			encoder.SetIndent("", "  ")
+		}
+		return encoder.Encode(report)
+	case "csv":
+		return printGapsCSV(stdout, &report)
+	default:
+		fmt.Fprintf(stdout, "=== Gaps (>= %g s) ===\n", report.MinGap)
+		for _, tg := range report.Tracks {
+			fmt.Fprintf(stdout, "%s (%s): %d segments, coverage %.2f s, %d gaps\n",
+				tg.Track, tg.Type, len(tg.Segments), tg.Coverage, len(tg.Gaps))
+			for _, g := range tg.Gaps {
+				fmt.Fprintf(stdout, "  %.6f - %.6f (%.3f s)\n", g.Start, g.End, g.Duration)
+			}
+		}
+		return nil
+	}
+}
+
+// printGapsCSV writes one row per gap.
+func printGapsCSV(w io.Writer, report *GapReport) error {
+	writer := bufio.NewWriter(w)
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write([]string{"track_name", "gap_start", "gap_end", "duration"}); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, tg := range report.Tracks {
+		for _, g := range tg.Gaps {
+			row := []string{tg.Track, fmt.Sprintf("%.6f", g.Start), fmt.Sprintf("%.6f", g.End),
+				strconv.FormatFloat(g.Duration, 'g', -1, 64)}
+			if err := csvWriter.Write(row); err != nil {
+				return fmt.Errorf("failed to write CSV row: %w", err)
+			}
+		}
+	}
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return writer.Flush()
+}
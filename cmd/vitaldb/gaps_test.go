@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mdsung/vitaldb_processor/vital/vitaltest"
+)
+
+func TestGapsCommand(t *testing.T) {
+	// HR 1초 간격, 3-10초 사이 7초 공백과 12-13.5초 사이 1.5초 공백
+	b := vitaltest.New(1000)
+	b.Device(1, "Bx50", "Bx50", "COM1")
+	b.Track(vitaltest.Track{ID: 1, Type: vitaltest.TypeNumeric, Fmt: vitaltest.FmtFloat32, Name: "HR", Unit: "/min", DevID: 1})
+	for _, dt := range []float64{0, 1, 2, 10, 11, 13.5} {
+		b.Numeric(1, 1000+dt, float32(60))
+	}
+	path := filepath.Join(t.TempDir(), "gaps.vital")
+	if err := b.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := runCLI("gaps", "-quiet", "-format", "json", path)
+	if code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	var report GapReport
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Tracks) != 1 || len(report.Tracks[0].Segments) != 3 || len(report.Tracks[0].Gaps) != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+	if g := report.Tracks[0].Gaps[0]; g.Start != 1003 || g.End != 1010 || g.Duration != 7 {
+		t.Errorf("first gap = %+v", g)
+	}
+
+	code, stdout, _ = runCLI("gaps", "-quiet", "-min-gap", "5", "-format", "csv", path)
+	if want := "track_name,gap_start,gap_end,duration\nBx50/HR,1003.000000,1010.000000,7\n"; code != exitOK || stdout != want {
+		t.Errorf("csv: exit %d, output %q", code, stdout)
+	}
+
+	code, stdout, _ = runCLI("gaps", "-quiet", "-track-type", "WAVE", fixture)
+	if code != exitOK || !strings.HasPrefix(stdout, "=== Gaps (>= 1 s) ===\n") || strings.Contains(stdout, "HR") {
+		t.Errorf("text: exit %d, output %q", code, stdout)
+	}
+}
@@ -1,29 +1,42 @@
 package main
 
 import (
-	"bufio"
-	"encoding/csv"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
-	"path/filepath"
 	"runtime/pprof"
+	"sort"
 	"strings"
 
 	"github.com/mdsung/vitaldb_processor/vital"
-	"github.com/parquet-go/parquet-go"
-	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Exit codes returned by the CLI.
+const (
+	exitOK      = 0 // 성공
+	exitError   = 1 // 파일 읽기/쓰기 등 실행 오류
+	exitUsage   = 2 // 잘못된 명령, 플래그 또는 인자
+	exitInvalid = 3 // validate가 파일에서 문제를 발견함
+)
+
+// Mode selects which sections of a file processVitalFile reports.
+type Mode int
+
+const (
+	ModeExport  Mode = iota // 파일 정보, 디바이스, 트랙과 레코드
+	ModeInfo                // 파일 정보만
+	ModeTracks              // 트랙 메타데이터만
+	ModeDevices             // 디바이스만
+	ModeStats               // 파일 정보, 디바이스, 트랙별 통계
+	ModeSummary             // 파일 정보, 디바이스, 트랙 메타데이터와 레코드 수 (이전 -summary)
 )
 
 type Config struct {
+	Mode         Mode    // 출력할 정보 범위 (서브커맨드가 결정)
 	Format       string  // "csv", "parquet", "text", "json", or "msgpack"
 	Compact      bool    // Compact JSON (no indentation)
-	ListTracks   bool    // 트랙 목록만 출력
-	InfoOnly     bool    // 파일 정보만 출력
-	ListDevices  bool    // 디바이스 목록만 출력
-	Summary      bool    // 요약 정보만 출력
 	Tracks       string  // 특정 트랙들만 출력 (쉼표로 구분)
 	TrackPattern string  // 트랙 이름 패턴 필터 (glob 스타일: *, ?)
 	TrackType    string  // 트랙 타입 필터 ("WAVE", "NUMERIC", "STRING")
@@ -33,519 +46,534 @@ type Config struct {
 	EndTime      float64 // 종료 시간
 	Quiet        bool    // 조용한 모드
 	Verbose      bool    // 상세 모드
+	Strict       bool    // validate: 경고도 실패로 처리
 	CPUProfile   string  // CPU 프로파일 출력 파일
 	MemProfile   string  // 메모리 프로파일 출력 파일
+	MemoryBudget int64   // WAVE 샘플 메모리 한도 (MB, 0 = 무제한)
+	SpillDir     string  // 한도 초과 트랙을 내보낼 임시 디렉토리
+	Output       string  // 출력 파일 (split 시 디렉토리, "" = 표준 출력)
+	Compress     string  // 출력 압축 ("none", "gzip", "zstd")
+	SplitTracks  bool    // 트랙마다 별도 파일로 출력
+	CropStart    float64 // crop, overview: 구간 시작
+	CropEnd      float64 // crop, overview: 구간 끝 (포함하지 않음)
+	Relative     bool    // crop, overview: 시각을 파일 시작 기준 초로 해석
+	Anonymize    anonymizeFlags
+	Gaps         gapFlags
+	Timing       timingFlags
+	Overview     overviewFlags
+	Waves        waveFlags
+	Physio       physioFlags
+	Aliases      string // 트랙 별칭 표 파일
+	Signals      string // 표준 신호 이름으로 트랙 선택 (쉼표로 구분)
+	Units        string // 트랙=단위 변환 목록 (쉼표로 구분)
+	SignalUnits  bool   // -aliases 신호 단위로 변환
+
+	aliases     *vital.AliasTable // prepareFilters가 읽은 -aliases
+	unitTargets []unitTarget      // prepareFilters가 해석한 -units
+	warn        io.Writer         // 경고 출력 (nil = 출력 안 함)
 }
 
-type OutputData struct {
-	FileInfo *FileInfo             `json:"file_info,omitempty"`
-	Devices  map[string]DeviceInfo `json:"devices,omitempty"`
-	Tracks   map[string]TrackInfo  `json:"tracks,omitempty"`
+// command is a vitaldb subcommand. Every command takes a single .vital
+// file argument; batch and merge, which take many, are dispatched
+// separately.
+type command struct {
+	name    string
+	summary string
+	formats []string // 허용 출력 형식, 첫 번째가 기본값
+	split   bool     // -split-tracks 지원 여부
+	flags   func(fs *flag.FlagSet, config *Config)
+	check   func(config *Config) error // 파일을 읽기 전 플래그 조합 검사 (실패 시 exitUsage)
+	run     func(vf *vital.VitalFile, path string, config *Config, stdout io.Writer) error
 }
 
-type FileInfo struct {
-	StartTime    float64 `json:"dt_start"`
-	EndTime      float64 `json:"dt_end"`
-	Duration     float64 `json:"duration"`
-	GMTOffset    int16   `json:"gmt_offset"`
-	TracksCount  int     `json:"tracks_count"`
-	DevicesCount int     `json:"devices_count"`
+// commands lists the subcommands in the order shown by help.
+var commands = []*command{
+	{
+		name:    "info",
+		summary: "파일 정보(시간 범위, GMT 오프셋, 트랙/디바이스 수) 출력",
+		formats: reportFormats,
+		run:     reportCommand(ModeInfo),
+	},
+	{
+		name:    "tracks",
+		summary: "트랙 목록과 메타데이터 출력",
+		formats: reportFormats,
+		flags:   addFilterFlags,
+		run:     reportCommand(ModeTracks),
+	},
+	{
+		name:    "devices",
+		summary: "디바이스 목록 출력",
+		formats: reportFormats,
+		run:     reportCommand(ModeDevices),
+	},
+	{
+		name:    "stats",
+		summary: "파일 정보, 디바이스, 트랙별 통계 (범위, gap, 분포, 실제 샘플링 레이트)",
+		formats: statsFormats,
+		flags:   addFilterFlags,
+		run:     reportCommand(ModeStats),
+	},
+	{
+		name:    "gaps",
+		summary: "트랙별 연속 구간과 기준 이상의 공백(gap) 목록",
+		formats: []string{"text", "json", "csv"},
+		flags: func(fs *flag.FlagSet, config *Config) {
+			addGapFlags(fs, config)
+			addFilterFlags(fs, config)
+		},
+		run: runGaps,
+	},
+	{
+		name:    "timing",
+		summary: "WAVE 트랙의 세그먼트별 실제 샘플링 레이트, 겹침, 중복 청크",
+		formats: []string{"text", "json"},
+		flags: func(fs *flag.FlagSet, config *Config) {
+			addTimingFlags(fs, config)
+			addFilterFlags(fs, config)
+		},
+		run: runTiming,
+	},
+	{
+		name:    "overview",
+		summary: "WAVE/NUMERIC 트랙을 구간의 버킷(픽셀)마다 최소/최대 또는 LTTB로 축약한 그래프용 JSON",
+		formats: []string{"json"},
+		flags: func(fs *flag.FlagSet, config *Config) {
+			addOverviewFlags(fs, config)
+			addFilterFlags(fs, config)
+		},
+		run: runOverview,
+	},
+	{
+		name:    "export",
+		summary: "레코드 데이터 출력 (기본: 트랙당 3개 샘플)",
+		formats: exportFormats,
+		split:   true,
+		flags: func(fs *flag.FlagSet, config *Config) {
+			addFilterFlags(fs, config)
+			addExportFlags(fs, config, 3)
+		},
+		run: runExport,
+	},
+	{
+		name:    "convert",
+		summary: "모든 레코드를 다른 형식으로 변환 (export -max-samples 0과 동일)",
+		formats: exportFormats,
+		split:   true,
+		flags: func(fs *flag.FlagSet, config *Config) {
+			addFilterFlags(fs, config)
+			addExportFlags(fs, config, 0)
+		},
+		run: runExport,
+	},
+	{
+		name:    "crop",
+		summary: "시간 구간만 잘라 새 파일로 저장 (.vital 또는 내보내기 형식)",
+		formats: vitalFormats,
+		flags: func(fs *flag.FlagSet, config *Config) {
+			addCropFlags(fs, config)
+			addFilterFlags(fs, config)
+			fs.BoolVar(&config.Compact, "compact", false, "Compact JSON (들여쓰기 없음)")
+		},
+		check: checkVitalFilters,
+		run:   runCrop,
+	},
+	{
+		name:    "anonymize",
+		summary: "날짜 이동, 디바이스 정보 삭제, STRING 트랙 가림으로 비식별화 (.vital 또는 내보내기 형식)",
+		formats: vitalFormats,
+		flags: func(fs *flag.FlagSet, config *Config) {
+			addAnonymizeFlags(fs, config)
+			fs.BoolVar(&config.Compact, "compact", false, "Compact JSON (들여쓰기 없음)")
+		},
+		check: checkAnonymize,
+		run:   runAnonymize,
+	},
+	{
+		name:    "retime",
+		summary: "WAVE 트랙의 중복 청크 삭제, 겹침 제거, 연속 시간축 재구성 (.vital 또는 내보내기 형식)",
+		formats: vitalFormats,
+		flags: func(fs *flag.FlagSet, config *Config) {
+			addTimingFlags(fs, config)
+			fs.BoolVar(&config.Timing.EffectiveRate, "effective-rate", false, "SRate를 추정한 실제 샘플링 레이트로 변경")
+			fs.StringVar(&config.Timing.Report, "report", "", "적용한 보정 내역 JSON 파일")
+			fs.BoolVar(&config.Compact, "compact", false, "Compact JSON (들여쓰기 없음)")
+		},
+		run: runRetime,
+	},
+	{
+		name:    "ecg",
+		summary: "ECG R 피크 검출, 심박수 트랙 추가, 모니터 HR과 비교 (.vital 또는 내보내기 형식)",
+		formats: vitalFormats,
+		flags: func(fs *flag.FlagSet, config *Config) {
+			addPhysioFlags(fs, config, "ECG 트랙 (쉼표로 구분, 기본: 이름이 ECG로 시작하는 WAVE 트랙)")
+			fs.StringVar(&config.Physio.HR, "hr-track", "", "비교할 모니터 HR 트랙 (기본: 같은 디바이스의 HR)")
+			fs.Float64Var(&config.Physio.Window, "compare-window", 10, "모니터 HR 값마다 비교할 직전 구간 (초)")
+		},
+		run: runECG,
+	},
+	{
+		name:    "hrv",
+		summary: "R-R 간격의 창별 HRV(SDNN, RMSSD, pNN50, LF/HF, SD1/SD2) 트랙 추가 (.vital 또는 내보내기 형식)",
+		formats: vitalFormats,
+		flags: func(fs *flag.FlagSet, config *Config) {
+			addPhysioFlags(fs, config, "ECG WAVE 또는 박동별 R-R 간격 NUMERIC 트랙 (쉼표로 구분, 기본: 이름이 ECG로 시작하는 WAVE 트랙)")
+			hf := &config.Physio.HRV
+			fs.Float64Var(&hf.Window, "window", 300, "HRV 창 길이 (초)")
+			fs.Float64Var(&hf.Step, "step", 30, "창 간격 (초)")
+			fs.IntVar(&hf.MinIntervals, "min-intervals", 30, "창마다 필요한 최소 R-R 간격 수")
+			fs.Float64Var(&hf.MaxChange, "max-change", 0.2, "직전 간격과 이 비율 이상 다른 간격 제외 (이소성 박동, 음수 = 사용 안 함)")
+			fs.StringVar(&config.Physio.Table, "table", "", "창별 HRV 지표 CSV 파일")
+		},
+		run: runHRV,
+	},
+	{
+		name:    "art",
+		summary: "동맥압 파형 박동 분할, 박동별 SBP/DBP/MAP/PP, 창별 PPV, damped/flat 표시 트랙 추가 (.vital 또는 내보내기 형식)",
+		formats: vitalFormats,
+		flags: func(fs *flag.FlagSet, config *Config) {
+			addPhysioFlags(fs, config, "동맥압 트랙 (쉼표로 구분, 기본: 이름이 ART 또는 ABP로 시작하는 WAVE 트랙)")
+			af := &config.Physio.ART
+			fs.Float64Var(&af.PPVWindow, "ppv-window", 30, "PPV 창 길이 (초)")
+			fs.Float64Var(&af.PPVStep, "ppv-step", 5, "PPV 창 간격 (초)")
+			fs.Float64Var(&af.QualityWindow, "quality-window", 5, "damped/flat 판정 창 길이 (초)")
+			fs.Float64Var(&af.FlatRange, "flat-range", 5, "창 안의 범위가 이보다 작으면 flat (mmHg)")
+			fs.Float64Var(&af.DampedPP, "damped-pp", 20, "맥압 중앙값이 이보다 작으면 damped (mmHg)")
+		},
+		run: runART,
+	},
+	{
+		name:    "ppg",
+		summary: "PPG(pleth) 파형의 창별 맥박수, 맥파 진폭, PVI 트랙 추가 (.vital 또는 내보내기 형식)",
+		formats: vitalFormats,
+		flags: func(fs *flag.FlagSet, config *Config) {
+			addPhysioFlags(fs, config, "pleth 트랙 (쉼표로 구분, 기본: 이름이 PLETH 또는 PPG로 시작하는 WAVE 트랙)")
+			pf := &config.Physio.PPG
+			fs.Float64Var(&pf.Window, "window", 10, "맥박수, 진폭 창 길이 (초)")
+			fs.Float64Var(&pf.PVIWindow, "pvi-window", 30, "PVI 창 길이 (초)")
+			fs.Float64Var(&pf.Step, "step", 5, "창 간격 (초)")
+		},
+		run: runPPG,
+	},
+	{
+		name:    "sqi",
+		summary: "파형의 창별 신호 품질(SQI)과 아티팩트(flat, clip, 포화, 전기소작, spike), NUMERIC 범위 밖 값 표시 트랙 추가 (.vital 또는 내보내기 형식)",
+		formats: vitalFormats,
+		flags: func(fs *flag.FlagSet, config *Config) {
+			addPhysioFlags(fs, config, "검사할 트랙 (쉼표로 구분, 기본: 이름이 ECG, ART, ABP, PLETH, PPG로 시작하는 WAVE 트랙과 생리적 범위가 알려진 NUMERIC 트랙)")
+			sf := &config.Physio.SQI
+			fs.Float64Var(&sf.Window, "window", 2, "SQI 창 길이 (초)")
+			fs.Float64Var(&sf.FlatTime, "flat-time", 1, "같은 값이 이 시간 이상 이어지면 flat (초)")
+			fs.Float64Var(&sf.CauteryRatio, "cautery-ratio", 0.5, "창의 고주파 파워 비율이 이보다 크면 전기소작 잡음")
+			fs.Float64Var(&sf.SpikeFactor, "spike-factor", 3, "창의 범위가 구간 창 범위 중앙값의 이 배수보다 크면 spike")
+		},
+		run: runSQI,
+	},
+	{
+		name:    "validate",
+		summary: "파일 구조 검사 (문제 발견 시 종료 코드 3)",
+		formats: []string{"text", "json"},
+		flags: func(fs *flag.FlagSet, config *Config) {
+			fs.BoolVar(&config.Strict, "strict", false, "경고도 검사 실패로 처리")
+		},
+		run: runValidate,
+	},
 }
 
-type DeviceInfo struct {
-	Name     string `json:"name"`
-	TypeName string `json:"type_name"`
-	Port     string `json:"port"`
-}
-
-type TrackInfo struct {
-	Name         string       `json:"name"`
-	Type         uint8        `json:"type"`
-	TypeName     string       `json:"type_name"`
-	Fmt          uint8        `json:"fmt"`
-	Unit         string       `json:"unit"`
-	SampleRate   float32      `json:"sample_rate"`
-	Gain         float64      `json:"gain"`
-	Offset       float64      `json:"offset"`
-	MinDisplay   float32      `json:"min_display"`
-	MaxDisplay   float32      `json:"max_display"`
-	Color        uint32       `json:"color"`
-	MonitorType  uint8        `json:"monitor_type"`
-	DeviceName   string       `json:"device_name"`
-	RecordsCount int          `json:"records_count"`
-	Records      []RecordInfo `json:"records,omitempty"`
-}
-
-type RecordInfo struct {
-	Time  float64     `json:"dt"`
-	Value interface{} `json:"val"`
-}
-
-// ParquetRow represents a single row in Parquet output
-type ParquetRow struct {
-	TrackName string  `parquet:"track_name,snappy"`
-	Timestamp float64 `parquet:"timestamp,snappy"`
-	Value     string  `parquet:"value,snappy"`
-	Unit      string  `parquet:"unit,snappy"`
-}
+var (
+	reportFormats = []string{"text", "json", "msgpack"}
+	exportFormats = []string{"csv", "parquet", "text", "json", "msgpack"}
+)
 
 func main() {
-	config := parseFlags()
-
-	if len(flag.Args()) < 1 {
-		fmt.Fprintf(os.Stderr, "Usage: %s [options] <vital_file_path>\n", os.Args[0])
-		flag.PrintDefaults()
-		os.Exit(1)
-	}
-
-	// CPU 프로파일링 시작
-	if config.CPUProfile != "" {
-		f, err := os.Create(config.CPUProfile)
-		if err != nil {
-			log.Fatal("CPU 프로파일 생성 실패:", err)
-		}
-		defer f.Close()
-		if err := pprof.StartCPUProfile(f); err != nil {
-			log.Fatal("CPU 프로파일 시작 실패:", err)
-		}
-		defer pprof.StopCPUProfile()
-	}
-
-	filepath := flag.Args()[0]
-
-	if !config.Quiet {
-		fmt.Fprintf(os.Stderr, "Reading VitalDB file: %s\n", filepath)
-	}
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
 
-	vf, err := vital.NewVitalFile(filepath)
-	if err != nil {
-		log.Fatal(err)
+// run executes the command line args and returns the process exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return exitUsage
 	}
 
-	output := processVitalFile(vf, config)
-
-	// 출력 형식에 따라 처리
-	switch config.Format {
-	case "csv":
-		if err := printCSVOutput(output, config); err != nil {
-			log.Fatal(err)
-		}
-	case "parquet":
-		if err := printParquetOutput(output, config); err != nil {
-			log.Fatal(err)
-		}
-	case "json":
-		encoder := json.NewEncoder(os.Stdout)
-		if !config.Compact {
-			encoder.SetIndent("", "  ")
-		}
-		if err := encoder.Encode(output); err != nil {
-			log.Fatal(err)
-		}
-	case "msgpack":
-		// 버퍼링된 writer 사용 (syscall 오버헤드 감소)
-		writer := bufio.NewWriterSize(os.Stdout, 256*1024) // 256KB 버퍼
-		encoder := msgpack.NewEncoder(writer)
-		if err := encoder.Encode(output); err != nil {
-			log.Fatal(err)
-		}
-		if err := writer.Flush(); err != nil {
-			log.Fatal(err)
+	switch name := args[0]; {
+	case name == "help" || name == "-h" || name == "-help" || name == "--help":
+		if len(args) > 1 {
+			switch args[1] {
+			case "batch":
+				fs, _, _ := newBatchFlagSet(stdout)
+				fs.Usage()
+				return exitOK
+			case "merge":
+				fs, _ := newMergeFlagSet(stdout)
+				fs.Usage()
+				return exitOK
+			}
+			if cmd := lookupCommand(args[1]); cmd != nil {
+				fs, _ := newFlagSet(cmd, stdout)
+				fs.Usage()
+				return exitOK
+			}
+			fmt.Fprintf(stderr, "unknown command %q\n\n", args[1])
+			printUsage(stderr)
+			return exitUsage
 		}
-	case "text":
-		printTextOutput(output, config)
+		printUsage(stdout)
+		return exitOK
+	case name == "batch":
+		return runBatch(args[1:], stdout, stderr)
+	case name == "merge":
+		return runMerge(args[1:], stdout, stderr)
+	case lookupCommand(name) != nil:
+		return runCommand(lookupCommand(name), args[1:], stdout, stderr)
+	case isLegacyInvocation(args):
+		return runLegacy(args, stdout, stderr)
 	default:
-		log.Fatalf("Unknown format: %s. Supported formats: csv, parquet, text, json, msgpack", config.Format)
+		fmt.Fprintf(stderr, "unknown command %q\n\n", name)
+		printUsage(stderr)
+		return exitUsage
 	}
+}
 
-	// 메모리 프로파일링
-	if config.MemProfile != "" {
-		f, err := os.Create(config.MemProfile)
-		if err != nil {
-			log.Fatal("메모리 프로파일 생성 실패:", err)
-		}
-		defer f.Close()
-		if err := pprof.WriteHeapProfile(f); err != nil {
-			log.Fatal("메모리 프로파일 작성 실패:", err)
+func lookupCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
 		}
 	}
+	return nil
 }
 
-func parseFlags() *Config {
-	config := &Config{}
-
-	flag.StringVar(&config.Format, "format", "csv", "출력 형식 (csv, parquet, text, json, msgpack)")
-	flag.BoolVar(&config.Compact, "compact", false, "Compact JSON (들여쓰기 없음)")
-	flag.BoolVar(&config.ListTracks, "list-tracks", false, "트랙 목록만 출력")
-	flag.BoolVar(&config.InfoOnly, "info-only", false, "파일 정보만 출력")
-	flag.BoolVar(&config.ListDevices, "list-devices", false, "디바이스 목록만 출력")
-	flag.BoolVar(&config.Summary, "summary", false, "요약 정보만 출력")
-	flag.StringVar(&config.Tracks, "tracks", "", "특정 트랙들만 출력 (쉼표로 구분)")
-	flag.StringVar(&config.TrackPattern, "track-pattern", "", "트랙 이름 패턴 필터 (glob 스타일: ECG*, *_II, 쉼표로 구분)")
-	flag.StringVar(&config.TrackType, "track-type", "", "트랙 타입 필터 (WAVE, NUMERIC, STRING)")
-	flag.IntVar(&config.MaxTracks, "max-tracks", 0, "최대 트랙 개수 제한 (0 = 무제한)")
-	flag.IntVar(&config.MaxSamples, "max-samples", 3, "샘플 데이터 최대 개수")
-	flag.Float64Var(&config.StartTime, "start-time", 0, "시작 시간")
-	flag.Float64Var(&config.EndTime, "end-time", 0, "종료 시간 (0 = 파일 끝까지)")
-	flag.BoolVar(&config.Quiet, "quiet", false, "조용한 모드 (에러만 출력)")
-	flag.BoolVar(&config.Verbose, "verbose", false, "상세 모드")
-	flag.StringVar(&config.CPUProfile, "cpuprofile", "", "CPU 프로파일 출력 파일")
-	flag.StringVar(&config.MemProfile, "memprofile", "", "메모리 프로파일 출력 파일")
-
-	flag.Parse()
-	return config
+// isLegacyInvocation reports whether args use the original flag form
+// ("vitaldb [options] <file>"), which is kept as an alias for export.
+func isLegacyInvocation(args []string) bool {
+	if strings.HasPrefix(args[0], "-") || strings.HasSuffix(args[0], ".vital") {
+		return true
+	}
+	_, err := os.Stat(args[0])
+	return err == nil
 }
 
-func processVitalFile(vf *vital.VitalFile, config *Config) *OutputData {
-	output := &OutputData{}
-
-	// 파일 정보
-	if !config.ListTracks && !config.ListDevices {
-		output.FileInfo = &FileInfo{
-			StartTime:    vf.DtStart,
-			EndTime:      vf.DtEnd,
-			Duration:     vf.DtEnd - vf.DtStart,
-			GMTOffset:    vf.Dgmt,
-			TracksCount:  len(vf.Trks),
-			DevicesCount: len(vf.Devs),
-		}
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: vitaldb <command> [flags] <vital_file_path>\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", cmd.name, cmd.summary)
 	}
+	fmt.Fprintf(w, "  %-9s %s\n", "batch", batchSummary)
+	fmt.Fprintf(w, "  %-9s %s\n", "merge", mergeSummary)
+	fmt.Fprintf(w, "\n'vitaldb help <command>' 또는 'vitaldb <command> -h'로 명령별 플래그를 확인합니다.\n")
+	fmt.Fprintf(w, "이전 형식 'vitaldb [options] <vital_file_path>'은 export와 동일하게 동작합니다.\n")
+}
 
-	// 디바이스 정보
-	if !config.InfoOnly && !config.ListTracks {
-		output.Devices = make(map[string]DeviceInfo)
-		for name, device := range vf.Devs {
-			output.Devices[name] = DeviceInfo{
-				Name:     device.Name,
-				TypeName: device.TypeName,
-				Port:     device.Port,
-			}
-		}
+// newFlagSet returns the flag set of a command and the Config it fills.
+func newFlagSet(cmd *command, output io.Writer) (*flag.FlagSet, *Config) {
+	config := &Config{}
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&config.Format, "format", cmd.formats[0], "출력 형식 ("+strings.Join(cmd.formats, ", ")+")")
+	addLoadFlags(fs, config)
+	addOutputFlags(fs, config, cmd.split)
+	if cmd.flags != nil {
+		cmd.flags(fs, config)
 	}
-
-	// 트랙 정보
-	if !config.InfoOnly && !config.ListDevices {
-		output.Tracks = processTracks(vf, config)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: vitaldb %s [flags] <vital_file_path>\n\n%s\n\nFlags:\n", cmd.name, cmd.summary)
+		fs.PrintDefaults()
 	}
-
-	return output
+	return fs, config
 }
 
-// matchesAnyPattern checks if a name matches any of the provided glob patterns.
-// Returns true if patterns is empty (no filtering) or if name matches at least one pattern.
-// Supports wildcards (* and ?) that can match across path separators.
-func matchesAnyPattern(name string, patterns []string) bool {
-	// No patterns = no filtering, match everything
-	if len(patterns) == 0 {
-		return true
-	}
-
-	// Check if name matches any pattern
-	for _, pattern := range patterns {
-		// First try filepath.Match for exact path matching
-		matched, err := filepath.Match(pattern, name)
-		if err == nil && matched {
-			return true
-		}
-
-		// Also try matching against just the base name (after last /)
-		// This allows patterns like "*_HR" to match "Bx50/ART1_HR"
-		baseName := filepath.Base(name)
-		matched, err = filepath.Match(pattern, baseName)
-		if err == nil && matched {
-			return true
-		}
-
-		// Try matching the pattern with simple string contains for wildcards
-		// This handles cases like "*_HR" matching "Bx50/ART1_HR"
-		if strings.Contains(pattern, "*") || strings.Contains(pattern, "?") {
-			// Convert glob pattern to regex-like matching
-			if matchGlobPattern(name, pattern) {
-				return true
-			}
+func runCommand(cmd *command, args []string, stdout, stderr io.Writer) int {
+	fs, config := newFlagSet(cmd, stderr)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
 		}
+		return exitUsage
 	}
-
-	return false
-}
-
-// matchGlobPattern performs simple glob matching that works across path separators
-func matchGlobPattern(str, pattern string) bool {
-	// Handle simple suffix patterns like "*_HR"
-	if strings.HasPrefix(pattern, "*") && !strings.Contains(pattern[1:], "*") {
-		suffix := pattern[1:]
-		return strings.HasSuffix(str, suffix)
+	if fs.NArg() != 1 {
+		fmt.Fprintf(stderr, "vitaldb %s: expected exactly one .vital file\n\n", cmd.name)
+		fs.Usage()
+		return exitUsage
 	}
-
-	// Handle simple prefix patterns like "ECG*"
-	if strings.HasSuffix(pattern, "*") && !strings.Contains(pattern[:len(pattern)-1], "*") {
-		prefix := pattern[:len(pattern)-1]
-		return strings.HasPrefix(str, prefix)
+	if err := prepareOutput(fs, config, cmd.formats); err != nil {
+		fmt.Fprintf(stderr, "vitaldb %s: %v\n", cmd.name, err)
+		return exitUsage
 	}
-
-	// Handle patterns with * in the middle
-	if strings.Contains(pattern, "*") {
-		parts := strings.Split(pattern, "*")
-		if len(parts) == 2 {
-			// Pattern like "Bx50/*_HR"
-			return strings.HasPrefix(str, parts[0]) && strings.HasSuffix(str, parts[1])
+	if err := prepareFilters(config); err != nil {
+		fmt.Fprintf(stderr, "vitaldb %s: %v\n", cmd.name, err)
+		return exitUsage
+	}
+	if cmd.check != nil {
+		if err := cmd.check(config); err != nil {
+			fmt.Fprintf(stderr, "vitaldb %s: %v\n", cmd.name, err)
+			return exitUsage
 		}
 	}
-
-	// Fall back to exact match
-	return str == pattern
+	return execute(cmd.name, cmd.run, fs.Arg(0), config, stdout, stderr)
 }
 
-func processTracks(vf *vital.VitalFile, config *Config) map[string]TrackInfo {
-	tracks := make(map[string]TrackInfo)
-
-	// 트랙 필터링
-	selectedTracks := make([]string, 0)
-	if config.Tracks != "" {
-		selectedTracks = strings.Split(config.Tracks, ",")
-		for i := range selectedTracks {
-			selectedTracks[i] = strings.TrimSpace(selectedTracks[i])
+// runLegacy handles "vitaldb [options] <file>". The mode flags of the
+// original CLI select the matching subcommand's output.
+func runLegacy(args []string, stdout, stderr io.Writer) int {
+	config := &Config{}
+	fs := flag.NewFlagSet("vitaldb", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&config.Format, "format", "csv", "출력 형식 (csv, parquet, text, json, msgpack)")
+	addLoadFlags(fs, config)
+	addOutputFlags(fs, config, true)
+	addFilterFlags(fs, config)
+	addExportFlags(fs, config, 3)
+	var listTracks, infoOnly, listDevices, summary bool
+	fs.BoolVar(&listTracks, "list-tracks", false, "트랙 목록만 출력 (= vitaldb tracks)")
+	fs.BoolVar(&infoOnly, "info-only", false, "파일 정보만 출력 (= vitaldb info)")
+	fs.BoolVar(&listDevices, "list-devices", false, "디바이스 목록만 출력 (= vitaldb devices)")
+	fs.BoolVar(&summary, "summary", false, "요약 정보만 출력 (파일 정보, 디바이스, 트랙별 레코드 수)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: vitaldb [options] <vital_file_path>\n\n")
+		fmt.Fprintf(fs.Output(), "이전 형식의 명령줄로, vitaldb export와 같습니다. 'vitaldb help'로 서브커맨드를 확인합니다.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
 		}
+		return exitUsage
+	}
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return exitUsage
 	}
 
-	// 패턴 필터링
-	trackPatterns := make([]string, 0)
-	if config.TrackPattern != "" {
-		trackPatterns = strings.Split(config.TrackPattern, ",")
-		for i := range trackPatterns {
-			trackPatterns[i] = strings.TrimSpace(trackPatterns[i])
-		}
+	// 여러 모드 플래그가 함께 주어지면 아래 순서로 하나만 적용
+	switch {
+	case listTracks:
+		config.Mode = ModeTracks
+	case infoOnly:
+		config.Mode = ModeInfo
+	case listDevices:
+		config.Mode = ModeDevices
+	case summary:
+		config.Mode = ModeSummary
+	}
+	if err := prepareOutput(fs, config, exportFormats); err != nil {
+		fmt.Fprintf(stderr, "vitaldb: %v\n", err)
+		return exitUsage
 	}
+	if err := prepareFilters(config); err != nil {
+		fmt.Fprintf(stderr, "vitaldb: %v\n", err)
+		return exitUsage
+	}
+	return execute("vitaldb", runExport, fs.Arg(0), config, stdout, stderr)
+}
 
-	count := 0
-	for name, track := range vf.Trks {
-		// 트랙 개수 제한 확인
-		if config.MaxTracks > 0 && count >= config.MaxTracks {
-			break
+// execute loads path, runs fn on it and maps the outcome to an exit code.
+func execute(name string, fn func(*vital.VitalFile, string, *Config, io.Writer) error, path string, config *Config, stdout, stderr io.Writer) int {
+	if config.CPUProfile != "" {
+		f, err := os.Create(config.CPUProfile)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: CPU 프로파일 생성 실패: %v\n", name, err)
+			return exitError
 		}
-
-		// 특정 트랙 필터링
-		if len(selectedTracks) > 0 {
-			found := false
-			for _, selectedTrack := range selectedTracks {
-				if selectedTrack == name {
-					found = true
-					break
-				}
-			}
-			if !found {
-				continue
-			}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			fmt.Fprintf(stderr, "%s: CPU 프로파일 시작 실패: %v\n", name, err)
+			return exitError
 		}
+		defer pprof.StopCPUProfile()
+	}
 
-		// 패턴 필터링
-		if !matchesAnyPattern(name, trackPatterns) {
-			continue
+	config.warn = stderr
+	if !config.Quiet {
+		fmt.Fprintf(stderr, "Reading VitalDB file: %s\n", path)
+	}
+	vf, err := vital.NewVitalFileWithOptions(path, vital.LoadOptions{
+		MemoryBudget: config.MemoryBudget << 20,
+		SpillDir:     config.SpillDir,
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", name, err)
+		// 파일은 있으나 .vital로 읽을 수 없는 경우 validate는 검사 실패로 보고
+		if _, statErr := os.Stat(path); statErr == nil && name == "validate" {
+			return exitInvalid
 		}
+		return exitError
+	}
+	defer vf.Close()
 
-		// 트랙 타입 필터링
-		if config.TrackType != "" {
-			typeMatch := false
-			switch strings.ToUpper(config.TrackType) {
-			case "WAVE":
-				typeMatch = track.Type == 1
-			case "NUMERIC":
-				typeMatch = track.Type == 2
-			case "STRING":
-				typeMatch = track.Type == 5
-			}
-			if !typeMatch {
-				continue
-			}
+	if err := writeTo(stdout, vf, path, config, fn); err != nil {
+		if errors.Is(err, errInvalid) {
+			return exitInvalid
 		}
+		fmt.Fprintf(stderr, "%s: %v\n", name, err)
+		return exitError
+	}
 
-		trackInfo := TrackInfo{
-			Name:        track.Name,
-			Type:        track.Type,
-			TypeName:    getTypeName(track.Type),
-			Fmt:         track.Fmt,
-			Unit:        track.Unit,
-			SampleRate:  track.SRate,
-			Gain:        track.Gain,
-			Offset:      track.Offset,
-			MinDisplay:  track.Mindisp,
-			MaxDisplay:  track.Maxdisp,
-			Color:       track.Col,
-			MonitorType: track.Montype,
-			DeviceName:  track.DName,
+	if config.MemProfile != "" {
+		f, err := os.Create(config.MemProfile)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: 메모리 프로파일 생성 실패: %v\n", name, err)
+			return exitError
 		}
-
-		// 레코드 데이터 (요약 모드가 아닌 경우만)
-		if !config.Summary && !config.ListTracks {
-			// 메모리 프리할당: 최대 필요 용량 사전 확보
-			expectedSize := len(track.Recs)
-			if config.MaxSamples > 0 && config.MaxSamples < expectedSize {
-				expectedSize = config.MaxSamples
-			}
-			records := make([]RecordInfo, 0, expectedSize)
-
-			for i, rec := range track.Recs {
-				if config.MaxSamples > 0 && i >= config.MaxSamples {
-					break
-				}
-
-				// 시간 범위 필터링
-				if config.StartTime > 0 && rec.Dt < config.StartTime {
-					continue
-				}
-				if config.EndTime > 0 && rec.Dt > config.EndTime {
-					break
-				}
-
-				records = append(records, RecordInfo{
-					Time:  rec.Dt,
-					Value: rec.Val,
-				})
-			}
-			trackInfo.Records = records
-			trackInfo.RecordsCount = len(records)
-		} else {
-			// Summary mode: still count total records
-			trackInfo.RecordsCount = len(track.Recs)
+		defer f.Close()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			fmt.Fprintf(stderr, "%s: 메모리 프로파일 작성 실패: %v\n", name, err)
+			return exitError
 		}
-
-		tracks[name] = trackInfo
-		count++
 	}
-
-	return tracks
+	return exitOK
 }
 
-func getTypeName(trackType uint8) string {
-	switch trackType {
-	case 1:
-		return "WAVE"
-	case 2:
-		return "NUMERIC"
-	case 5:
-		return "STRING"
-	default:
-		return "UNKNOWN"
-	}
+// addLoadFlags registers the flags that control how a file is loaded.
+func addLoadFlags(fs *flag.FlagSet, config *Config) {
+	fs.BoolVar(&config.Quiet, "quiet", false, "조용한 모드 (에러만 출력)")
+	fs.Int64Var(&config.MemoryBudget, "memory-budget", 0, "WAVE 샘플 메모리 한도 MB (초과 시 디스크로 내보냄, 0 = 무제한)")
+	fs.StringVar(&config.SpillDir, "spill-dir", "", "메모리 한도 초과 시 사용할 임시 디렉토리 (기본: 시스템 임시 디렉토리)")
 }
 
-func printParquetOutput(output *OutputData, config *Config) error {
-	// Collect all rows first
-	var rows []ParquetRow
-
-	if output.Tracks != nil {
-		for trackName, track := range output.Tracks {
-			for _, record := range track.Records {
-				// Convert value to string
-				valueStr := fmt.Sprintf("%v", record.Value)
-
-				rows = append(rows, ParquetRow{
-					TrackName: trackName,
-					Timestamp: record.Time,
-					Value:     valueStr,
-					Unit:      track.Unit,
-				})
-			}
-		}
-	}
-
-	// Create Parquet writer with buffering
-	writer := bufio.NewWriterSize(os.Stdout, 256*1024) // 256KB buffer
-	defer writer.Flush()
-
-	parquetWriter := parquet.NewGenericWriter[ParquetRow](writer)
-	defer parquetWriter.Close()
-
-	// Write all rows at once for better performance
-	if _, err := parquetWriter.Write(rows); err != nil {
-		return fmt.Errorf("failed to write Parquet data: %w", err)
-	}
-
-	return nil
+// addFilterFlags registers the track selection flags.
+func addFilterFlags(fs *flag.FlagSet, config *Config) {
+	fs.StringVar(&config.Tracks, "tracks", "", "특정 트랙들만 출력 (쉼표로 구분)")
+	fs.StringVar(&config.TrackPattern, "track-pattern", "", "트랙 이름 패턴 필터 (glob 스타일: ECG*, *_II, 쉼표로 구분)")
+	fs.StringVar(&config.TrackType, "track-type", "", "트랙 타입 필터 (WAVE, NUMERIC, STRING)")
+	fs.IntVar(&config.MaxTracks, "max-tracks", 0, "최대 트랙 개수 제한 (0 = 무제한)")
+	addAliasFlags(fs, config)
 }
 
-func printCSVOutput(output *OutputData, config *Config) error {
-	// CSV writer with buffering for performance
-	writer := bufio.NewWriterSize(os.Stdout, 256*1024) // 256KB buffer
-	csvWriter := csv.NewWriter(writer)
-	defer func() {
-		csvWriter.Flush()
-		writer.Flush()
-	}()
-
-	// Write header
-	header := []string{"track_name", "timestamp", "value", "unit"}
-	if err := csvWriter.Write(header); err != nil {
-		return fmt.Errorf("failed to write CSV header: %w", err)
-	}
-
-	// Write data rows
-	if output.Tracks != nil {
-		for trackName, track := range output.Tracks {
-			for _, record := range track.Records {
-				// Convert value to string
-				valueStr := fmt.Sprintf("%v", record.Value)
-
-				row := []string{
-					trackName,
-					fmt.Sprintf("%.6f", record.Time),
-					valueStr,
-					track.Unit,
-				}
-
-				if err := csvWriter.Write(row); err != nil {
-					return fmt.Errorf("failed to write CSV row: %w", err)
-				}
-			}
-		}
-	}
-
-	return nil
+// addExportFlags registers the record output flags of export and convert.
+func addExportFlags(fs *flag.FlagSet, config *Config, maxSamples int) {
+	addRecordFlags(fs, config, maxSamples)
+	fs.StringVar(&config.CPUProfile, "cpuprofile", "", "CPU 프로파일 출력 파일")
+	fs.StringVar(&config.MemProfile, "memprofile", "", "메모리 프로파일 출력 파일")
 }
 
-func printTextOutput(output *OutputData, config *Config) {
-	if output.FileInfo != nil {
-		fmt.Printf("=== File Information ===\n")
-		fmt.Printf("Start Time: %f\n", output.FileInfo.StartTime)
-		fmt.Printf("End Time: %f\n", output.FileInfo.EndTime)
-		fmt.Printf("Duration: %.2f seconds\n", output.FileInfo.Duration)
-		fmt.Printf("GMT Offset: %d\n", output.FileInfo.GMTOffset)
-		fmt.Printf("Number of Tracks: %d\n", output.FileInfo.TracksCount)
-		fmt.Printf("Number of Devices: %d\n", output.FileInfo.DevicesCount)
-		fmt.Println()
-	}
+// addRecordFlags registers the flags that select and format records.
+func addRecordFlags(fs *flag.FlagSet, config *Config, maxSamples int) {
+	fs.BoolVar(&config.Compact, "compact", false, "Compact JSON (들여쓰기 없음)")
+	fs.IntVar(&config.MaxSamples, "max-samples", maxSamples, "트랙당 레코드 최대 개수 (0 = 전체)")
+	fs.Float64Var(&config.StartTime, "start-time", 0, "시작 시간")
+	fs.Float64Var(&config.EndTime, "end-time", 0, "종료 시간 (0 = 파일 끝까지)")
+	fs.BoolVar(&config.Verbose, "verbose", false, "상세 모드")
+	addUnitFlags(fs, config)
+	addWaveFlags(fs, config)
+}
 
-	if output.Devices != nil && len(output.Devices) > 0 {
-		fmt.Printf("=== Devices ===\n")
-		for name, device := range output.Devices {
-			fmt.Printf("- %s: %s (Port: %s)\n", name, device.TypeName, device.Port)
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
 		}
-		fmt.Println()
 	}
+	return false
+}
 
-	if output.Tracks != nil && len(output.Tracks) > 0 {
-		if config.ListTracks {
-			fmt.Printf("=== Available Tracks ===\n")
-			for name, track := range output.Tracks {
-				fmt.Printf("- %s: %s (%s), Rate: %.1f Hz\n",
-					name, track.TypeName, track.Unit, track.SampleRate)
-			}
-		} else {
-			fmt.Printf("=== Tracks ===\n")
-			for name, track := range output.Tracks {
-				fmt.Printf("- %s: %s, Rate: %.1f Hz, Records: %d\n",
-					name, track.Unit, track.SampleRate, len(track.Records))
-
-				if config.Verbose && len(track.Records) > 0 {
-					fmt.Printf("  Sample data:\n")
-					for i, rec := range track.Records {
-						if i >= 3 {
-							break
-						}
-						fmt.Printf("    [%d] Time: %.6f, Value: %v\n", i+1, rec.Time, rec.Value)
-					}
-				}
-			}
-		}
+// sortedKeys returns the keys of m in ascending order so that text output
+// is stable.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
+	return keys
 }
@@ -152,7 +152,10 @@ func TestTrackPatternFiltering(t *testing.T) {
 			}
 
 			// Process tracks with pattern filtering
-			tracks := processTracks(vf, config)
+			tracks, err := processTracks(vf, config)
+			if err != nil {
+				t.Fatal(err)
+			}
 
 			// Check track count
 			trackCount := len(tracks)
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+const mergeSummary = "분할 저장된 파일들을 하나의 기록으로 합침 (.vital 또는 내보내기 형식)"
+
+// vitalFormats are the formats of commands that produce a new recording:
+// a .vital file or any export format.
+var vitalFormats = append([]string{"vital"}, exportFormats...)
+
+func newMergeFlagSet(output io.Writer) (*flag.FlagSet, *Config) {
+	config := &Config{}
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&config.Format, "format", vitalFormats[0], "출력 형식 ("+strings.Join(vitalFormats, ", ")+")")
+	addLoadFlags(fs, config)
+	addOutputFlags(fs, config, false)
+	addFilterFlags(fs, config)
+	addRecordFlags(fs, config, 0)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: vitaldb merge [flags] <file|dir|glob|@list>...\n\n%s\n\n", mergeSummary)
+		fmt.Fprintf(fs.Output(), "파일은 시작 시간 순으로 합치며, 겹치는 구간은 앞 파일의 데이터를 사용합니다.\n")
+		fmt.Fprintf(fs.Output(), "트랙 필터와 레코드 옵션은 내보내기 형식에만 적용됩니다. -format vital에서 트랙 필터는\n")
+		fmt.Fprintf(fs.Output(), "-filter, -resample, -artifacts를 적용할 트랙만 고르며, 파형 옵션 없이 쓰면 사용법 오류입니다.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	return fs, config
+}
+
+// runMerge loads the input files, merges them with vital.Merge and writes
+// the result.
+func runMerge(args []string, stdout, stderr io.Writer) int {
+	fs, config := newMergeFlagSet(stderr)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintf(stderr, "vitaldb merge: no input files\n\n")
+		fs.Usage()
+		return exitUsage
+	}
+	if err := prepareOutput(fs, config, vitalFormats); err != nil {
+		fmt.Fprintf(stderr, "vitaldb merge: %v\n", err)
+		return exitUsage
+	}
+	if err := prepareFilters(config); err != nil {
+		fmt.Fprintf(stderr, "vitaldb merge: %v\n", err)
+		return exitUsage
+	}
+	if err := checkVitalFilters(config); err != nil {
+		fmt.Fprintf(stderr, "vitaldb merge: %v\n", err)
+		return exitUsage
+	}
+
+	inputs, err := expandInputs(fs.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "vitaldb merge: %v\n", err)
+		return exitError
+	}
+	files := make([]*vital.VitalFile, 0, len(inputs))
+	defer func() {
+		for _, vf := range files {
+			vf.Close()
+		}
+	}()
+	for _, path := range inputs {
+		if !config.Quiet {
+			fmt.Fprintf(stderr, "Reading VitalDB file: %s\n", path)
+		}
+		vf, err := vital.NewVitalFileWithOptions(path, vital.LoadOptions{
+			MemoryBudget: config.MemoryBudget << 20,
+			SpillDir:     config.SpillDir,
+		})
+		if err != nil {
+			fmt.Fprintf(stderr, "vitaldb merge: %s: %v\n", path, err)
+			return exitError
+		}
+		files = append(files, vf)
+	}
+
+	merged, err := vital.Merge(files...)
+	if err != nil {
+		fmt.Fprintf(stderr, "vitaldb merge: %v\n", err)
+		return exitError
+	}
+	if !config.Quiet {
+		fmt.Fprintf(stderr, "Merged %d files: %d tracks, %.2f seconds\n", len(files), len(merged.Trks), merged.DtEnd-merged.DtStart)
+	}
+
+	config.warn = stderr
+	if err := writeTo(stdout, merged, "", config, writeRecording); err != nil {
+		fmt.Fprintf(stderr, "vitaldb merge: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// writeRecording writes vf as a .vital file, or exports all of its records
+// in another format.
+func writeRecording(vf *vital.VitalFile, path string, config *Config, stdout io.Writer) error {
+	if config.Format == "vital" {
+		if err := transformWaves(vf, config); err != nil {
+			return err
+		}
+		return vf.Write(stdout)
+	}
+	config.Mode = ModeExport
+	return runExport(vf, path, config, stdout)
+}
+
+// checkVitalFilters rejects track filters with -format vital before any
+// file is read: the .vital output keeps every track, and the filters only
+// select the tracks of the wave flags.
+func checkVitalFilters(config *Config) error {
+	w := &config.Waves
+	waves := w.Filter != "" || w.Resample > 0 || w.Artifacts != ""
+	if config.Format == "vital" && hasTrackFilter(config) && !waves {
+		return errors.New("track filters apply only to export formats, not to -format vital")
+	}
+	return nil
+}
+
+// hasTrackFilter reports whether any flag of addFilterFlags narrows the
+// tracks.
+func hasTrackFilter(config *Config) bool {
+	return config.Tracks != "" || config.TrackPattern != "" || config.TrackType != "" || config.MaxTracks > 0 || config.Signals != ""
+}
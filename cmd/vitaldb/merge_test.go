@@ -0,0 +1,64 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+	"github.com/mdsung/vitaldb_processor/vital/vitaltest"
+)
+
+// writeSplitPart writes one file of a split session with an HR value every
+// second and returns its path.
+func writeSplitPart(t *testing.T, dir, name string, start float64, seconds int) string {
+	t.Helper()
+	b := vitaltest.New(start)
+	b.Device(1, "Bx50", "Bx50", "COM1")
+	b.Track(vitaltest.Track{ID: 1, Type: vitaltest.TypeNumeric, Fmt: vitaltest.FmtFloat32, Name: "HR", Unit: "/min", DevID: 1})
+	for s := 0; s < seconds; s++ {
+		b.Numeric(1, start+float64(s), float32(60+s))
+	}
+	path := filepath.Join(dir, name)
+	if err := b.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMergeCommand(t *testing.T) {
+	in := t.TempDir()
+	writeSplitPart(t, in, "MICUA01_240724_180000.vital", 1721844000, 3)
+	writeSplitPart(t, in, "MICUA01_240724_180003.vital", 1721844003, 2)
+	out := filepath.Join(t.TempDir(), "merged.vital")
+
+	if code, _, stderr := runCLI("merge", "-quiet", "-o", out, in); code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	vf, err := vital.NewVitalFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := vf.Trks["Bx50/HR"].NumRecs(); n != 5 || vf.DtStart != 1721844000 || vf.DtEnd != 1721844004 {
+		t.Errorf("merged %d records over [%f, %f]", n, vf.DtStart, vf.DtEnd)
+	}
+
+	code, stdout, _ := runCLI("merge", "-quiet", "-format", "csv", in)
+	if code != exitOK || stdout == "" {
+		t.Errorf("csv: exit %d, output %q", code, stdout)
+	}
+	if code, _, _ := runCLI("merge", "-compress", "gzip", "-o", out, in); code != exitUsage {
+		t.Errorf("compressed .vital: exit %d, want %d", code, exitUsage)
+	}
+
+	// .vital은 모든 트랙을 쓰므로 파형 옵션 없는 트랙 필터는 사용법 오류
+	if code, _, stderr := runCLI("merge", "-quiet", "-tracks", "Bx50/HR", "-o", out, in); code != exitUsage || !strings.Contains(stderr, "track filters") {
+		t.Errorf("-tracks with -format vital: exit %d, stderr %q", code, stderr)
+	}
+	if code, _, stderr := runCLI("merge", "-quiet", "-tracks", "Bx50/HR", "-resample", "100", "-o", out, in); code != exitOK {
+		t.Errorf("-tracks with -resample: exit %d, stderr %q", code, stderr)
+	}
+	if code, _, stderr := runCLI("merge", "-quiet", "-tracks", "Bx50/HR", "-format", "csv", in); code != exitOK {
+		t.Errorf("-tracks with csv: exit %d, stderr %q", code, stderr)
+	}
+}
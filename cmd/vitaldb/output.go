@@ -0,0 +1,258 @@
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+// Output compression codecs.
+const (
+	compressNone = "none"
+	compressGzip = "gzip"
+	compressZstd = "zstd"
+)
+
+// formatByExtension maps output file extensions to formats for -o.
+var formatByExtension = map[string]string{
+	".csv":     "csv",
+	".parquet": "parquet",
+	".pq":      "parquet",
+	".json":    "json",
+	".msgpack": "msgpack",
+	".mpk":     "msgpack",
+	".txt":     "text",
+	".vital":   "vital",
+}
+
+// addOutputFlags registers -o/-output and -compress. Export commands also
+// get -split-tracks.
+func addOutputFlags(fs *flag.FlagSet, config *Config, split bool) {
+	usage := "출력 파일 (기본: 표준 출력, 확장자로 형식과 압축 추론: .csv, .parquet, .json, .msgpack, .txt, .gz, .zst)"
+	fs.StringVar(&config.Output, "o", "", usage)
+	fs.StringVar(&config.Output, "output", "", usage)
+	fs.StringVar(&config.Compress, "compress", "", "출력 압축 (none, gzip, zstd, 기본: -o 확장자로 추론)")
+	if split {
+		fs.BoolVar(&config.SplitTracks, "split-tracks", false, "트랙마다 <-o 디렉토리>/{track}.<ext> 파일로 나누어 출력")
+	}
+}
+
+// prepareOutput infers the format and compression from -o when they were
+// not given explicitly and checks that the combination is supported.
+func prepareOutput(fs *flag.FlagSet, config *Config, formats []string) error {
+	formatSet := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "format" {
+			formatSet = true
+		}
+	})
+
+	if config.SplitTracks && config.Output == "" {
+		return errors.New("-split-tracks requires -o <directory>")
+	}
+	if config.Output != "" && !config.SplitTracks {
+		ext := strings.ToLower(filepath.Ext(config.Output))
+		name := config.Output
+		if codec := codecByExtension(ext); codec != "" {
+			if config.Compress == "" {
+				config.Compress = codec
+			}
+			name = strings.TrimSuffix(name, filepath.Ext(name))
+			ext = strings.ToLower(filepath.Ext(name))
+		}
+		if format, ok := formatByExtension[ext]; ok && !formatSet {
+			config.Format = format
+		}
+	}
+
+	if !contains(formats, config.Format) {
+		return fmt.Errorf("unknown format %q (supported: %s)", config.Format, strings.Join(formats, ", "))
+	}
+	return checkCompression(config)
+}
+
+// checkCompression normalizes config.Compress and rejects codecs the output
+// cannot use.
+func checkCompression(config *Config) error {
+	switch config.Compress {
+	case "":
+		config.Compress = compressNone
+	case compressNone, compressGzip, compressZstd:
+	default:
+		return fmt.Errorf("unknown compression %q (supported: none, gzip, zstd)", config.Compress)
+	}
+	if config.Compress != compressNone && (config.Format == "parquet" || config.Format == "vital") {
+		return fmt.Errorf("%s output is already compressed; -compress applies to text outputs only", config.Format)
+	}
+	return nil
+}
+
+func codecByExtension(ext string) string {
+	switch ext {
+	case ".gz":
+		return compressGzip
+	case ".zst":
+		return compressZstd
+	default:
+		return ""
+	}
+}
+
+// formatExtension returns the file extension used for an output format.
+func formatExtension(format string) string {
+	if format == "text" {
+		return "txt"
+	}
+	return format
+}
+
+// compressionExtension returns the suffix added to compressed outputs.
+func compressionExtension(codec string) string {
+	switch codec {
+	case compressGzip:
+		return ".gz"
+	case compressZstd:
+		return ".zst"
+	default:
+		return ""
+	}
+}
+
+// writeTo runs fn with its output sent to stdout or, with -o, to a file or
+// per-track files. A validate failure still keeps its report.
+func writeTo(stdout io.Writer, vf *vital.VitalFile, path string, config *Config, fn func(*vital.VitalFile, string, *Config, io.Writer) error) error {
+	if config.Output == "" {
+		return writeCompressed(stdout, config.Compress, func(w io.Writer) error {
+			return fn(vf, path, config, w)
+		})
+	}
+	if config.SplitTracks {
+		return writeSplitTracks(vf, config)
+	}
+
+	var runErr error
+	err := writeFileAtomic(config.Output, func(w io.Writer) error {
+		return writeCompressed(w, config.Compress, func(w io.Writer) error {
+			runErr = fn(vf, path, config, w)
+			if errors.Is(runErr, errInvalid) {
+				return nil
+			}
+			return runErr
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return runErr
+}
+
+// writeSplitTracks writes each selected track to its own file in the
+// config.Output directory. Every file carries the file and device
+// information so it can be read on its own. The wave flags apply as in
+// runExport. Tracks whose file names collide get a numeric suffix ("_2")
+// in name order.
+func writeSplitTracks(vf *vital.VitalFile, config *Config) error {
+	if err := transformWaves(vf, config); err != nil {
+		return err
+	}
+	output, err := processVitalFile(vf, config)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(config.Output, 0o755); err != nil {
+		return err
+	}
+
+	ext := "." + formatExtension(config.Format) + compressionExtension(config.Compress)
+	used := make(map[string]bool) // 대소문자를 구분하지 않는 파일 시스템도 고려
+	for _, name := range sortedKeys(output.Tracks) {
+		single := &OutputData{
+			FileInfo: output.FileInfo,
+			Devices:  output.Devices,
+			Tracks:   map[string]TrackInfo{name: output.Tracks[name]},
+		}
+		// "A/B"와 "A_B"처럼 같은 파일 이름이 되는 트랙은 번호를 붙임
+		base := trackFileName(name)
+		for i := 2; used[strings.ToLower(base)]; i++ {
+			base = fmt.Sprintf("%s_%d", trackFileName(name), i)
+		}
+		used[strings.ToLower(base)] = true
+		file := filepath.Join(config.Output, base+ext)
+		err := writeFileAtomic(file, func(w io.Writer) error {
+			return writeCompressed(w, config.Compress, func(w io.Writer) error {
+				return writeOutput(w, single, vf, config)
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// trackFileName turns a "Device/Name" track name into a file name.
+func trackFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// writeCompressed calls write with w wrapped in the codec's compressor and
+// closes the compressor afterwards.
+func writeCompressed(w io.Writer, codec string, write func(io.Writer) error) error {
+	var cw io.WriteCloser
+	switch codec {
+	case compressGzip:
+		cw = gzip.NewWriter(w)
+	case compressZstd:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return err
+		}
+		cw = zw
+	default:
+		return write(w)
+	}
+
+	if err := write(cw); err != nil {
+		cw.Close()
+		return err
+	}
+	return cw.Close()
+}
+
+// writeFileAtomic writes path through a temporary file in the same
+// directory and renames it into place, so a partial file is never left
+// under the final name.
+func writeFileAtomic(path string, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // rename 성공 후에는 아무 일도 하지 않음
+
+	// CreateTemp는 0600으로 만들므로 일반 파일 권한으로 변경
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
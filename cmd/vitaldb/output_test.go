@@ -0,0 +1,170 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/mdsung/vitaldb_processor/vital/vitaltest"
+)
+
+// readOutput reads path, decompressing .gz and .zst files.
+func readOutput(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	switch filepath.Ext(path) {
+	case ".gz":
+		if r, err = gzip.NewReader(f); err != nil {
+			t.Fatal(err)
+		}
+	case ".zst":
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestOutputFileInfersFormatAndCompression(t *testing.T) {
+	dir := t.TempDir()
+	cases := []struct {
+		args []string
+		file string
+		want string
+	}{
+		{[]string{"export"}, "out.csv", "track_name,timestamp,value,unit\n"},
+		{[]string{"convert"}, "out.json.gz", `"file_info"`},
+		{[]string{"convert", "-compact"}, "out.json.zst", `"tracks":{`},
+		{[]string{"info"}, "info.txt", "=== File Information ==="},
+		// 명시한 -format이 확장자보다 우선
+		{[]string{"export", "-format", "text"}, "forced.csv", "=== Tracks ==="},
+		// 이전 형식도 -o 지원
+		{nil, "legacy.csv", "track_name,timestamp,value,unit\n"},
+	}
+	for _, tc := range cases {
+		path := filepath.Join(dir, tc.file)
+		args := append(append(tc.args, "-quiet", "--output", path), fixture)
+		code, stdout, stderr := runCLI(args...)
+		if code != exitOK {
+			t.Errorf("%v: exit %d, stderr %q", args, code, stderr)
+			continue
+		}
+		if stdout != "" {
+			t.Errorf("%v: data written to stdout: %q", args, stdout)
+		}
+		if got := readOutput(t, path); !strings.Contains(got, tc.want) {
+			t.Errorf("%s: output does not contain %q:\n%s", tc.file, tc.want, got)
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != len(cases) {
+		t.Errorf("%d files in output directory, want %d (temporary files left behind?)", len(entries), len(cases))
+	}
+}
+
+func TestOutputSplitTracks(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tracks")
+	code, _, stderr := runCLI("convert", "-quiet", "-format", "json", "-compress", "gzip", "-split-tracks", "-o", dir, fixture)
+	if code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	for _, name := range []string{"Bx50/ECG_II", "Bx50/HR", "Intellivue/ART", "Intellivue/HR"} {
+		var output OutputData
+		data := readOutput(t, filepath.Join(dir, trackFileName(name)+".json.gz"))
+		if err := json.Unmarshal([]byte(data), &output); err != nil {
+			t.Fatal(err)
+		}
+		if len(output.Tracks) != 1 || output.Tracks[name].RecordsCount != 4 || output.FileInfo == nil {
+			t.Errorf("%s: unexpected output %+v", name, output)
+		}
+	}
+}
+
+func TestOutputSplitTracksResample(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tracks")
+	code, _, stderr := runCLI("convert", "-quiet", "-format", "json", "-split-tracks", "-o", dir,
+		"-tracks", "Bx50/ECG_II", "-resample", "250", fixture)
+	if code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	var output OutputData
+	if err := json.Unmarshal([]byte(readOutput(t, filepath.Join(dir, trackFileName("Bx50/ECG_II")+".json"))), &output); err != nil {
+		t.Fatal(err)
+	}
+	if trk := output.Tracks["Bx50/ECG_II"]; trk.SampleRate != 250 {
+		t.Errorf("split track SRate %g, want 250", trk.SampleRate)
+	}
+}
+
+func TestOutputSplitTracksNameCollision(t *testing.T) {
+	b := vitaltest.New(1000)
+	b.Device(1, "Dev", "Dev", "COM1")
+	b.Track(vitaltest.Track{ID: 1, Type: vitaltest.TypeNumeric, Fmt: vitaltest.FmtFloat32, Name: "HR", DevID: 1})
+	b.Track(vitaltest.Track{ID: 2, Type: vitaltest.TypeNumeric, Fmt: vitaltest.FmtFloat32, Name: "Dev_HR"})
+	b.Numeric(1, 1000, float32(1))
+	b.Numeric(2, 1000, float32(2))
+	in := filepath.Join(t.TempDir(), "collide.vital")
+	if err := b.WriteFile(in); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "tracks")
+	code, _, stderr := runCLI("convert", "-quiet", "-format", "json", "-split-tracks", "-o", dir, in)
+	if code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	// 이름 순: "Dev/HR" < "Dev_HR"
+	for file, name := range map[string]string{"Dev_HR.json": "Dev/HR", "Dev_HR_2.json": "Dev_HR"} {
+		var output OutputData
+		if err := json.Unmarshal([]byte(readOutput(t, filepath.Join(dir, file))), &output); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := output.Tracks[name]; !ok || len(output.Tracks) != 1 {
+			t.Errorf("%s: tracks %v", file, sortedKeys(output.Tracks))
+		}
+	}
+}
+
+func TestOutputFailureLeavesNoFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv")
+	if code, _, _ := runCLI("export", "-quiet", "-o", path, "missing.vital"); code != exitError {
+		t.Errorf("exit %d, want %d", code, exitError)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("output exists after failure: %v", err)
+	}
+}
+
+func TestOutputUsageErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"export", "-split-tracks", fixture},
+		{"export", "-o", "out.parquet.gz", fixture},
+		{"export", "-compress", "bzip2", fixture},
+		{"info", "-o", "info.csv", fixture},
+		{"info", "-split-tracks", "-o", "dir", fixture},
+	} {
+		if code, _, _ := runCLI(args...); code != exitUsage {
+			t.Errorf("%v: exit %d, want %d", args, code, exitUsage)
+		}
+	}
+}
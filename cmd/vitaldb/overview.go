@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+// overviewFlags holds the flags of the overview command.
+type overviewFlags struct {
+	Buckets int
+	Method  string
+}
+
+// OverviewReport is the output of the overview command.
+type OverviewReport struct {
+	File    string            `json:"file"`
+	Method  string            `json:"method"`
+	Buckets int               `json:"buckets"`
+	Tracks  []*vital.Overview `json:"tracks"`
+}
+
+func addOverviewFlags(fs *flag.FlagSet, config *Config) {
+	o := &config.Overview
+	fs.Float64Var(&config.CropStart, "start", 0, "구간 시작 (Unix 초, 0 = 트랙 처음부터)")
+	fs.Float64Var(&config.CropEnd, "end", 0, "구간 끝, 이 시각은 포함하지 않음 (Unix 초, 0 = 트랙 끝까지)")
+	fs.BoolVar(&config.Relative, "relative", false, "-start, -end를 파일 시작 시각으로부터의 초로 해석")
+	fs.IntVar(&o.Buckets, "buckets", 1000, "트랙마다 나눌 버킷(픽셀) 수")
+	fs.StringVar(&o.Method, "method", vital.OverviewMinMax, "축약 방법 (minmax: 버킷별 최소/최대, lttb: 버킷별 대표 샘플)")
+	fs.BoolVar(&config.Compact, "compact", false, "Compact JSON (들여쓰기 없음)")
+}
+
+// runOverview writes the selected WAVE and NUMERIC tracks reduced to
+// -buckets points between -start and -end.
+func runOverview(vf *vital.VitalFile, path string, config *Config, stdout io.Writer) error {
+	config.Mode = ModeTracks
+	tracks, err := processTracks(vf, config)
+	if err != nil {
+		return err
+	}
+	opts := vital.OverviewOptions{Buckets: config.Overview.Buckets, Method: config.Overview.Method}
+	base := 0.0
+	if config.Relative {
+		base = vf.DtStart
+	}
+	if config.CropStart != 0 {
+		opts.Start = base + config.CropStart
+	}
+	if config.CropEnd != 0 {
+		opts.End = base + config.CropEnd
+	}
+
+	report := OverviewReport{File: path, Method: opts.Method, Buckets: opts.Buckets, Tracks: []*vital.Overview{}}
+	for _, name := range sortedKeys(tracks) {
+		trk := vf.Trks[tracks[name].sourceName()]
+		if trk.Type != 1 && trk.Type != 2 || trk.NumRecs() == 0 {
+			continue
+		}
+		ov, err := trk.Overview(opts)
+		if err != nil {
+			return err
+		}
+		ov.Track = name
+		report.Tracks = append(report.Tracks, ov)
+	}
+
+	encoder := json.NewEncoder(stdout)
+	if !config.Compact {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(report)
+}
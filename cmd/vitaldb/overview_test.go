@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestOverviewCommand(t *testing.T) {
+	wave := func(phase float64) float64 { return math.Sin(2 * math.Pi * phase / 0.75) }
+	in := writePhysioRecording(t, map[string]func(float64) float64{"PLETH": wave})
+
+	code, stdout, stderr := runCLI("overview", "-buckets", "20", "-relative", "-start", "5", "-end", "15", in)
+	if code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	var report OverviewReport
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Tracks) != 2 || report.Method != "minmax" {
+		t.Fatalf("report %+v", report)
+	}
+	hr, pleth := report.Tracks[0], report.Tracks[1]
+	if hr.Track != "Bx50/HR" || hr.Samples != 10 || len(hr.Times) != 10 || hr.Max[0] != 80 {
+		t.Errorf("HR overview %+v", hr)
+	}
+	if pleth.Samples != 5000 || len(pleth.Min) != 20 || pleth.Start != 1005 || pleth.Bucket != 0.5 || pleth.Max[0] < 0.99 {
+		t.Errorf("PLETH overview: %d samples, %d buckets", pleth.Samples, len(pleth.Min))
+	}
+
+	code, stdout, _ = runCLI("overview", "-method", "lttb", "-buckets", "50", "-tracks", "Bx50/PLETH", "-compact", in)
+	if code != exitOK {
+		t.Fatalf("lttb: exit %d", code)
+	}
+	report = OverviewReport{}
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil || len(report.Tracks) != 1 || len(report.Tracks[0].Values) != 50 {
+		t.Errorf("lttb report %s, %v", stdout, err)
+	}
+
+	// 모든 트랙이 -start 전에 끝나면 빈 결과
+	code, stdout, stderr = runCLI("overview", "-relative", "-start", "30", in)
+	report = OverviewReport{}
+	if code != exitOK || json.Unmarshal([]byte(stdout), &report) != nil || len(report.Tracks) != 2 || report.Tracks[1].Samples != 0 {
+		t.Errorf("after the tracks: exit %d, stderr %q, output %s", code, stderr, stdout)
+	}
+
+	if code, _, _ := runCLI("overview", "-method", "mean", in); code != exitError {
+		t.Errorf("unknown -method: exit %d", code)
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+	"github.com/mdsung/vitaldb_processor/vital/physio"
+)
+
+// physioFlags holds the flags of the waveform analysis commands.
+type physioFlags struct {
+	Track  string  // 분석할 트랙 (쉼표로 구분, "" = 이름으로 자동 선택)
+	HR     string  // 비교할 모니터 HR 트랙
+	Window float64 // HR 비교 구간 (초)
+	Report string  // 결과 요약 JSON 파일
+	Table  string  // hrv: 창별 지표 CSV 파일
+	HRV    physio.HRVOptions
+	ART    physio.ARTOptions
+	PPG    physio.PPGOptions
+	SQI    physio.SQIOptions
+}
+
+// physioRecord is the report file written by -report.
+type physioRecord struct {
+	Input     string    `json:"input"`
+	Output    string    `json:"output,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Tracks    any       `json:"tracks"`
+}
+
+// addPhysioFlags registers the flags shared by the waveform analysis
+// commands; track describes the -track flag.
+func addPhysioFlags(fs *flag.FlagSet, config *Config, track string) {
+	pf := &config.Physio
+	fs.StringVar(&pf.Track, "track", "", track)
+	fs.StringVar(&pf.Report, "report", "", "분석 결과 요약 JSON 파일")
+	fs.BoolVar(&config.Compact, "compact", false, "Compact JSON (들여쓰기 없음)")
+}
+
+// physioTracks returns the names of the tracks to analyze: the -track list,
+// or every WAVE track whose name without device starts with one of
+// prefixes.
+func physioTracks(vf *vital.VitalFile, config *Config, prefixes ...string) ([]string, error) {
+	if names := splitList(config.Physio.Track); len(names) > 0 {
+		for _, name := range names {
+			if _, ok := vf.Trks[name]; !ok {
+				return nil, fmt.Errorf("track %q not found", name)
+			}
+		}
+		return names, nil
+	}
+	var names []string
+	for name, trk := range vf.Trks {
+		short := strings.ToUpper(name[strings.LastIndex(name, "/")+1:])
+		for _, prefix := range prefixes {
+			if trk.Type == 1 && strings.HasPrefix(short, prefix) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no %s WAVE track (use -track)", strings.Join(prefixes, "/"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// writePhysioReport writes the -report file, if requested.
+func writePhysioReport(path string, config *Config, results any) error {
+	if config.Physio.Report == "" {
+		return nil
+	}
+	record := physioRecord{Input: path, Output: config.Output, CreatedAt: time.Now().UTC(), Tracks: results}
+	err := writeFileAtomic(config.Physio.Report, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(record)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// runECG detects the R peaks of the ECG tracks, adds the R peak and heart
+// rate tracks and writes the recording.
+func runECG(vf *vital.VitalFile, path string, config *Config, stdout io.Writer) error {
+	names, err := physioTracks(vf, config, "ECG")
+	if err != nil {
+		return err
+	}
+	opts := physio.ECGOptions{MonitorHR: config.Physio.HR, CompareWindow: config.Physio.Window}
+	results := []*physio.ECGResult{}
+	for _, name := range names {
+		res, err := physio.AnalyzeECG(vf, name, opts)
+		if err != nil {
+			return err
+		}
+		results = append(results, res)
+		if config.warn != nil && !config.Quiet {
+			printECG(config.warn, res)
+		}
+	}
+	if err := writePhysioReport(path, config, results); err != nil {
+		return err
+	}
+	return writeRecording(vf, path, config, stdout)
+}
+
+// printECG writes a one line summary of an ECG analysis.
+func printECG(w io.Writer, r *physio.ECGResult) {
+	fmt.Fprintf(w, "%s: %d beats, mean HR %.1f /min", r.Track, r.Beats, r.MeanHR)
+	if c := r.Comparison; c != nil {
+		fmt.Fprintf(w, ", vs %s: bias %+.1f, MAE %.1f, within 5/min %.0f%% (%d pairs)", c.Track, c.Bias, c.MAE, 100*c.Within, c.Pairs)
+	}
+	fmt.Fprintln(w)
+}
+
+// runHRV computes windowed HRV from the ECG or R-R interval tracks, adds a
+// track per metric and writes the recording and, with -table, the windows
+// as CSV.
+func runHRV(vf *vital.VitalFile, path string, config *Config, stdout io.Writer) error {
+	names, err := physioTracks(vf, config, "ECG")
+	if err != nil {
+		return err
+	}
+	results := []*physio.HRVResult{}
+	for _, name := range names {
+		res, err := physio.AnalyzeHRV(vf, name, config.Physio.HRV)
+		if err != nil {
+			return err
+		}
+		results = append(results, res)
+		if config.warn != nil && !config.Quiet {
+			fmt.Fprintf(config.warn, "%s: %d R-R intervals, %d windows\n", res.Track, res.Intervals, len(res.Windows))
+		}
+	}
+	if config.Physio.Table != "" {
+		err := writeFileAtomic(config.Physio.Table, func(w io.Writer) error { return printHRVCSV(w, results) })
+		if err != nil {
+			return fmt.Errorf("failed to write HRV table: %w", err)
+		}
+	}
+	if err := writePhysioReport(path, config, results); err != nil {
+		return err
+	}
+	return writeRecording(vf, path, config, stdout)
+}
+
+// printHRVCSV writes one row per HRV window.
+func printHRVCSV(w io.Writer, results []*physio.HRVResult) error {
+	writer := bufio.NewWriter(w)
+	csvWriter := csv.NewWriter(writer)
+	header := []string{"track_name", "time", "intervals", "mean_rr", "sdnn", "rmssd", "pnn50", "lf", "hf", "lf_hf", "sd1", "sd2"}
+	if err := csvWriter.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	f := func(v float64) string { return strconv.FormatFloat(v, 'g', 6, 64) }
+	for _, res := range results {
+		for _, h := range res.Windows {
+			row := []string{res.Track, fmt.Sprintf("%.6f", h.Time), strconv.Itoa(h.Intervals), f(h.MeanRR), f(h.SDNN),
+				f(h.RMSSD), f(h.PNN50), "", "", "", f(h.SD1), f(h.SD2)}
+			if h.HF > 0 {
+				row[7], row[8], row[9] = f(h.LF), f(h.HF), f(h.LFHF)
+			}
+			if err := csvWriter.Write(row); err != nil {
+				return fmt.Errorf("failed to write CSV row: %w", err)
+			}
+		}
+	}
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return writer.Flush()
+}
+
+// runART segments the arterial pressure tracks into beats, adds the beat,
+// PPV and quality flag tracks and writes the recording.
+func runART(vf *vital.VitalFile, path string, config *Config, stdout io.Writer) error {
+	names, err := physioTracks(vf, config, "ART", "ABP")
+	if err != nil {
+		return err
+	}
+	results := []*physio.ARTResult{}
+	for _, name := range names {
+		res, err := physio.AnalyzeART(vf, name, config.Physio.ART)
+		if err != nil {
+			return err
+		}
+		results = append(results, res)
+		if config.warn != nil && !config.Quiet {
+			fmt.Fprintf(config.warn, "%s: %d beats, SBP/DBP %.0f/%.0f (MAP %.0f), PPV %.1f%%, %d flagged stretches\n",
+				res.Track, res.Beats, res.SBP, res.DBP, res.MAP, res.PPV, len(res.Flagged))
+		}
+	}
+	if err := writePhysioReport(path, config, results); err != nil {
+		return err
+	}
+	return writeRecording(vf, path, config, stdout)
+}
+
+// runPPG detects the pulses of the pleth tracks, adds the windowed pulse
+// rate, amplitude and PVI tracks and writes the recording.
+func runPPG(vf *vital.VitalFile, path string, config *Config, stdout io.Writer) error {
+	names, err := physioTracks(vf, config, "PLETH", "PPG")
+	if err != nil {
+		return err
+	}
+	results := []*physio.PPGResult{}
+	for _, name := range names {
+		res, err := physio.AnalyzePPG(vf, name, config.Physio.PPG)
+		if err != nil {
+			return err
+		}
+		results = append(results, res)
+		if config.warn != nil && !config.Quiet {
+			fmt.Fprintf(config.warn, "%s: %d beats, pulse rate %.1f /min, amplitude %.3g, PVI %.1f%%\n",
+				res.Track, res.Beats, res.Rate, res.Amplitude, res.PVI)
+		}
+	}
+	if err := writePhysioReport(path, config, results); err != nil {
+		return err
+	}
+	return writeRecording(vf, path, config, stdout)
+}
+
+// sqiPrefixes are the WAVE tracks checked by sqi without -track.
+var sqiPrefixes = []string{"ECG", "ART", "ABP", "PLETH", "PPG"}
+
+func runSQI(vf *vital.VitalFile, path string, config *Config, stdout io.Writer) error {
+	names := splitList(config.Physio.Track)
+	if len(names) == 0 {
+		// 파형 트랙과 생리적 범위가 알려진 NUMERIC 트랙
+		if waves, err := physioTracks(vf, config, sqiPrefixes...); err == nil {
+			names = waves
+		}
+		for name, trk := range vf.Trks {
+			if _, ok := physio.PlausibleRange(trk, physio.DefaultLimits); ok {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("no %s WAVE track or NUMERIC track with a plausible range (use -track)", strings.Join(sqiPrefixes, "/"))
+		}
+		sort.Strings(names)
+	}
+	results := []*physio.SQIResult{}
+	for _, name := range names {
+		res, err := physio.AnalyzeSQI(vf, name, config.Physio.SQI)
+		if err != nil {
+			return err
+		}
+		results = append(results, res)
+		if config.warn != nil && !config.Quiet {
+			var counts []string
+			for _, name := range sortedKeys(res.Artifacts) {
+				counts = append(counts, fmt.Sprintf("%s %d", name, res.Artifacts[name]))
+			}
+			fmt.Fprintf(config.warn, "%s: %d/%d flagged, mean SQI %.2f", res.Track, res.Flagged, res.Windows, res.MeanSQI)
+			if len(counts) > 0 {
+				fmt.Fprintf(config.warn, " (%s)", strings.Join(counts, ", "))
+			}
+			fmt.Fprintln(config.warn)
+		}
+	}
+	if err := writePhysioReport(path, config, results); err != nil {
+		return err
+	}
+	return writeRecording(vf, path, config, stdout)
+}
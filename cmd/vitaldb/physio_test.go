@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+	"github.com/mdsung/vitaldb_processor/vital/physio"
+	"github.com/mdsung/vitaldb_processor/vital/vitaltest"
+)
+
+// writePhysioRecording writes 20 s of 500 Hz waveforms with a beat every
+// 0.75 s (80 /min) and a 1 Hz monitor HR track, and returns its path. Each
+// wave is given the time since the last beat.
+func writePhysioRecording(t *testing.T, waves map[string]func(phase float64) float64) string {
+	t.Helper()
+	const start = 1000.0
+	b := vitaltest.New(start)
+	b.Device(1, "Bx50", "Bx50", "COM1")
+	b.Track(vitaltest.Track{ID: 1, Type: vitaltest.TypeNumeric, Fmt: vitaltest.FmtFloat32, Name: "HR", Unit: "/min", DevID: 1})
+	id := uint16(2)
+	for _, name := range sortedKeys(waves) {
+		b.Track(vitaltest.Track{ID: id, Type: vitaltest.TypeWave, Fmt: vitaltest.FmtFloat32, Name: name, SRate: 500, Gain: 1, DevID: 1})
+		for s := 0; s < 20; s++ {
+			chunk := make([]float32, 500)
+			for i := range chunk {
+				chunk[i] = float32(waves[name](math.Mod(float64(s)+float64(i)/500, 0.75)))
+			}
+			b.Wave(id, start+float64(s), chunk)
+		}
+		id++
+	}
+	for s := 0; s < 20; s++ {
+		b.Numeric(1, start+float64(s), float32(80))
+	}
+	path := filepath.Join(t.TempDir(), "physio.vital")
+	if err := b.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// qrs is a narrow R wave 0.1 s after each beat.
+func qrs(phase float64) float64 {
+	d := (phase - 0.1) / 0.01
+	return math.Exp(-d * d / 2)
+}
+
+func TestECGCommand(t *testing.T) {
+	in := writePhysioRecording(t, map[string]func(float64) float64{"ECG_II": qrs})
+	dir := t.TempDir()
+	out, report := filepath.Join(dir, "out.vital"), filepath.Join(dir, "ecg.json")
+
+	code, _, stderr := runCLI("ecg", "-report", report, "-o", out, in)
+	if code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	if !strings.Contains(stderr, "Bx50/ECG_II: 27 beats") {
+		t.Errorf("summary %q", stderr)
+	}
+	vf, err := vital.NewVitalFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hr := vf.Trks["Bx50/ECG_II_HR"]
+	if hr == nil || hr.NumRecs() != 26 || vf.Trks["Bx50/ECG_II_RPEAK"] == nil {
+		t.Fatalf("derived tracks missing: %v", sortedKeys(vf.Trks))
+	}
+
+	var record struct {
+		Tracks []struct {
+			MeanHR     float64 `json:"mean_hr"`
+			Comparison struct {
+				Track string  `json:"track"`
+				Bias  float64 `json:"bias"`
+			} `json:"comparison"`
+		} `json:"tracks"`
+	}
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &record); err != nil {
+		t.Fatal(err)
+	}
+	if len(record.Tracks) != 1 || math.Abs(record.Tracks[0].MeanHR-80) > 0.5 ||
+		record.Tracks[0].Comparison.Track != "Bx50/HR" || math.Abs(record.Tracks[0].Comparison.Bias) > 0.5 {
+		t.Errorf("report %s", data)
+	}
+
+	if code, _, _ := runCLI("ecg", "-quiet", "-track", "Bx50/HR", in); code != exitError {
+		t.Errorf("NUMERIC -track: exit %d", code)
+	}
+}
+
+func TestHRVCommand(t *testing.T) {
+	in := writePhysioRecording(t, map[string]func(float64) float64{"ECG_II": qrs})
+	table := filepath.Join(t.TempDir(), "hrv.csv")
+
+	code, stdout, stderr := runCLI("hrv", "-quiet", "-window", "10", "-step", "5", "-min-intervals", "5",
+		"-table", table, "-format", "csv", in)
+	if code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	// 첫 R-R 간격(0.85 s)부터 10 s 창이 5 s 간격으로 3개
+	if n := strings.Count(stdout, "\nBx50/ECG_II_SDNN,"); n != 3 {
+		t.Errorf("%d SDNN records in %q", n, stdout)
+	}
+	data, err := os.ReadFile(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "track_name,time,intervals,mean_rr,sdnn") ||
+		!strings.HasPrefix(lines[1], "Bx50/ECG_II,1010.850000,14,750,0,0,0,,,,0,0") {
+		t.Errorf("table %q", data)
+	}
+}
+
+func TestARTCommand(t *testing.T) {
+	pressure := func(phase float64) float64 {
+		d := (phase - 0.15) / 0.05
+		return 70 + 40*math.Exp(-d*d/2)
+	}
+	in := writePhysioRecording(t, map[string]func(float64) float64{"ART": pressure})
+	out := filepath.Join(t.TempDir(), "out.vital")
+
+	code, _, stderr := runCLI("art", "-o", out, in)
+	if code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	if !strings.Contains(stderr, "Bx50/ART: 26 beats, SBP/DBP 110/70 (MAP 77), PPV 0.0%, 0 flagged") {
+		t.Errorf("summary %q", stderr)
+	}
+	vf, err := vital.NewVitalFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"SBP", "DBP", "MAP", "PP", "PPV", "FLAG"} {
+		if vf.Trks["Bx50/ART_"+name] == nil {
+			t.Errorf("track ART_%s missing", name)
+		}
+	}
+	if code, _, stderr := runCLI("art", "-quiet", writePhysioRecording(t, map[string]func(float64) float64{"ECG_II": qrs})); code != exitError || !strings.Contains(stderr, "no ART/ABP WAVE track") {
+		t.Errorf("no ART track: exit %d, stderr %q", code, stderr)
+	}
+}
+
+func TestPPGCommand(t *testing.T) {
+	wave := func(phase float64) float64 {
+		if phase < 0.2 {
+			return 1 + (1-math.Cos(math.Pi*phase/0.2))/2
+		}
+		return 1 + math.Exp(-(phase-0.2)/0.3)
+	}
+	in := writePhysioRecording(t, map[string]func(float64) float64{"PLETH": wave})
+
+	code, stdout, stderr := runCLI("ppg", "-pvi-window", "15", "-format", "csv", in)
+	if code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	if !strings.Contains(stderr, "Bx50/PLETH: 26 beats, pulse rate 80.0 /min") {
+		t.Errorf("summary %q", stderr)
+	}
+	// 10 s 창을 5 s 간격으로: 10, 15, 20 s
+	if n := strings.Count(stdout, "\nBx50/PLETH_PR,"); n != 3 || !strings.Contains(stdout, "\nBx50/PLETH_PVI,") {
+		t.Errorf("%d pulse rate records in %q", n, stdout)
+	}
+}
+
+func TestSQICommand(t *testing.T) {
+	wave := func(phase float64) float64 { return 1 + math.Sin(2*math.Pi*phase/0.75) }
+	in := writePhysioRecording(t, map[string]func(float64) float64{"PLETH": wave})
+	report := filepath.Join(t.TempDir(), "sqi.json")
+
+	code, stdout, stderr := runCLI("sqi", "-report", report, "-format", "csv", in)
+	if code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	if !strings.Contains(stderr, "Bx50/HR: 0/20 flagged") || !strings.Contains(stderr, "Bx50/PLETH: 0/10 flagged, mean SQI 1.00") {
+		t.Errorf("summary %q", stderr)
+	}
+	if n := strings.Count(stdout, "\nBx50/PLETH_SQI,"); n != 10 {
+		t.Errorf("%d SQI records", n)
+	}
+	var record struct {
+		Tracks []physio.SQIResult `json:"tracks"`
+	}
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &record); err != nil {
+		t.Fatal(err)
+	}
+	if len(record.Tracks) != 2 || record.Tracks[1].Windows != 10 || len(record.Tracks[1].Tracks) != 2 {
+		t.Errorf("report %s", data)
+	}
+}
+
+func TestExportArtifacts(t *testing.T) {
+	b := vitaltest.New(1000)
+	b.Device(1, "Bx50", "Bx50", "COM1")
+	b.Track(vitaltest.Track{ID: 1, Type: vitaltest.TypeNumeric, Fmt: vitaltest.FmtFloat32, Name: "HR", Unit: "/min", DevID: 1})
+	for i, v := range []float32{80, 0, 81, 400} {
+		b.Numeric(1, 1000+float64(i), v)
+	}
+	in := filepath.Join(t.TempDir(), "hr.vital")
+	if err := b.WriteFile(in); err != nil {
+		t.Fatal(err)
+	}
+
+	for mode, want := range map[string]int{"drop": 2, "nan": 4} {
+		code, stdout, stderr := runCLI("export", "-format", "csv", "-max-samples", "0", "-artifacts", mode, in)
+		if code != exitOK {
+			t.Fatalf("%s: exit %d, stderr %q", mode, code, stderr)
+		}
+		if n := strings.Count(stdout, "\nBx50/HR,"); n != want || strings.Contains(stdout, ",400") {
+			t.Errorf("%s: %d records in %q", mode, n, stdout)
+		}
+	}
+	if code, _, _ := runCLI("export", "-artifacts", "zero", in); code != exitUsage {
+		t.Errorf("unknown -artifacts: exit %d", code)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+// statsFormats are the output formats of the stats command.
+var statsFormats = append(reportFormats, "csv")
+
+// printStatsCSV writes one row of statistics per track.
+func printStatsCSV(w io.Writer, output *OutputData) error {
+	writer := bufio.NewWriter(w)
+	csvWriter := csv.NewWriter(writer)
+
+	header := []string{"track_name", "type", "unit", "records", "values", "invalid",
+		"start", "end", "coverage", "gaps", "gap_time", "min", "max", "mean", "std"}
+	for _, p := range vital.StatsPercentiles {
+		header = append(header, vital.PercentileKey(p))
+	}
+	header = append(header, "declared_rate", "effective_rate", "out_of_range")
+	if err := csvWriter.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	num := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	for _, name := range sortedKeys(output.Tracks) {
+		track := output.Tracks[name]
+		s := track.Stats
+		if s == nil {
+			continue
+		}
+		row := []string{name, track.TypeName, track.Unit,
+			strconv.Itoa(s.Records), strconv.Itoa(s.Values), strconv.Itoa(s.Invalid),
+			fmt.Sprintf("%.6f", s.Start), fmt.Sprintf("%.6f", s.End), num(s.Coverage),
+			strconv.Itoa(s.Gaps), num(s.GapTime),
+			num(s.Min), num(s.Max), num(s.Mean), num(s.Std)}
+		for _, p := range vital.StatsPercentiles {
+			row = append(row, num(s.Percentiles[vital.PercentileKey(p)]))
+		}
+		row = append(row, num(s.DeclaredRate), num(s.EffectiveRate), num(s.OutOfRange))
+		if err := csvWriter.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return writer.Flush()
+}
+
+// printTrackStats writes the statistics line of a track in text output.
+func printTrackStats(w io.Writer, s *vital.TrackStats) {
+	fmt.Fprintf(w, "  Coverage: %.2f s, Gaps: %d (%.2f s)", s.Coverage, s.Gaps, s.GapTime)
+	if s.DeclaredRate > 0 || s.EffectiveRate > 0 {
+		fmt.Fprintf(w, ", Effective Rate: %.2f Hz", s.EffectiveRate)
+	}
+	fmt.Fprintln(w)
+	if s.Values > 0 {
+		fmt.Fprintf(w, "  Values: %d, Min: %.6g, Max: %.6g, Mean: %.6g, Std: %.6g, Median: %.6g, Out of Range: %.1f%%\n",
+			s.Values, s.Min, s.Max, s.Mean, s.Std, s.Percentiles["p50"], 100*s.OutOfRange)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStatsCommand(t *testing.T) {
+	code, stdout, stderr := runCLI("stats", "-quiet", "-format", "json", fixture)
+	if code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	var output OutputData
+	if err := json.Unmarshal([]byte(stdout), &output); err != nil {
+		t.Fatal(err)
+	}
+	hr := output.Tracks["Bx50/HR"].Stats
+	if hr == nil || hr.Values != 4 || hr.Min != 60 || hr.Max != 63 || hr.Percentiles["p50"] != 61.5 || hr.Coverage != 3 {
+		t.Errorf("HR stats = %+v", hr)
+	}
+	if ecg := output.Tracks["Bx50/ECG_II"].Stats; ecg == nil || ecg.EffectiveRate != 500 || ecg.DeclaredRate != 500 {
+		t.Errorf("ECG stats = %+v", ecg)
+	}
+
+	code, stdout, _ = runCLI("stats", "-quiet", "-format", "csv", "-tracks", "Bx50/HR", fixture)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if code != exitOK || len(lines) != 2 || !strings.HasPrefix(lines[0], "track_name,type,unit,records,values") ||
+		!strings.HasPrefix(lines[1], "Bx50/HR,NUMERIC,/min,4,4,0,") {
+		t.Errorf("csv: exit %d, output %q", code, stdout)
+	}
+}
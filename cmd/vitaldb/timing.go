@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+// timingFlags holds the flags of the timing and retime commands.
+type timingFlags struct {
+	Tolerance     float64
+	MaxDrift      float64
+	EffectiveRate bool
+	Report        string
+}
+
+// timingRecord is the report file written by retime -report.
+type timingRecord struct {
+	Input     string                `json:"input"`
+	Output    string                `json:"output,omitempty"`
+	CreatedAt time.Time             `json:"created_at"`
+	Tracks    []*vital.TimingReport `json:"tracks"`
+}
+
+func addTimingFlags(fs *flag.FlagSet, config *Config) {
+	tf := &config.Timing
+	fs.Float64Var(&tf.Tolerance, "tolerance", 0, "청크를 연속으로 볼 최대 시각 차이 (초, 0 = 샘플 주기의 절반)")
+	fs.Float64Var(&tf.MaxDrift, "max-drift", 0.01, "클럭 드리프트로 볼 최대 샘플링 레이트 오차 (비율)")
+}
+
+func (tf *timingFlags) options() vital.TimingOptions {
+	return vital.TimingOptions{Tolerance: tf.Tolerance, MaxDrift: tf.MaxDrift, EffectiveRate: tf.EffectiveRate}
+}
+
+// runTiming reports the timing of the selected WAVE tracks.
+func runTiming(vf *vital.VitalFile, path string, config *Config, stdout io.Writer) error {
+	config.Mode = ModeTracks
+	tracks, err := processTracks(vf, config)
+	if err != nil {
+		return err
+	}
+	reports := []*vital.TimingReport{}
+	for _, name := range sortedKeys(tracks) {
+		trk := vf.Trks[tracks[name].sourceName()]
+		if trk.Type != 1 || trk.SRate <= 0 {
+			continue
+		}
+		r, err := trk.Timing(config.Timing.options())
+		if err != nil {
+			return err
+		}
+		r.Track = name
+		reports = append(reports, r)
+	}
+
+	if config.Format == "json" {
+		encoder := json.NewEncoder(stdout)
+		if !config.Compact {
+			encoder.SetIndent("", "  ")
+		}
+		return encoder.Encode(reports)
+	}
+	fmt.Fprintln(stdout, "=== Timing ===")
+	for _, r := range reports {
+		printTiming(stdout, r)
+	}
+	return nil
+}
+
+// printTiming writes a timing report in text output.
+func printTiming(w io.Writer, r *vital.TimingReport) {
+	fmt.Fprintf(w, "%s: %d segments, SRate %.2f Hz, Effective Rate %.4f Hz, Overlaps: %d (%.3f s), Duplicates: %d\n",
+		r.Track, len(r.Segments), r.SRate, r.EffectiveRate, r.Overlaps, r.OverlapTime, r.Duplicates)
+	for _, seg := range r.Segments {
+		fmt.Fprintf(w, "  %.6f - %.6f: %d samples, %.4f Hz (%+.3f%%)\n",
+			seg.Start, seg.End, seg.Samples, seg.EffectiveRate, 100*seg.Drift)
+	}
+	if r.Retimed > 0 || len(r.Corrections) > 0 {
+		fmt.Fprintf(w, "  Retimed: %d chunks (max shift %.3f s), Corrections: %d\n", r.Retimed, r.MaxShift, len(r.Corrections))
+	}
+}
+
+// runRetime writes vf with the timing of its WAVE tracks corrected and, with
+// -report, the corrections applied.
+func runRetime(vf *vital.VitalFile, path string, config *Config, stdout io.Writer) error {
+	fixed, reports, err := vf.CorrectTiming(config.Timing.options())
+	if err != nil {
+		return err
+	}
+	if config.Timing.Report != "" {
+		record := timingRecord{Input: path, Output: config.Output, CreatedAt: time.Now().UTC(), Tracks: reports}
+		err := writeFileAtomic(config.Timing.Report, func(w io.Writer) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(record)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write timing report: %w", err)
+		}
+	}
+	return writeRecording(fixed, path, config, stdout)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+	"github.com/mdsung/vitaldb_processor/vital/vitaltest"
+)
+
+func TestTimingCommands(t *testing.T) {
+	// 100 Hz, 1초 청크: 1초 청크가 중복 전송되고 2.5초 청크가 앞과 겹침
+	b := vitaltest.New(1000)
+	b.Device(1, "Bx50", "Bx50", "COM1")
+	b.Track(vitaltest.Track{ID: 1, Type: vitaltest.TypeWave, Fmt: vitaltest.FmtInt16, Name: "ECG_II", SRate: 100, DevID: 1})
+	b.Track(vitaltest.Track{ID: 2, Type: vitaltest.TypeNumeric, Fmt: vitaltest.FmtFloat32, Name: "HR", Unit: "/min", DevID: 1})
+	for _, dt := range []float64{0, 1, 1, 2, 2.5} {
+		b.Wave(1, 1000+dt, make([]int16, 100))
+	}
+	b.Numeric(2, 1000, float32(60))
+	dir := t.TempDir()
+	in := filepath.Join(dir, "overlap.vital")
+	if err := b.WriteFile(in); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := runCLI("timing", "-quiet", "-format", "json", in)
+	if code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	var reports []vital.TimingReport
+	if err := json.Unmarshal([]byte(stdout), &reports); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || reports[0].Duplicates != 1 || reports[0].Overlaps != 1 {
+		t.Errorf("reports = %+v", reports)
+	}
+	if code, stdout, _ := runCLI("timing", "-quiet", in); code != exitOK || !strings.Contains(stdout, "Bx50/ECG_II: 2 segments") {
+		t.Errorf("text: exit %d, output %q", code, stdout)
+	}
+
+	out, report := filepath.Join(dir, "fixed.vital"), filepath.Join(dir, "timing.json")
+	if code, _, stderr := runCLI("retime", "-quiet", "-report", report, "-o", out, in); code != exitOK {
+		t.Fatalf("retime: exit %d, stderr %q", code, stderr)
+	}
+	vf, err := vital.NewVitalFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 0-3초 300 샘플 + 겹치지 않는 50 샘플
+	ecg := vf.Trks["Bx50/ECG_II"]
+	total := 0
+	for _, rec := range ecg.Recs {
+		total += len(rec.Val.([]int16))
+	}
+	if total != 350 || vf.Trks["Bx50/HR"].NumRecs() != 1 {
+		t.Errorf("corrected ECG has %d samples in %d chunks", total, len(ecg.Recs))
+	}
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record timingRecord
+	if err := json.Unmarshal(data, &record); err != nil || record.Input != in || len(record.Tracks) != 1 || len(record.Tracks[0].Corrections) != 2 {
+		t.Errorf("report %s: %v", data, err)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+// unitTarget is one "pattern=unit" item of -units.
+type unitTarget struct {
+	pattern string
+	unit    string
+}
+
+// addUnitFlags registers the unit conversion flags of record exports.
+func addUnitFlags(fs *flag.FlagSet, config *Config) {
+	fs.StringVar(&config.Units, "units", "", "트랙 값을 변환할 단위 (트랙=단위, 쉼표로 구분, 예: */ART=kPa,*/TEMP=°C)")
+	fs.BoolVar(&config.SignalUnits, "normalize-units", false, "-aliases 표의 신호 단위로 변환")
+}
+
+// prepareUnits parses -units and checks that the target units are known.
+func prepareUnits(config *Config) error {
+	if config.SignalUnits && config.Aliases == "" {
+		return errors.New("-normalize-units requires -aliases")
+	}
+	for _, item := range splitList(config.Units) {
+		pattern, unit, ok := strings.Cut(item, "=")
+		if !ok || pattern == "" || unit == "" {
+			return fmt.Errorf("-units %q: want track=unit", item)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("-units: bad pattern %q", pattern)
+		}
+		if _, ok := vital.DefaultUnits.Parse(unit); !ok {
+			return fmt.Errorf("-units: %w %q", vital.ErrUnknownUnit, unit)
+		}
+		config.unitTargets = append(config.unitTargets, unitTarget{pattern, unit})
+	}
+	return nil
+}
+
+// targetUnit returns the unit a track output as name should be converted
+// to, or "" to keep its values. -units takes precedence over the signal
+// units of -normalize-units.
+func targetUnit(name string, track *vital.Track, config *Config) string {
+	for _, t := range config.unitTargets {
+		if ok, _ := path.Match(t.pattern, name); ok {
+			return t.unit
+		}
+		if ok, _ := path.Match(t.pattern, track.Name); ok {
+			return t.unit
+		}
+	}
+	if config.SignalUnits && config.aliases != nil {
+		if sig, ok := config.aliases.Lookup(track.Name); ok {
+			return sig.Unit
+		}
+	}
+	return ""
+}
+
+// convertUnit sets up info to output the values of track in its target
+// unit. A unit that cannot be converted is reported and left as is.
+func convertUnit(info *TrackInfo, track *vital.Track, config *Config) {
+	to := targetUnit(info.Name, track, config)
+	if to == "" {
+		return
+	}
+	conv, err := vital.DefaultUnits.Conversion(track.Unit, to)
+	if err != nil {
+		info.UnitWarning = err.Error()
+		config.warnf("%s: %v; values left in %q", info.Name, err, track.Unit)
+		return
+	}
+	if conv.To.Symbol != track.Unit {
+		info.SourceUnit = track.Unit
+	}
+	// 변환한 값은 보정(gain/offset)까지 적용한 float64
+	info.Unit, info.Fmt, info.Gain, info.Offset = conv.To.Symbol, 2, 1, 0
+	info.MinDisplay = float32(conv.Apply(float64(track.Mindisp)))
+	info.MaxDisplay = float32(conv.Apply(float64(track.Maxdisp)))
+	info.conv = &conv
+}
+
+// convert returns rec in the unit of info.
+func (t TrackInfo) convert(track *vital.Track, rec vital.Rec) vital.Rec {
+	if t.conv != nil {
+		if converted, ok := t.conv.ConvertRec(track, rec); ok {
+			return converted
+		}
+	}
+	return rec
+}
+
+// warnf reports a problem that does not stop the command.
+func (c *Config) warnf(format string, args ...any) {
+	if c.warn != nil {
+		fmt.Fprintf(c.warn, "warning: "+format+"\n", args...)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestUnitConversionFlags(t *testing.T) {
+	code, stdout, stderr := runCLI("export", "-quiet", "-format", "json", "-max-samples", "1",
+		"-units", "*/ART=kPa,Bx50/HR=mmHg", "-tracks", "Intellivue/ART,Bx50/HR", fixture)
+	if code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	if !strings.Contains(stderr, "warning: Bx50/HR: incompatible units") {
+		t.Errorf("stderr %q does not report the incompatible unit", stderr)
+	}
+	var output OutputData
+	if err := json.Unmarshal([]byte(stdout), &output); err != nil {
+		t.Fatal(err)
+	}
+	art := output.Tracks["Intellivue/ART"]
+	if art.Unit != "kPa" || art.SourceUnit != "mmHg" || art.Fmt != 2 {
+		t.Errorf("ART = %+v", art)
+	}
+	if samples := art.Records[0].Value.([]any); len(samples) == 0 || samples[0].(float64) > 20 {
+		t.Errorf("ART samples not in kPa: %v", samples[:3])
+	}
+	if hr := output.Tracks["Bx50/HR"]; hr.Unit != "/min" || hr.UnitWarning == "" || hr.Records[0].Value.(float64) != 60 {
+		t.Errorf("HR = %+v", hr)
+	}
+
+	// 별칭 표의 신호 단위로 변환: 같은 단위면 값은 그대로
+	code, stdout, _ = runCLI("export", "-quiet", "-format", "csv", "-aliases", aliasFixture, "-signals", "HR",
+		"-normalize-units", fixture)
+	if code != exitOK || !strings.Contains(stdout, "\nHR,1721811600.000000,60,/min\n") {
+		t.Errorf("normalize: exit %d, output %q", code, stdout)
+	}
+
+	for _, args := range [][]string{
+		{"-units", "*/ART=furlong"},
+		{"-units", "*/ART"},
+		{"-normalize-units"},
+	} {
+		args = append([]string{"export", "-quiet"}, append(args, fixture)...)
+		if code, _, _ := runCLI(args...); code != exitUsage {
+			t.Errorf("%v: exit %d, want %d", args, code, exitUsage)
+		}
+	}
+}
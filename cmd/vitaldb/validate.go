@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+// errInvalid reports that validate found problems; the issues have already
+// been written, so the CLI only sets the exit code.
+var errInvalid = errors.New("validation failed")
+
+// ValidationIssue is a single problem found by validate.
+type ValidationIssue struct {
+	Severity string `json:"severity"` // "error" 또는 "warning"
+	Track    string `json:"track,omitempty"`
+	Message  string `json:"message"`
+}
+
+// ValidationReport is the output of validate.
+type ValidationReport struct {
+	File   string            `json:"file"`
+	Valid  bool              `json:"valid"`
+	Issues []ValidationIssue `json:"issues"`
+}
+
+func runValidate(vf *vital.VitalFile, path string, config *Config, stdout io.Writer) error {
+	report := ValidationReport{File: path, Issues: validateVitalFile(vf)}
+	report.Valid = true
+	for _, issue := range report.Issues {
+		if issue.Severity == "error" || config.Strict {
+			report.Valid = false
+		}
+	}
+
+	switch config.Format {
+	case "json":
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	default:
+		for _, issue := range report.Issues {
+			if issue.Track != "" {
+				fmt.Fprintf(stdout, "%s: %s: %s\n", issue.Severity, issue.Track, issue.Message)
+			} else {
+				fmt.Fprintf(stdout, "%s: %s\n", issue.Severity, issue.Message)
+			}
+		}
+		if report.Valid {
+			fmt.Fprintf(stdout, "%s: OK (%d tracks, %d devices)\n", path, len(vf.Trks), len(vf.Devs))
+		} else {
+			fmt.Fprintf(stdout, "%s: INVALID\n", path)
+		}
+	}
+
+	if !report.Valid {
+		return errInvalid
+	}
+	return nil
+}
+
+// validateVitalFile checks the structural invariants of a loaded file: the
+// header time range, track order and definitions, and the time stamps of
+// every record.
+func validateVitalFile(vf *vital.VitalFile) []ValidationIssue {
+	var issues []ValidationIssue
+	add := func(severity, track, format string, args ...any) {
+		issues = append(issues, ValidationIssue{Severity: severity, Track: track, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if vf.DtStart > vf.DtEnd {
+		add("error", "", "start time %f is after end time %f", vf.DtStart, vf.DtEnd)
+	}
+
+	seen := make(map[string]bool, len(vf.Order))
+	for _, name := range vf.Order {
+		if _, ok := vf.Trks[name]; !ok {
+			add("error", name, "listed in track order but not defined")
+		}
+		if seen[name] {
+			add("warning", name, "listed more than once in track order (redefined track)")
+		}
+		seen[name] = true
+	}
+
+	for _, name := range sortedKeys(vf.Trks) {
+		trk := vf.Trks[name]
+		switch trk.Type {
+		case 1, 2:
+			if sampleBytes(trk.Fmt) == 0 {
+				add("error", name, "unknown sample format %d", trk.Fmt)
+			}
+		case 5:
+		default:
+			add("error", name, "unknown track type %d", trk.Type)
+		}
+		if trk.Type == 1 && trk.SRate <= 0 {
+			add("error", name, "WAVE track has sample rate %v", trk.SRate)
+		}
+		if trk.DName != "" {
+			if _, ok := vf.Devs[trk.DName]; !ok {
+				add("warning", name, "device %q is not defined", trk.DName)
+			}
+		}
+
+		// 레코드 시간 검사 - 시간 범위 끝은 WAVE 길이만큼 늘어날 수 있으므로 시작만 비교
+		var badTime, outOfRange, unordered int
+		prev := math.Inf(-1)
+		err := trk.ForEachRec(func(rec vital.Rec) error {
+			switch {
+			case math.IsNaN(rec.Dt) || math.IsInf(rec.Dt, 0) || rec.Dt <= 0:
+				badTime++
+				return nil
+			case rec.Dt < vf.DtStart || rec.Dt > vf.DtEnd:
+				outOfRange++
+			}
+			if rec.Dt < prev {
+				unordered++
+			}
+			prev = rec.Dt
+			return nil
+		})
+		if err != nil {
+			add("error", name, "cannot read records: %v", err)
+			continue
+		}
+		if badTime > 0 {
+			add("error", name, "%d records have an invalid time stamp", badTime)
+		}
+		if outOfRange > 0 {
+			add("error", name, "%d records lie outside the file time range", outOfRange)
+		}
+		if unordered > 0 {
+			add("warning", name, "%d records are out of time order", unordered)
+		}
+	}
+	return issues
+}
+
+// sampleBytes returns the byte width of a sample fmt code, or 0 if the code
+// is unknown.
+func sampleBytes(fmtcode uint8) int {
+	switch fmtcode {
+	case 1, 7, 8:
+		return 4
+	case 2:
+		return 8
+	case 3, 4:
+		return 1
+	case 5, 6:
+		return 2
+	default:
+		return 0
+	}
+}
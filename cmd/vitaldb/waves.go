@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+	"github.com/mdsung/vitaldb_processor/vital/dsp"
+	"github.com/mdsung/vitaldb_processor/vital/physio"
+)
+
+// waveFlags holds the flags that transform the selected WAVE tracks (and,
+// for -artifacts, NUMERIC tracks) of the record exporting commands.
+type waveFlags struct {
+	Filter         string  // dsp 필터 체인
+	Resample       float64 // 0 = 변환 안 함
+	ResampleFilter string
+	Artifacts      string // "", drop, nan
+
+	chain dsp.Chain // prepareWaves가 해석한 -filter
+}
+
+func addWaveFlags(fs *flag.FlagSet, config *Config) {
+	w := &config.Waves
+	fs.StringVar(&w.Filter, "filter", "", "선택한 WAVE 트랙에 적용할 필터 체인 (예: baseline,bandpass:0.5-40,notch:60)")
+	fs.Float64Var(&w.Resample, "resample", 0, "선택한 WAVE 트랙을 이 샘플링 레이트로 변환 (Hz, 0 = 변환 안 함)")
+	fs.StringVar(&w.ResampleFilter, "resample-filter", vital.FilterSinc, "리샘플링 필터 (sinc: 앨리어싱 방지, linear: 선형 보간)")
+	fs.StringVar(&w.Artifacts, "artifacts", "", "선택한 트랙에서 아티팩트로 표시된 구간 처리 (drop: 제거, nan: NaN으로 대체)")
+}
+
+// prepareWaves checks the wave flags before any file is read.
+func prepareWaves(config *Config) error {
+	w := &config.Waves
+	switch w.ResampleFilter {
+	case "", vital.FilterSinc, vital.FilterLinear:
+	default:
+		return fmt.Errorf("unknown -resample-filter %q", w.ResampleFilter)
+	}
+	switch w.Artifacts {
+	case "", "drop", "nan":
+	default:
+		return fmt.Errorf("unknown -artifacts %q (drop, nan)", w.Artifacts)
+	}
+	if w.Filter != "" {
+		chain, err := dsp.ParseChain(w.Filter)
+		if err != nil {
+			return fmt.Errorf("-filter: %w", err)
+		}
+		w.chain = chain
+	}
+	return nil
+}
+
+// transformWaves replaces the WAVE tracks of vf selected by the track
+// filter flags with copies filtered by -filter and then resampled to
+// -resample. With -artifacts the windows of selected WAVE tracks and the
+// records of selected NUMERIC tracks that physio.TrackQuality flags in the
+// original track are then dropped or set to NaN.
+func transformWaves(vf *vital.VitalFile, config *Config) error {
+	w := &config.Waves
+	if w.chain == nil && w.Resample <= 0 && w.Artifacts == "" {
+		return nil
+	}
+	sel := *config
+	sel.Mode, sel.warn = ModeTracks, nil
+	tracks, err := processTracks(vf, &sel)
+	if err != nil {
+		return err
+	}
+	opts := vital.ResampleOptions{Rate: w.Resample, Filter: w.ResampleFilter}
+	for _, info := range tracks {
+		name := info.sourceName()
+		trk := vf.Trks[name]
+		var mask physio.ArtifactMask
+		if w.Artifacts != "" {
+			if mask, err = physio.TrackQuality(trk, physio.SQIOptions{}); err != nil {
+				return err
+			}
+		}
+		if trk.Type != 1 || trk.SRate <= 0 {
+			if mask != nil {
+				if vf.Trks[name], err = trk.Mask(mask.Flagged, w.Artifacts == "drop"); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if w.chain != nil {
+			if trk, err = trk.FilterWave(w.chain.Apply); err != nil {
+				return err
+			}
+		}
+		if w.Resample > 0 && float64(trk.SRate) != w.Resample {
+			if trk, err = trk.Resample(opts); err != nil {
+				return err
+			}
+		}
+		// 필터가 NaN을 퍼뜨리지 않도록 마스크는 마지막에 적용
+		if mask != nil {
+			if trk, err = trk.Mask(mask.Flagged, w.Artifacts == "drop"); err != nil {
+				return err
+			}
+		}
+		vf.Trks[name] = trk
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+func TestResampleExport(t *testing.T) {
+	code, stdout, stderr := runCLI("convert", "-quiet", "-format", "csv", "-tracks", "Bx50/ECG_II", "-resample", "250", fixture)
+	if code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("%d lines", len(lines))
+	}
+	fields := strings.SplitN(lines[1], ",", 4)
+	if n := len(strings.Fields(strings.Trim(fields[2], "[]"))); n != 250 {
+		t.Errorf("first chunk has %d samples, want 250", n)
+	}
+
+	// .vital 출력에도 적용, 선택하지 않은 트랙은 그대로
+	out := filepath.Join(t.TempDir(), "merged.vital")
+	if code, _, stderr := runCLI("merge", "-quiet", "-resample", "100", "-track-pattern", "*ECG*", "-o", out, fixture); code != exitOK {
+		t.Fatalf("merge: exit %d, stderr %q", code, stderr)
+	}
+	vf, err := vital.NewVitalFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srate := vf.Trks["Bx50/ECG_II"].SRate; srate != 100 {
+		t.Errorf("ECG SRate %g after merge -resample", srate)
+	}
+
+	if code, _, _ := runCLI("export", "-quiet", "-resample", "100", "-resample-filter", "cubic", fixture); code != exitUsage {
+		t.Errorf("unknown resampling filter: exit %d", code)
+	}
+}
+
+func TestFilterExport(t *testing.T) {
+	// 영위상 저역통과는 값만 바꾸고 청크 수와 시각은 유지
+	code, stdout, stderr := runCLI("convert", "-quiet", "-format", "csv", "-tracks", "Bx50/ECG_II", "-filter", "baseline,lowpass:40", "-resample", "250", fixture)
+	if code != exitOK {
+		t.Fatalf("exit %d, stderr %q", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[1], "Bx50/ECG_II,1721811600.000000,[") {
+		t.Fatalf("output %q", stdout)
+	}
+	if n := len(strings.Fields(strings.Trim(strings.SplitN(lines[1], ",", 4)[2], "[]"))); n != 250 {
+		t.Errorf("first chunk has %d samples, want 250", n)
+	}
+	if code, _, stderr := runCLI("export", "-quiet", "-filter", "lowpass:40-50", fixture); code != exitUsage || !strings.Contains(stderr, "-filter") {
+		t.Errorf("bad chain: exit %d, stderr %q", code, stderr)
+	}
+}
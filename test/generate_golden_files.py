@@ -10,6 +10,7 @@ CRITICAL: Python VitalDB is the golden standard. The Go implementation
 must produce identical results.
 """
 
+import argparse
 import json
 import os
 import sys
@@ -127,11 +128,25 @@ def extract_vital_data(vital_file_path):
 
 
 def main():
+    parser = argparse.ArgumentParser(description=__doc__)
+    parser.add_argument(
+        "--synthetic",
+        action="store_true",
+        help="process the checked-in fixtures in vital/testdata/synthetic "
+             "instead of data_sample/ (goldens go to testdata/golden/synthetic)",
+    )
+    args = parser.parse_args()
+
     # Determine project root and paths
     script_dir = Path(__file__).parent
     project_root = script_dir.parent
-    data_sample_dir = project_root / "data_sample"
-    golden_dir = project_root / "vital" / "testdata" / "golden"
+    testdata_dir = project_root / "vital" / "testdata"
+    if args.synthetic:
+        data_sample_dir = testdata_dir / "synthetic"
+        golden_dir = testdata_dir / "golden" / "synthetic"
+    else:
+        data_sample_dir = project_root / "data_sample"
+        golden_dir = testdata_dir / "golden"
 
     # Create golden directory if it doesn't exist
     golden_dir.mkdir(parents=True, exist_ok=True)
@@ -0,0 +1,143 @@
+package vital
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AliasTable maps vendor-specific track names to canonical signal names, so
+// that e.g. Bx50/ECG1, Solar8000/ECG_II and Intellivue/ECG_II can all be
+// queried as ECG_II. It is read from YAML or JSON:
+//
+//	signals:
+//	  - name: ECG_II
+//	    unit: mV
+//	    tracks: [Bx50/ECG1, "*/ECG_II"]
+//	  - name: HR
+//	    unit: /min
+//	    tracks: [Solar8000/HR, "*/HR"]
+type AliasTable struct {
+	Signals []Signal `json:"signals" yaml:"signals"`
+}
+
+// Signal is a canonical signal and the track name patterns recorded as it.
+// Patterns use path.Match syntax against "Device/Name", so * does not cross
+// the "/"; they are listed in order of preference.
+type Signal struct {
+	Name   string   `json:"name" yaml:"name"`
+	Unit   string   `json:"unit,omitempty" yaml:"unit,omitempty"` // 신호의 표준 단위
+	Tracks []string `json:"tracks" yaml:"tracks"`
+}
+
+// LoadAliasTable reads an alias table from a YAML or JSON file.
+func LoadAliasTable(filename string) (*AliasTable, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alias table: %w", err)
+	}
+	table, err := ParseAliasTable(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return table, nil
+}
+
+// ParseAliasTable parses an alias table from YAML or JSON (which YAML
+// accepts as well) and checks its signal names and patterns.
+func ParseAliasTable(data []byte) (*AliasTable, error) {
+	var table AliasTable
+	if err := yaml.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("invalid alias table: %w", err)
+	}
+	seen := make(map[string]bool, len(table.Signals))
+	for i, sig := range table.Signals {
+		switch {
+		case sig.Name == "":
+			return nil, fmt.Errorf("signal %d: missing name", i+1)
+		case seen[sig.Name]:
+			return nil, fmt.Errorf("signal %q: defined twice", sig.Name)
+		case len(sig.Tracks) == 0:
+			return nil, fmt.Errorf("signal %q: no track patterns", sig.Name)
+		}
+		seen[sig.Name] = true
+		for _, pattern := range sig.Tracks {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("signal %q: bad pattern %q", sig.Name, pattern)
+			}
+		}
+	}
+	return &table, nil
+}
+
+// Lookup returns the signal a track name is recorded as: the first signal
+// with a matching pattern.
+func (a *AliasTable) Lookup(trackName string) (Signal, bool) {
+	sig, _, ok := a.lookup(trackName)
+	return sig, ok
+}
+
+// lookup also returns the index of the matching pattern, which ranks the
+// tracks of a signal.
+func (a *AliasTable) lookup(trackName string) (Signal, int, bool) {
+	for _, sig := range a.Signals {
+		for rank, pattern := range sig.Tracks {
+			if ok, _ := path.Match(pattern, trackName); ok {
+				return sig, rank, true
+			}
+		}
+	}
+	return Signal{}, 0, false
+}
+
+// CanonicalTracks returns the tracks of vf recorded as the canonical signal
+// name, best first: in the order of the signal's patterns, then by track
+// name.
+func (vf *VitalFile) CanonicalTracks(a *AliasTable, name string) []*Track {
+	type ranked struct {
+		trk  *Track
+		rank int
+	}
+	var matches []ranked
+	for trkName, trk := range vf.Trks {
+		if sig, rank, ok := a.lookup(trkName); ok && sig.Name == name {
+			matches = append(matches, ranked{trk, rank})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].rank != matches[j].rank {
+			return matches[i].rank < matches[j].rank
+		}
+		return matches[i].trk.Name < matches[j].trk.Name
+	})
+
+	trks := make([]*Track, len(matches))
+	for i, m := range matches {
+		trks[i] = m.trk
+	}
+	return trks
+}
+
+// CanonicalTrack returns the preferred track of vf for the canonical signal
+// name, or nil if the file has none.
+func (vf *VitalFile) CanonicalTrack(a *AliasTable, name string) *Track {
+	if trks := vf.CanonicalTracks(a, name); len(trks) > 0 {
+		return trks[0]
+	}
+	return nil
+}
+
+// CanonicalNames maps the name of every track of vf that the table knows
+// to its canonical signal name.
+func (vf *VitalFile) CanonicalNames(a *AliasTable) map[string]string {
+	names := make(map[string]string)
+	for trkName := range vf.Trks {
+		if sig, ok := a.Lookup(trkName); ok {
+			names[trkName] = sig.Name
+		}
+	}
+	return names
+}
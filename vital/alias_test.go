@@ -0,0 +1,67 @@
+package vital
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAliasTable(t *testing.T) {
+	table, err := LoadAliasTable("testdata/aliases.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vf, err := NewVitalFile("testdata/synthetic/multi_device.vital")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sig, ok := table.Lookup("Intellivue/ART"); !ok || sig.Name != "ABP" || sig.Unit != "mmHg" {
+		t.Errorf("Lookup(Intellivue/ART) = %+v, %v", sig, ok)
+	}
+	if _, ok := table.Lookup("Bx50/ECG_II/extra"); ok {
+		t.Error("* matched across /")
+	}
+
+	// Bx50/HR가 첫 패턴이므로 우선
+	hr := vf.CanonicalTracks(table, "HR")
+	if len(hr) != 2 || hr[0].Name != "Bx50/HR" || hr[1].Name != "Intellivue/HR" {
+		t.Errorf("HR tracks = %v", trackNames(hr))
+	}
+	if trk := vf.CanonicalTrack(table, "ECG_II"); trk == nil || trk.Name != "Bx50/ECG_II" {
+		t.Errorf("ECG_II track = %v", trk)
+	}
+	if trk := vf.CanonicalTrack(table, "SpO2"); trk != nil {
+		t.Errorf("SpO2 track = %v", trk.Name)
+	}
+	if names := vf.CanonicalNames(table); len(names) != 4 || names["Intellivue/ART"] != "ABP" {
+		t.Errorf("CanonicalNames = %v", names)
+	}
+}
+
+func TestParseAliasTable(t *testing.T) {
+	json := `{"signals": [{"name": "SpO2", "tracks": ["*/PLETH_SAT_O2", "*/SPO2"]}]}`
+	if table, err := ParseAliasTable([]byte(json)); err != nil || len(table.Signals[0].Tracks) != 2 {
+		t.Errorf("JSON table: %+v, %v", table, err)
+	}
+
+	cases := map[string]string{
+		"signals: [{tracks: [a/b]}]":                                  "missing name",
+		"signals: [{name: HR}]":                                       "no track patterns",
+		"signals: [{name: HR, tracks: ['[']}]":                        "bad pattern",
+		"signals: [{name: HR, tracks: [a]}, {name: HR, tracks: [b]}]": "defined twice",
+		"signals: {name: HR}":                                         "invalid alias table",
+	}
+	for input, want := range cases {
+		if _, err := ParseAliasTable([]byte(input)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error %v, want %q", input, err, want)
+		}
+	}
+}
+
+func trackNames(trks []*Track) []string {
+	names := make([]string, len(trks))
+	for i, trk := range trks {
+		names[i] = trk.Name
+	}
+	return names
+}
@@ -0,0 +1,286 @@
+package vital
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Date shift methods recorded in AuditLog.ShiftMethod.
+const (
+	ShiftNone   = "none"
+	ShiftFixed  = "fixed"
+	ShiftKeyed  = "keyed"
+	ShiftRandom = "random"
+)
+
+// defaultMaxShiftDays bounds derived date shifts when
+// AnonymizeOptions.MaxShiftDays is not set.
+const defaultMaxShiftDays = 365
+
+// DefaultRedaction replaces matched text when RedactRule.Replacement is
+// empty.
+const DefaultRedaction = "[REDACTED]"
+
+// AnonymizeOptions configures Anonymize.
+type AnonymizeOptions struct {
+	// Shift is the date shift in seconds added to the header and every
+	// record time. If it is zero, a shift of whole days is derived: from
+	// Key and CaseID if Key is set, so that every file of a case (e.g. the
+	// parts of a split recording) moves by the same amount, otherwise at
+	// random.
+	Shift        float64
+	Key          []byte
+	CaseID       string
+	MaxShiftDays int  // 유도된 이동의 최대 일수 (0 = 365)
+	NoShift      bool // 시간을 그대로 둠
+
+	// DeviceNames renames devices; the "Device/" prefix of their track names
+	// changes with them. With ScrubDeviceNames, devices missing from the map
+	// are renamed DEV1, DEV2, ... in name order.
+	DeviceNames      map[string]string
+	ScrubDeviceNames bool
+	ScrubPorts       bool // 디바이스 포트(COM, IP 주소 등)를 비움
+
+	// Redact is applied to the values of STRING tracks in order.
+	Redact []RedactRule
+}
+
+// RedactRule redacts the values of STRING tracks. Track selects tracks by
+// full name (nil = all STRING tracks). If Drop is set, matching tracks are
+// removed; otherwise text matching Pattern is replaced with Replacement,
+// or DefaultRedaction if it is empty.
+type RedactRule struct {
+	Track       *regexp.Regexp
+	Pattern     *regexp.Regexp
+	Replacement string
+	Drop        bool
+}
+
+// AuditLog records what Anonymize changed. Since it maps new device names
+// back to the original ones and holds the date shift, it must be kept with
+// the source data and not shared with the anonymized files.
+type AuditLog struct {
+	CaseID       string       `json:"case_id,omitempty"`
+	ShiftMethod  string       `json:"shift_method"`
+	ShiftSeconds float64      `json:"shift_seconds"`
+	Changes      []AuditEntry `json:"changes"`
+}
+
+// AuditEntry is one change made by Anonymize. Target names the device or
+// track in the anonymized file. Redacted text itself is never recorded,
+// only the number of values changed.
+type AuditEntry struct {
+	Action string `json:"action"` // rename_device, clear_port, drop_track, redact
+	Target string `json:"target"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+	Count  int    `json:"count,omitempty"`
+}
+
+// Anonymize returns a de-identified copy of vf and a log of the changes:
+// times are shifted by the date shift, devices renamed and their ports
+// cleared as configured, and STRING track values redacted or dropped by
+// opts.Redact. vf is not modified.
+//
+// Like Crop, the result holds its records in memory, including those of
+// spilled tracks, and shares WAVE sample slices with vf.
+func Anonymize(vf *VitalFile, opts AnonymizeOptions) (*VitalFile, *AuditLog, error) {
+	audit := &AuditLog{CaseID: opts.CaseID, Changes: []AuditEntry{}}
+	shift, err := opts.dateShift(audit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := newEmptyFile(vf.Dgmt)
+	out.DtStart, out.DtEnd = vf.DtStart+shift, vf.DtEnd+shift
+
+	renames := opts.deviceRenames(vf)
+	devName := func(name string) string {
+		if to, ok := renames[name]; ok {
+			return to
+		}
+		return name
+	}
+	for _, name := range sortedNames(vf.Devs) {
+		dev := vf.Devs[name]
+		dev.Name = devName(name)
+		if dev.Name != name {
+			audit.add(AuditEntry{Action: "rename_device", Target: dev.Name, From: name, To: dev.Name})
+		}
+		if opts.ScrubPorts && dev.Port != "" {
+			audit.add(AuditEntry{Action: "clear_port", Target: dev.Name, From: dev.Port})
+			dev.Port = ""
+		}
+		if _, ok := out.Devs[dev.Name]; ok {
+			return nil, nil, fmt.Errorf("device %q: renamed to existing device %q", name, dev.Name)
+		}
+		out.Devs[dev.Name] = dev
+	}
+
+	for _, trk := range vf.writeOrder() {
+		dst := trk.copyMeta()
+		if trk.DName != "" {
+			dst.DName = devName(trk.DName)
+			if rest, ok := strings.CutPrefix(trk.Name, trk.DName+"/"); ok {
+				dst.Name = dst.DName + "/" + rest
+			}
+		}
+		if _, ok := out.Trks[dst.Name]; ok {
+			return nil, nil, fmt.Errorf("track %q: renamed to existing track %q", trk.Name, dst.Name)
+		}
+
+		rules := opts.rulesFor(trk)
+		if dropRule(rules) {
+			audit.add(AuditEntry{Action: "drop_track", Target: dst.Name, Count: trk.NumRecs()})
+			continue
+		}
+		redacted := 0
+		err := trk.ForEachRec(func(rec Rec) error {
+			rec.Dt += shift
+			if s, ok := rec.Val.(string); ok && len(rules) > 0 {
+				if r := redact(s, rules); r != s {
+					rec.Val = r
+					redacted++
+				}
+			}
+			dst.Recs = append(dst.Recs, rec)
+			return nil
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("track %q: %w", trk.Name, err)
+		}
+		if redacted > 0 {
+			audit.add(AuditEntry{Action: "redact", Target: dst.Name, Count: redacted})
+		}
+		out.Trks[dst.Name] = dst
+		out.Order = append(out.Order, dst.Name)
+	}
+
+	// 남은 트랙에 원본 정의 순서대로 새 ID 부여
+	out.renumber()
+	return out, audit, nil
+}
+
+// dateShift returns the shift in seconds and records how it was chosen.
+func (opts *AnonymizeOptions) dateShift(audit *AuditLog) (float64, error) {
+	switch {
+	case opts.NoShift:
+		audit.ShiftMethod = ShiftNone
+		return 0, nil
+	case opts.Shift != 0:
+		audit.ShiftMethod, audit.ShiftSeconds = ShiftFixed, opts.Shift
+		return opts.Shift, nil
+	}
+
+	maxDays := opts.MaxShiftDays
+	if maxDays <= 0 {
+		maxDays = defaultMaxShiftDays
+	}
+	var seed [8]byte
+	if len(opts.Key) > 0 {
+		if opts.CaseID == "" {
+			return 0, errors.New("keyed date shift requires a case ID")
+		}
+		mac := hmac.New(sha256.New, opts.Key)
+		mac.Write([]byte(opts.CaseID))
+		copy(seed[:], mac.Sum(nil))
+		audit.ShiftMethod = ShiftKeyed
+	} else {
+		if _, err := rand.Read(seed[:]); err != nil {
+			return 0, fmt.Errorf("random date shift: %w", err)
+		}
+		audit.ShiftMethod = ShiftRandom
+	}
+
+	// 하루 단위로 이동해 시각(하루 중 시간)은 유지, 방향은 최하위 비트로 결정
+	v := binary.LittleEndian.Uint64(seed[:])
+	days := float64(1 + (v>>1)%uint64(maxDays))
+	if v&1 == 1 {
+		days = -days
+	}
+	audit.ShiftSeconds = days * 86400
+	return audit.ShiftSeconds, nil
+}
+
+// deviceRenames returns the new name of every renamed device, including
+// devices that are only referenced by tracks.
+func (opts *AnonymizeOptions) deviceRenames(vf *VitalFile) map[string]string {
+	devs := make(map[string]Device, len(vf.Devs))
+	for name, dev := range vf.Devs {
+		devs[name] = dev
+	}
+	for _, trk := range vf.Trks {
+		if trk.DName != "" {
+			devs[trk.DName] = devs[trk.DName]
+		}
+	}
+
+	renames := make(map[string]string)
+	n := 0
+	for _, name := range sortedNames(devs) {
+		if to, ok := opts.DeviceNames[name]; ok {
+			renames[name] = to
+		} else if opts.ScrubDeviceNames {
+			n++
+			renames[name] = fmt.Sprintf("DEV%d", n)
+		}
+	}
+	return renames
+}
+
+// rulesFor returns the redaction rules that apply to trk.
+func (opts *AnonymizeOptions) rulesFor(trk *Track) []RedactRule {
+	if trk.Type != 5 {
+		return nil
+	}
+	var rules []RedactRule
+	for _, rule := range opts.Redact {
+		if rule.Track == nil || rule.Track.MatchString(trk.Name) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func dropRule(rules []RedactRule) bool {
+	for _, rule := range rules {
+		if rule.Drop {
+			return true
+		}
+	}
+	return false
+}
+
+func redact(s string, rules []RedactRule) string {
+	for _, rule := range rules {
+		if rule.Pattern == nil {
+			continue
+		}
+		repl := rule.Replacement
+		if repl == "" {
+			repl = DefaultRedaction
+		}
+		s = rule.Pattern.ReplaceAllString(s, repl)
+	}
+	return s
+}
+
+func (a *AuditLog) add(e AuditEntry) {
+	a.Changes = append(a.Changes, e)
+}
+
+func sortedNames(devs map[string]Device) []string {
+	names := make([]string, 0, len(devs))
+	for name := range devs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
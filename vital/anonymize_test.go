@@ -0,0 +1,87 @@
+package vital
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/mdsung/vitaldb_processor/vital/vitaltest"
+)
+
+// noteRecording returns syntheticRecording with a bed-named monitor holding
+// a free-text STRING track.
+func noteRecording(t *testing.T) *VitalFile {
+	b := syntheticRecording(2)
+	b.Device(2, "Intellivue", "MICUA01", "192.168.0.21")
+	b.Track(vitaltest.Track{ID: 3, Type: vitaltest.TypeString, Name: "NOTE", DevID: 2})
+	b.Track(vitaltest.Track{ID: 4, Type: vitaltest.TypeString, Name: "EVENT", DevID: 2})
+	b.String(3, syntheticStart, "pt Hong Gildong 12345678 intubated")
+	b.String(3, syntheticStart+1, "extubated")
+	b.String(4, syntheticStart+1, "Case started")
+	return readVital(t, b)
+}
+
+func TestAnonymize(t *testing.T) {
+	vf := noteRecording(t)
+	anon, audit, err := Anonymize(vf, AnonymizeOptions{
+		Key:              []byte("secret"),
+		CaseID:           "case-1",
+		ScrubDeviceNames: true,
+		ScrubPorts:       true,
+		Redact: []RedactRule{
+			{Pattern: regexp.MustCompile(`\d{8}`)},
+			{Track: regexp.MustCompile(`/NOTE$`), Pattern: regexp.MustCompile(`Hong Gildong`), Replacement: "*"},
+			{Track: regexp.MustCompile(`/EVENT$`), Drop: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shift := audit.ShiftSeconds
+	if audit.ShiftMethod != ShiftKeyed || shift == 0 || shift != float64(int(shift/86400))*86400 {
+		t.Fatalf("shift %s %f, want whole days", audit.ShiftMethod, shift)
+	}
+	if anon.DtStart != vf.DtStart+shift || anon.Trks["DEV1/HR"].Recs[1].Dt != syntheticStart+1+shift {
+		t.Errorf("times not shifted by %f", shift)
+	}
+	// 같은 키와 케이스는 같은 이동량
+	if _, again, _ := Anonymize(vf, AnonymizeOptions{Key: []byte("secret"), CaseID: "case-1"}); again.ShiftSeconds != shift {
+		t.Errorf("keyed shift %f, then %f", shift, again.ShiftSeconds)
+	}
+
+	if dev, ok := anon.Devs["DEV2"]; !ok || dev.Port != "" || dev.TypeName != "Intellivue" {
+		t.Errorf("devices = %+v", anon.Devs)
+	}
+	note := anon.Trks["DEV2/NOTE"]
+	if note == nil || note.Recs[0].Val != "pt * [REDACTED] intubated" || note.Recs[1].Val != "extubated" {
+		t.Fatalf("note = %+v", note)
+	}
+	if _, ok := anon.Trks["DEV2/EVENT"]; ok || len(anon.Trks) != 3 {
+		t.Errorf("tracks = %v", anon.Order)
+	}
+	if vf.Trks["MICUA01/NOTE"].Recs[0].Val != "pt Hong Gildong 12345678 intubated" {
+		t.Error("Anonymize modified its input")
+	}
+
+	want := map[string]int{"rename_device": 2, "clear_port": 2, "drop_track": 1, "redact": 1}
+	got := map[string]int{}
+	for _, e := range audit.Changes {
+		got[e.Action]++
+	}
+	for action, n := range want {
+		if got[action] != n {
+			t.Errorf("%d %s entries, want %d: %+v", got[action], action, n, audit.Changes)
+		}
+	}
+	diffGolden(roundTrip(t, anon), goldenFromVitalFile(anon), goldenTolerance{}).report(t)
+}
+
+func TestAnonymizeErrors(t *testing.T) {
+	vf := noteRecording(t)
+	if _, _, err := Anonymize(vf, AnonymizeOptions{Key: []byte("secret")}); err == nil {
+		t.Error("keyed shift without case ID succeeded")
+	}
+	if _, _, err := Anonymize(vf, AnonymizeOptions{NoShift: true, DeviceNames: map[string]string{"MICUA01": "Bx50"}}); err == nil {
+		t.Error("renaming onto an existing device succeeded")
+	}
+}
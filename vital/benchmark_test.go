@@ -1,24 +1,86 @@
 package vital
 
 import (
+	"bytes"
 	"testing"
 )
 
-// Benchmark tests will be added here to measure performance.
-// These tests will:
-// - Measure file parsing performance
-// - Compare different parsing strategies
-// - Track performance regressions
+// BenchmarkNewVitalFile measures end-to-end loading of a one hour synthetic
+// recording (500 Hz int16 wave plus a 1 Hz numeric track).
+func BenchmarkNewVitalFile(b *testing.B) {
+	path := writeSyntheticVital(b, 3600)
+	b.SetBytes(int64(len(syntheticVital(3600))))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewVitalFile(path); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDecodePackets measures the packet loop alone on an in-memory
+// stream, without gzip inflation.
+func BenchmarkDecodePackets(b *testing.B) {
+	stream := syntheticVital(3600)
+	b.SetBytes(int64(len(stream)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d := newDecoder(bytes.NewReader(stream))
+		vf, err := d.readHeader()
+		if err != nil {
+			b.Fatal(err)
+		}
+		for {
+			typ, pkt, err := d.next()
+			if err != nil {
+				break
+			}
+			dispatchPacket(typ, pkt, vf)
+		}
+	}
+}
 
-// Example benchmark structure (to be implemented):
-// func BenchmarkVitalFileParse(b *testing.B) {
-//     for i := 0; i < b.N; i++ {
-//         _, _ = NewVitalFile("testdata/sample.vital")
-//     }
-// }
+// BenchmarkParseRecWave measures decoding of a single 500-sample int16 chunk.
+func BenchmarkParseRecWave(b *testing.B) {
+	vf, pkt := benchRecPacket(b, 1)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		parseRec(pkt, vf)
+		vf.Trks["Bx50/ECG_II"].Recs = vf.Trks["Bx50/ECG_II"].Recs[:0]
+	}
+}
+
+// BenchmarkParseRecNumeric measures decoding of a single float32 value.
+func BenchmarkParseRecNumeric(b *testing.B) {
+	vf, pkt := benchRecPacket(b, 2)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		parseRec(pkt, vf)
+		vf.Trks["Bx50/HR"].Recs = vf.Trks["Bx50/HR"].Recs[:0]
+	}
+}
 
-// Placeholder to ensure the file compiles
-func BenchmarkPlaceholder(b *testing.B) {
-	// Benchmarks will be implemented after test data infrastructure is ready
-	b.Skip("Benchmarks will be implemented after testdata infrastructure")
+// benchRecPacket loads the metadata of a synthetic stream and returns a copy
+// of the first REC packet for the given track ID.
+func benchRecPacket(b *testing.B, tid uint16) (*VitalFile, []byte) {
+	b.Helper()
+	d := newDecoder(bytes.NewReader(syntheticVital(2)))
+	vf, err := d.readHeader()
+	if err != nil {
+		b.Fatal(err)
+	}
+	for {
+		typ, pkt, err := d.next()
+		if err != nil {
+			b.Fatalf("no REC packet for track %d", tid)
+		}
+		if typ == 1 && len(pkt) >= 12 && uint16(pkt[10])|uint16(pkt[11])<<8 == tid {
+			return vf, append([]byte(nil), pkt...)
+		}
+		dispatchPacket(typ, pkt, vf)
+	}
 }
@@ -25,18 +25,18 @@ func parseCmd(pkt []byte, vf *VitalFile) {
 		count := binary.LittleEndian.Uint16(pkt[pos : pos+2])
 		pos += 2
 
+		// 패킷에 실제로 담긴 ID 수 이상은 할당하지 않음
+		if avail := (len(pkt) - pos) / 2; int(count) > avail {
+			count = uint16(avail)
+		}
 		newOrder := make([]string, 0, count)
 		for i := 0; i < int(count); i++ {
-			if pos+2 > len(pkt) {
-				break
-			}
 			trkID := binary.LittleEndian.Uint16(pkt[pos : pos+2])
 			pos += 2
 
-			// 트랙 ID를 인덱스로 사용해서 트랙 이름 찾기
-			if int(trkID-1) < len(vf.Order) {
-				trackName := vf.Order[int(trkID-1)]
-				newOrder = append(newOrder, trackName)
+			// 트랙 ID(1부터 시작)를 인덱스로 사용해서 트랙 이름 찾기 - 0은 uint16에서 wrap되므로 제외
+			if trkID >= 1 && int(trkID) <= len(vf.Order) {
+				newOrder = append(newOrder, vf.Order[trkID-1])
 			}
 		}
 
@@ -0,0 +1,77 @@
+package vital
+
+import (
+	"fmt"
+	"math"
+)
+
+// Crop returns a new VitalFile holding the records of vf within the time
+// window [dtFrom, dtTo). WAVE chunks that straddle either edge are trimmed
+// at sample granularity using the track's SRate, so the result contains
+// exactly the samples whose time falls inside the window. All devices and
+// track definitions are kept, including tracks left without records, and
+// DtStart and DtEnd span the remaining records (or the window, if none
+// remain).
+//
+// Pass math.Inf(-1) or math.Inf(1) to leave an edge open. The result holds
+// its records in memory, including those of spilled tracks, and shares
+// sample slices with vf.
+func (vf *VitalFile) Crop(dtFrom, dtTo float64) (*VitalFile, error) {
+	if !(dtFrom < dtTo) {
+		return nil, fmt.Errorf("invalid time range: [%f, %f)", dtFrom, dtTo)
+	}
+
+	out := vf.copyStructure()
+	kept := false
+	for name, trk := range vf.Trks {
+		dst := trk.copyMeta()
+		out.Trks[name] = dst
+		err := trk.ForEachRec(func(rec Rec) error {
+			rec, keep := dst.trimBefore(rec, dtFrom)
+			if keep {
+				rec, keep = dst.trimAfter(rec, dtTo)
+			}
+			if keep {
+				dst.Recs = append(dst.Recs, rec)
+				kept = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("track %q: %w", name, err)
+		}
+	}
+	out.linkTracks()
+
+	if kept {
+		out.recomputeTimeRange()
+	} else {
+		out.DtStart = math.Max(dtFrom, vf.DtStart)
+		out.DtEnd = math.Max(out.DtStart, math.Min(dtTo, vf.DtEnd))
+	}
+	return out, nil
+}
+
+// trimAfter drops the part of rec at or after time to. Instantaneous
+// records at or after to are dropped; WAVE chunks lose their trailing
+// samples.
+func (t *Track) trimAfter(rec Rec, to float64) (Rec, bool) {
+	if rec.Dt >= to {
+		return rec, false
+	}
+	if t.Type != 1 || t.SRate <= 0 || t.recEnd(&rec) <= to {
+		return rec, true
+	}
+	keep := samplesBefore(rec.Dt, to, t.SRate)
+	if keep <= 0 {
+		return rec, false
+	}
+	return Rec{Dt: rec.Dt, Val: sliceSamples(rec.Val, 0, keep)}, true
+}
+
+// samplesBefore returns the number of samples of a chunk starting at dt
+// whose time is before t.
+func samplesBefore(dt, t float64, srate float32) int {
+	// 1e-9 샘플 여유: t가 샘플 경계와 같을 때 부동소수점 오차로 한 샘플이 더 포함되지 않도록
+	return int(math.Ceil((t-dt)*float64(srate) - 1e-9))
+}
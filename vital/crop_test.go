@@ -0,0 +1,73 @@
+package vital
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCropTrimsWaveAtSampleGranularity(t *testing.T) {
+	vf := readVital(t, syntheticRecording(5)) // ECG 500 Hz, HR 1 Hz
+	from, to := syntheticStart+1.25, syntheticStart+3.5
+
+	cropped, err := vf.Crop(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecg := cropped.Trks["Bx50/ECG_II"]
+	total := 0
+	for _, rec := range ecg.Recs {
+		total += sampleCount(rec.Val)
+	}
+	if total != int(2.25*500) {
+		t.Errorf("kept %d ECG samples, want %d", total, int(2.25*500))
+	}
+	first, last := ecg.Recs[0], ecg.Recs[len(ecg.Recs)-1]
+	if first.Dt != from || sampleCount(first.Val) != 375 {
+		t.Errorf("first chunk at %f with %d samples", first.Dt-syntheticStart, sampleCount(first.Val))
+	}
+	// 첫 청크는 원본 1초 청크의 125번째 샘플부터 시작
+	if got, want := first.Val.([]int16)[0], vf.Trks["Bx50/ECG_II"].Recs[1].Val.([]int16)[125]; got != want {
+		t.Errorf("first sample = %d, want %d", got, want)
+	}
+	if end := ecg.recEnd(&last); math.Abs(end-to) > 1e-9 {
+		t.Errorf("last chunk ends at %f", end-syntheticStart)
+	}
+
+	// HR: 2초, 3초 값만 구간 안
+	if hr := cropped.Trks["Bx50/HR"].Recs; len(hr) != 2 || hr[0].Dt != syntheticStart+2 {
+		t.Errorf("HR records = %+v", hr)
+	}
+	if cropped.DtStart != from || math.Abs(cropped.DtEnd-to) > 1e-9 {
+		t.Errorf("time range = [%f, %f]", cropped.DtStart-syntheticStart, cropped.DtEnd-syntheticStart)
+	}
+	if len(cropped.Devs) != 1 || len(cropped.Order) != 2 {
+		t.Errorf("devices %v, order %v", cropped.Devs, cropped.Order)
+	}
+
+	// 원본은 그대로, 잘라낸 결과는 다시 쓸 수 있음
+	if vf.Trks["Bx50/ECG_II"].NumRecs() != 5 {
+		t.Error("Crop modified its input")
+	}
+	diffGolden(roundTrip(t, cropped), goldenFromVitalFile(cropped), goldenTolerance{}).report(t)
+}
+
+func TestCropEdges(t *testing.T) {
+	vf := readVital(t, syntheticRecording(3))
+
+	all, err := vf.Crop(math.Inf(-1), math.Inf(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffGolden(all, goldenFromVitalFile(vf), goldenTolerance{}).report(t)
+
+	empty, err := vf.Crop(syntheticStart+10, syntheticStart+20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty.Trks["Bx50/HR"].NumRecs() != 0 || empty.DtStart != syntheticStart+10 || empty.DtEnd != syntheticStart+10 {
+		t.Errorf("empty crop: [%f, %f]", empty.DtStart-syntheticStart, empty.DtEnd-syntheticStart)
+	}
+	if _, err := vf.Crop(syntheticStart+2, syntheticStart+1); err == nil {
+		t.Error("reversed range should fail")
+	}
+}
@@ -0,0 +1,200 @@
+package dsp
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Filter stage kinds.
+const (
+	KindLowpass    = "lowpass"     // Butterworth, lowpass:40
+	KindHighpass   = "highpass"    // Butterworth, highpass:0.5
+	KindBandpass   = "bandpass"    // Butterworth, bandpass:0.5-40
+	KindBandstop   = "bandstop"    // Butterworth, bandstop:48-52
+	KindNotch      = "notch"       // 2차 notch, notch:60 또는 notch:60/30 (Q)
+	KindBaseline   = "baseline"    // 기저선 변동 제거 (2차 Butterworth 고역통과), baseline 또는 baseline:0.5
+	KindFIRLowpass = "fir-lowpass" // Kaiser 창 sinc, fir-lowpass:40
+)
+
+// Filter defaults.
+const (
+	DefaultOrder        = 4   // lowpass, highpass
+	DefaultBandOrder    = 2   // bandpass, bandstop (필터 차수는 두 배)
+	DefaultNotchQ       = 30  // 60 Hz에서 대역폭 2 Hz
+	DefaultBaselineFreq = 0.5 // Hz
+)
+
+// Stage is one filter of a Chain. Frequencies are in Hz; Order is the
+// Butterworth order, or for fir-lowpass the half length in taps (0 selects
+// the default).
+type Stage struct {
+	Kind  string
+	Low   float64 // 차단 주파수 (lowpass, highpass, notch, baseline) 또는 대역 아래 끝
+	High  float64 // 대역 위 끝 (bandpass, bandstop)
+	Q     float64 // notch
+	Order int
+}
+
+// Chain is a sequence of filters applied in order. Its text form is a comma
+// separated list of stages such as "baseline,bandpass:0.5-40@2,notch:60";
+// "@n" sets the order.
+type Chain []Stage
+
+// ParseChain parses the text form of a filter chain.
+func ParseChain(spec string) (Chain, error) {
+	var c Chain
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		st, err := parseStage(item)
+		if err != nil {
+			return nil, fmt.Errorf("filter %q: %w", item, err)
+		}
+		c = append(c, st)
+	}
+	if len(c) == 0 {
+		return nil, fmt.Errorf("empty filter chain %q", spec)
+	}
+	return c, nil
+}
+
+func parseStage(item string) (Stage, error) {
+	var st Stage
+	if rest, order, ok := strings.Cut(item, "@"); ok {
+		n, err := strconv.Atoi(order)
+		if err != nil || n < 1 {
+			return st, fmt.Errorf("invalid order %q", order)
+		}
+		st.Order, item = n, rest
+	}
+	kind, args, _ := strings.Cut(item, ":")
+	st.Kind = strings.ToLower(strings.TrimSpace(kind))
+	num := func(s string) (float64, error) {
+		v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil || !(v > 0) {
+			return 0, fmt.Errorf("invalid frequency %q", s)
+		}
+		return v, nil
+	}
+
+	var err error
+	switch st.Kind {
+	case KindLowpass, KindHighpass, KindFIRLowpass:
+		st.Low, err = num(args)
+	case KindBandpass, KindBandstop:
+		lo, hi, ok := strings.Cut(args, "-")
+		if !ok {
+			return st, fmt.Errorf("want %s:LOW-HIGH", st.Kind)
+		}
+		if st.Low, err = num(lo); err == nil {
+			st.High, err = num(hi)
+		}
+		if err == nil && st.Low >= st.High {
+			err = fmt.Errorf("invalid band %g-%g Hz", st.Low, st.High)
+		}
+	case KindNotch:
+		f, q, hasQ := strings.Cut(args, "/")
+		st.Q = DefaultNotchQ
+		if st.Low, err = num(f); err == nil && hasQ {
+			st.Q, err = num(q)
+		}
+	case KindBaseline:
+		st.Low = DefaultBaselineFreq
+		if args != "" {
+			st.Low, err = num(args)
+		}
+	default:
+		err = fmt.Errorf("unknown filter kind %q", st.Kind)
+	}
+	return st, err
+}
+
+// String returns the text form of the chain.
+func (c Chain) String() string {
+	items := make([]string, len(c))
+	for i, st := range c {
+		items[i] = st.String()
+	}
+	return strings.Join(items, ",")
+}
+
+// String returns the text form of the stage.
+func (st Stage) String() string {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	s := st.Kind + ":" + f(st.Low)
+	switch st.Kind {
+	case KindBandpass, KindBandstop:
+		s += "-" + f(st.High)
+	case KindNotch:
+		s += "/" + f(st.Q)
+	}
+	if st.Order > 0 {
+		s += "@" + strconv.Itoa(st.Order)
+	}
+	return s
+}
+
+// Apply filters x sampled at fs Hz through every stage. IIR stages run
+// forward and backward (SOS.FiltFilt) and FIR stages centred (FIR.Apply),
+// so the chain does not shift the signal in time.
+func (c Chain) Apply(x []float64, fs float64) ([]float64, error) {
+	y := x
+	for _, st := range c {
+		if st.Kind == KindFIRLowpass {
+			half := st.Order
+			if half == 0 {
+				half = int(math.Ceil(4 * fs / st.Low))
+			}
+			h, err := LowpassFIR(st.Low, fs, half, 0)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", st, err)
+			}
+			y = h.Apply(y)
+			continue
+		}
+		sos, err := st.design(fs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", st, err)
+		}
+		y = sos.FiltFilt(y)
+	}
+	if len(c) == 0 {
+		y = append([]float64(nil), x...)
+	}
+	return y, nil
+}
+
+// design returns the biquads of an IIR stage for sample rate fs.
+func (st Stage) design(fs float64) (SOS, error) {
+	order := func(def int) int {
+		if st.Order > 0 {
+			return st.Order
+		}
+		return def
+	}
+	switch st.Kind {
+	case KindLowpass:
+		return ButterLowpass(order(DefaultOrder), st.Low, fs)
+	case KindHighpass:
+		return ButterHighpass(order(DefaultOrder), st.Low, fs)
+	case KindBandpass:
+		return ButterBandpass(order(DefaultBandOrder), st.Low, st.High, fs)
+	case KindBandstop:
+		return ButterBandstop(order(DefaultBandOrder), st.Low, st.High, fs)
+	case KindNotch:
+		return Notch(st.Low, st.Q, fs)
+	case KindBaseline:
+		return ButterHighpass(order(2), st.Low, fs)
+	}
+	return nil, fmt.Errorf("unknown filter kind %q", st.Kind)
+}
+
+// RemoveBaseline removes baseline wander below cutoff Hz (typically 0.5 Hz
+// for ECG) with a zero-phase second order Butterworth high-pass filter.
+func RemoveBaseline(x []float64, fs, cutoff float64) ([]float64, error) {
+	return Chain{{Kind: KindBaseline, Low: cutoff}}.Apply(x, fs)
+}
@@ -0,0 +1,55 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseChain(t *testing.T) {
+	c, err := ParseChain("baseline, bandpass:0.5-40@3, notch:60, notch:50/10, lowpass:100, fir-lowpass:30@50")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "baseline:0.5,bandpass:0.5-40@3,notch:60/30,notch:50/10,lowpass:100,fir-lowpass:30@50"
+	if got := c.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	for _, bad := range []string{"", "lowpass", "bandpass:40-5", "bandpass:5", "wavelet:3", "lowpass:40@0", "notch:-60"} {
+		if _, err := ParseChain(bad); err == nil {
+			t.Errorf("ParseChain(%q) should fail", bad)
+		}
+	}
+}
+
+func TestChainApply(t *testing.T) {
+	const fs = 250
+	// 0.1 Hz 기저선 변동 + 10 Hz 신호 + 60 Hz 험
+	x := make([]float64, 20*fs)
+	for i := range x {
+		tm := float64(i) / fs
+		x[i] = 3*math.Sin(2*math.Pi*0.1*tm) + math.Sin(2*math.Pi*10*tm) + 0.3*math.Sin(2*math.Pi*60*tm)
+	}
+	c, err := ParseChain("baseline,notch:60")
+	if err != nil {
+		t.Fatal(err)
+	}
+	y, err := c.Apply(x, fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var worst float64
+	for i := 5 * fs; i < 15*fs; i++ {
+		worst = math.Max(worst, math.Abs(y[i]-math.Sin(2*math.Pi*10*float64(i)/fs)))
+	}
+	if worst > 0.05 {
+		t.Errorf("max error %g after baseline and notch", worst)
+	}
+
+	// 샘플링 레이트에 맞지 않는 필터
+	if _, err := c.Apply(x, 100); err == nil {
+		t.Error("60 Hz notch at 100 Hz should fail")
+	}
+	if y, _ := RemoveBaseline(x, fs, 0.5); len(y) != len(x) {
+		t.Errorf("RemoveBaseline returned %d samples", len(y))
+	}
+}
@@ -0,0 +1,133 @@
+package dsp
+
+import (
+	"fmt"
+	"math"
+)
+
+// DefaultKaiserBeta is the Kaiser window parameter used when none is given:
+// about 80 dB of stopband attenuation.
+const DefaultKaiserBeta = 8
+
+// FIR holds the taps of a finite impulse response filter. The filters
+// designed here have an odd number of symmetric taps centred on
+// len(h)/2.
+type FIR []float64
+
+// WindowedSinc returns a low-pass FIR with 2*half+1 taps, cutoff fc in
+// cycles per sample (0 < fc <= 0.5) and a Kaiser window with parameter
+// beta. The taps are scaled to unit gain at DC.
+func WindowedSinc(fc float64, half int, beta float64) FIR {
+	h := make(FIR, 2*half+1)
+	var sum float64
+	for i := range h {
+		d := float64(i - half)
+		w := 1.0
+		if half > 0 {
+			w = Kaiser(d/float64(half), beta)
+		}
+		h[i] = sinc(2*fc*d) * w
+		sum += h[i]
+	}
+	for i := range h {
+		h[i] /= sum
+	}
+	return h
+}
+
+// LowpassFIR designs a windowed-sinc low-pass filter with cutoff fc Hz for
+// sample rate fs, 2*half+1 taps and Kaiser parameter beta (0 selects
+// DefaultKaiserBeta).
+func LowpassFIR(fc, fs float64, half int, beta float64) (FIR, error) {
+	if err := checkDesign(1, fs, fc); err != nil {
+		return nil, err
+	}
+	if half < 1 {
+		return nil, fmt.Errorf("invalid FIR half length %d", half)
+	}
+	if beta <= 0 {
+		beta = DefaultKaiserBeta
+	}
+	return WindowedSinc(fc/fs, half, beta), nil
+}
+
+// HighpassFIR designs a windowed-sinc high-pass filter by spectral
+// inversion of LowpassFIR.
+func HighpassFIR(fc, fs float64, half int, beta float64) (FIR, error) {
+	h, err := LowpassFIR(fc, fs, half, beta)
+	if err != nil {
+		return nil, err
+	}
+	for i := range h {
+		h[i] = -h[i]
+	}
+	h[half]++
+	return h, nil
+}
+
+// Filter convolves x with h causally, as if x were preceded by zeros.
+func (h FIR) Filter(x []float64) []float64 {
+	y := make([]float64, len(x))
+	for n := range y {
+		var acc float64
+		for k, c := range h {
+			if n-k < 0 {
+				break
+			}
+			acc += c * x[n-k]
+		}
+		y[n] = acc
+	}
+	return y
+}
+
+// Apply convolves x with h centred on its middle tap, extending the first
+// and last samples beyond the edges. For the symmetric filters designed
+// here this is zero-phase.
+func (h FIR) Apply(x []float64) []float64 {
+	if len(x) == 0 {
+		return nil
+	}
+	half := len(h) / 2
+	y := make([]float64, len(x))
+	for n := range y {
+		var acc float64
+		for k, c := range h {
+			i := n + half - k
+			switch {
+			case i < 0:
+				i = 0
+			case i >= len(x):
+				i = len(x) - 1
+			}
+			acc += c * x[i]
+		}
+		y[n] = acc
+	}
+	return y
+}
+
+// Kaiser returns the Kaiser window with parameter beta at x in [-1, 1].
+func Kaiser(x, beta float64) float64 {
+	return besselI0(beta*math.Sqrt(math.Max(0, 1-x*x))) / besselI0(beta)
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// besselI0 is the zeroth order modified Bessel function of the first kind.
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; k < 50; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+		if term < sum*1e-16 {
+			break
+		}
+	}
+	return sum
+}
@@ -0,0 +1,50 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFIR(t *testing.T) {
+	const fs = 500
+	lp, err := LowpassFIR(40, fs, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hp, err := HighpassFIR(40, fs, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sum float64
+	for i, c := range lp {
+		sum += c
+		if c != lp[len(lp)-1-i] {
+			t.Fatal("taps are not symmetric")
+		}
+	}
+	if len(lp) != 201 || math.Abs(sum-1) > 1e-12 {
+		t.Errorf("%d taps with DC gain %g", len(lp), sum)
+	}
+
+	// 5 Hz는 저역통과, 150 Hz는 고역통과
+	x := make([]float64, 4*fs)
+	for i := range x {
+		tm := float64(i) / fs
+		x[i] = math.Sin(2*math.Pi*5*tm) + math.Sin(2*math.Pi*150*tm)
+	}
+	low, high := lp.Apply(x), hp.Apply(x)
+	for i := fs; i < 3*fs; i++ {
+		tm := float64(i) / fs
+		if d := low[i] - math.Sin(2*math.Pi*5*tm); math.Abs(d) > 1e-3 {
+			t.Fatalf("low-pass sample %d off by %g", i, d)
+		}
+		if d := high[i] - math.Sin(2*math.Pi*150*tm); math.Abs(d) > 1e-3 {
+			t.Fatalf("high-pass sample %d off by %g", i, d)
+		}
+	}
+	// 인과 필터는 중앙 탭만큼 늦음
+	causal := lp.Filter(x)
+	if d := causal[2*fs] - low[2*fs-100]; math.Abs(d) > 1e-9 {
+		t.Errorf("causal output is not delayed by 100 samples (%g)", d)
+	}
+}
@@ -0,0 +1,280 @@
+// Package dsp implements the digital filters used to preprocess waveforms:
+// Butterworth and notch IIR filters as cascades of biquads, windowed-sinc
+// FIR filters, zero-phase (forward-backward) filtering and filter chains
+// parsed from a short text form. It works on plain []float64 sample slices
+// and does not depend on the .vital reader.
+package dsp
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+)
+
+// Biquad is a second order section with transfer function
+// (B0 + B1 z^-1 + B2 z^-2) / (1 + A1 z^-1 + A2 z^-2). First order sections
+// have B2 = A2 = 0.
+type Biquad struct {
+	B0, B1, B2 float64
+	A1, A2     float64
+}
+
+// SOS is a cascade of second order sections.
+type SOS []Biquad
+
+// Filter applies the cascade causally, starting from zero state.
+func (s SOS) Filter(x []float64) []float64 {
+	y := append([]float64(nil), x...)
+	for _, q := range s {
+		q.run(y, 0, 0)
+	}
+	return y
+}
+
+// FiltFilt applies the cascade forward and backward, which cancels its
+// phase shift and squares its magnitude response. The signal is extended
+// at both ends by odd reflection and the filter starts in steady state, so
+// the edges do not ring.
+func (s SOS) FiltFilt(x []float64) []float64 {
+	if len(x) == 0 {
+		return nil
+	}
+	pad := 3 * (2*len(s) + 1)
+	if pad > len(x)-1 {
+		pad = len(x) - 1
+	}
+	// 홀수 반사: 2*x[0] - x[pad..1], x, 2*x[n-1] - x[n-2..n-1-pad]
+	n := len(x)
+	ext := make([]float64, 0, n+2*pad)
+	for i := pad; i >= 1; i-- {
+		ext = append(ext, 2*x[0]-x[i])
+	}
+	ext = append(ext, x...)
+	for i := n - 2; i >= n-1-pad; i-- {
+		ext = append(ext, 2*x[n-1]-x[i])
+	}
+
+	s.steady(ext)
+	reverse(ext)
+	s.steady(ext)
+	reverse(ext)
+	return ext[pad : pad+n]
+}
+
+// steady filters y in place, starting each section in the steady state for
+// a constant input equal to its first sample.
+func (s SOS) steady(y []float64) {
+	for _, q := range s {
+		u := y[0]
+		g := q.dcGain()
+		out := g * u
+		// 전치 직접형 II 정상 상태: z2 = b2*u - a2*y, z1 = b1*u - a1*y + z2
+		z2 := q.B2*u - q.A2*out
+		z1 := q.B1*u - q.A1*out + z2
+		q.run(y, z1, z2)
+	}
+}
+
+// run filters y in place (transposed direct form II) from state z1, z2.
+func (q Biquad) run(y []float64, z1, z2 float64) {
+	for i, x := range y {
+		out := q.B0*x + z1
+		z1 = q.B1*x - q.A1*out + z2
+		z2 = q.B2*x - q.A2*out
+		y[i] = out
+	}
+}
+
+func (q Biquad) dcGain() float64 {
+	return (q.B0 + q.B1 + q.B2) / (1 + q.A1 + q.A2)
+}
+
+// Response returns the complex frequency response of the cascade at f Hz
+// for sample rate fs.
+func (s SOS) Response(f, fs float64) complex128 {
+	z := cmplx.Exp(complex(0, -2*math.Pi*f/fs)) // z^-1
+	h := complex(1, 0)
+	for _, q := range s {
+		num := complex(q.B0, 0) + complex(q.B1, 0)*z + complex(q.B2, 0)*z*z
+		den := 1 + complex(q.A1, 0)*z + complex(q.A2, 0)*z*z
+		h *= num / den
+	}
+	return h
+}
+
+// ButterLowpass designs an order-n Butterworth low-pass filter with a -3 dB
+// cutoff at fc Hz.
+func ButterLowpass(n int, fc, fs float64) (SOS, error) {
+	if err := checkDesign(n, fs, fc); err != nil {
+		return nil, err
+	}
+	wc := prewarp(fc, fs)
+	var poles []complex128
+	for _, p := range butterPrototype(n) {
+		poles = append(poles, p*complex(wc, 0))
+	}
+	return sections(poles, fs, func(int) [3]float64 { return [3]float64{1, 2, 1} }, 0), nil
+}
+
+// ButterHighpass designs an order-n Butterworth high-pass filter with a -3
+// dB cutoff at fc Hz.
+func ButterHighpass(n int, fc, fs float64) (SOS, error) {
+	if err := checkDesign(n, fs, fc); err != nil {
+		return nil, err
+	}
+	wc := prewarp(fc, fs)
+	var poles []complex128
+	for _, p := range butterPrototype(n) {
+		poles = append(poles, complex(wc, 0)/p)
+	}
+	return sections(poles, fs, func(int) [3]float64 { return [3]float64{1, -2, 1} }, fs/2), nil
+}
+
+// ButterBandpass designs a Butterworth band-pass filter passing lo to hi Hz
+// (-3 dB edges). The prototype has order n, so the filter has order 2n.
+func ButterBandpass(n int, lo, hi, fs float64) (SOS, error) {
+	if err := checkDesign(n, fs, lo, hi); err != nil {
+		return nil, err
+	}
+	if lo >= hi {
+		return nil, fmt.Errorf("invalid band %g-%g Hz", lo, hi)
+	}
+	wl, wh := prewarp(lo, fs), prewarp(hi, fs)
+	w0, bw := math.Sqrt(wl*wh), wh-wl
+	var poles []complex128
+	for _, p := range butterPrototype(n) {
+		// s^2 - p*bw*s + w0^2 = 0
+		pb := p * complex(bw, 0)
+		d := cmplx.Sqrt(pb*pb - complex(4*w0*w0, 0))
+		poles = append(poles, (pb+d)/2, (pb-d)/2)
+	}
+	center := math.Atan(w0/(2*fs)) * fs / math.Pi
+	return sections(poles, fs, func(int) [3]float64 { return [3]float64{1, 0, -1} }, center), nil
+}
+
+// ButterBandstop designs a Butterworth band-stop filter rejecting lo to hi
+// Hz (-3 dB edges). The prototype has order n, so the filter has order 2n.
+func ButterBandstop(n int, lo, hi, fs float64) (SOS, error) {
+	if err := checkDesign(n, fs, lo, hi); err != nil {
+		return nil, err
+	}
+	if lo >= hi {
+		return nil, fmt.Errorf("invalid band %g-%g Hz", lo, hi)
+	}
+	wl, wh := prewarp(lo, fs), prewarp(hi, fs)
+	w0, bw := math.Sqrt(wl*wh), wh-wl
+	var poles []complex128
+	for _, p := range butterPrototype(n) {
+		// s^2 - (bw/p)*s + w0^2 = 0
+		bp := complex(bw, 0) / p
+		d := cmplx.Sqrt(bp*bp - complex(4*w0*w0, 0))
+		poles = append(poles, (bp+d)/2, (bp-d)/2)
+	}
+	// 영점: 디지털 중심 주파수의 단위원 위
+	c := -2 * math.Cos(2*math.Atan(w0/(2*fs)))
+	return sections(poles, fs, func(int) [3]float64 { return [3]float64{1, c, 1} }, 0), nil
+}
+
+// Notch designs a second order notch filter at f0 Hz with quality factor q
+// (bandwidth f0/q), as in the Audio EQ Cookbook.
+func Notch(f0, q, fs float64) (SOS, error) {
+	if err := checkDesign(1, fs, f0); err != nil {
+		return nil, err
+	}
+	if q <= 0 {
+		return nil, fmt.Errorf("invalid notch quality factor %g", q)
+	}
+	w0 := 2 * math.Pi * f0 / fs
+	alpha := math.Sin(w0) / (2 * q)
+	a0 := 1 + alpha
+	c := -2 * math.Cos(w0) / a0
+	return SOS{{B0: 1 / a0, B1: c, B2: 1 / a0, A1: c, A2: (1 - alpha) / a0}}, nil
+}
+
+// checkDesign checks the order and that the frequencies are between 0 and
+// the Nyquist frequency.
+func checkDesign(n int, fs float64, freqs ...float64) error {
+	if n < 1 {
+		return fmt.Errorf("invalid filter order %d", n)
+	}
+	if !(fs > 0) {
+		return fmt.Errorf("invalid sample rate %g", fs)
+	}
+	for _, f := range freqs {
+		if !(f > 0 && f < fs/2) {
+			return fmt.Errorf("frequency %g Hz is outside (0, %g) for sample rate %g Hz", f, fs/2, fs)
+		}
+	}
+	return nil
+}
+
+// butterPrototype returns the poles of the order-n analog Butterworth
+// low-pass prototype with unit cutoff.
+func butterPrototype(n int) []complex128 {
+	poles := make([]complex128, n)
+	for k := range poles {
+		poles[k] = cmplx.Exp(complex(0, math.Pi*float64(2*k+n+1)/float64(2*n)))
+	}
+	return poles
+}
+
+// prewarp returns the analog frequency (rad/s) that the bilinear transform
+// maps to f Hz.
+func prewarp(f, fs float64) float64 {
+	return 2 * fs * math.Tan(math.Pi*f/fs)
+}
+
+// sections maps analog poles to the z-plane with the bilinear transform,
+// pairs them into biquads with numerators num(i), and scales each section
+// to unit gain at fref Hz.
+func sections(analog []complex128, fs float64, num func(i int) [3]float64, fref float64) SOS {
+	var complexPoles []complex128
+	var realPoles []float64
+	for _, s := range analog {
+		z := (complex(2*fs, 0) + s) / (complex(2*fs, 0) - s)
+		switch {
+		case math.Abs(imag(z)) < 1e-12:
+			realPoles = append(realPoles, real(z))
+		case imag(z) > 0:
+			complexPoles = append(complexPoles, z)
+		}
+	}
+	// 결정적인 순서: 단위원에서 먼 극점부터
+	sort.Slice(complexPoles, func(i, j int) bool { return cmplx.Abs(complexPoles[i]) < cmplx.Abs(complexPoles[j]) })
+	sort.Float64s(realPoles)
+
+	var sos SOS
+	for _, z := range complexPoles {
+		b := num(len(sos))
+		sos = append(sos, Biquad{B0: b[0], B1: b[1], B2: b[2], A1: -2 * real(z), A2: real(z)*real(z) + imag(z)*imag(z)})
+	}
+	for i := 0; i+1 < len(realPoles); i += 2 {
+		b := num(len(sos))
+		r1, r2 := realPoles[i], realPoles[i+1]
+		sos = append(sos, Biquad{B0: b[0], B1: b[1], B2: b[2], A1: -(r1 + r2), A2: r1 * r2})
+	}
+	if len(realPoles)%2 == 1 {
+		// 홀수 차수 저역/고역통과: 1차 구간 (영점 하나)
+		b := num(len(sos))
+		r := realPoles[len(realPoles)-1]
+		b1 := 1.0
+		if b[1] < 0 {
+			b1 = -1
+		}
+		sos = append(sos, Biquad{B0: 1, B1: b1, A1: -r})
+	}
+	for i := range sos {
+		g := cmplx.Abs(SOS{sos[i]}.Response(fref, fs))
+		sos[i].B0 /= g
+		sos[i].B1 /= g
+		sos[i].B2 /= g
+	}
+	return sos
+}
+
+func reverse(x []float64) {
+	for i, j := 0, len(x)-1; i < j; i, j = i+1, j-1 {
+		x[i], x[j] = x[j], x[i]
+	}
+}
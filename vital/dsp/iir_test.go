@@ -0,0 +1,89 @@
+package dsp
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func gainDB(s SOS, f, fs float64) float64 {
+	return 20 * math.Log10(cmplx.Abs(s.Response(f, fs)))
+}
+
+func TestButterworth(t *testing.T) {
+	const fs = 500
+	tests := []struct {
+		name   string
+		design func() (SOS, error)
+		pass   []float64 // 0 dB 근처
+		edge   []float64 // -3 dB
+		stop   []float64 // -40 dB 이하
+	}{
+		{"lowpass", func() (SOS, error) { return ButterLowpass(4, 40, fs) }, []float64{1, 10}, []float64{40}, []float64{150, 240}},
+		{"lowpass odd", func() (SOS, error) { return ButterLowpass(5, 40, fs) }, []float64{1, 10}, []float64{40}, []float64{150}},
+		{"highpass", func() (SOS, error) { return ButterHighpass(4, 1, fs) }, []float64{10, 100}, []float64{1}, []float64{0.1}},
+		{"bandpass", func() (SOS, error) { return ButterBandpass(2, 5, 40, fs) }, []float64{15}, []float64{5, 40}, []float64{0.2, 200}},
+		{"bandstop", func() (SOS, error) { return ButterBandstop(2, 55, 65, fs) }, []float64{1, 150}, []float64{55, 65}, []float64{60}},
+	}
+	for _, tt := range tests {
+		sos, err := tt.design()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for _, f := range tt.pass {
+			if g := gainDB(sos, f, fs); math.Abs(g) > 0.5 {
+				t.Errorf("%s: %g dB at %g Hz", tt.name, g, f)
+			}
+		}
+		for _, f := range tt.edge {
+			if g := gainDB(sos, f, fs); math.Abs(g+3.01) > 0.05 {
+				t.Errorf("%s: %g dB at edge %g Hz", tt.name, g, f)
+			}
+		}
+		for _, f := range tt.stop {
+			if g := gainDB(sos, f, fs); g > -40 {
+				t.Errorf("%s: %g dB at %g Hz", tt.name, g, f)
+			}
+		}
+	}
+
+	if _, err := ButterLowpass(4, 300, fs); err == nil {
+		t.Error("cutoff above Nyquist should fail")
+	}
+	if _, err := ButterBandpass(2, 40, 5, fs); err == nil {
+		t.Error("reversed band should fail")
+	}
+}
+
+func TestNotchFiltFilt(t *testing.T) {
+	const fs = 500
+	notch, err := Notch(60, 30, fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := gainDB(notch, 60, fs); g > -60 {
+		t.Errorf("notch: %g dB at 60 Hz", g)
+	}
+
+	// 5 Hz 신호 + 60 Hz 험 + DC: 영위상 필터링 후 5 Hz 신호가 위상 지연 없이 남음
+	x := make([]float64, 5*fs)
+	for i := range x {
+		tm := float64(i) / fs
+		x[i] = 2 + math.Sin(2*math.Pi*5*tm) + 0.5*math.Sin(2*math.Pi*60*tm)
+	}
+	y := notch.FiltFilt(x)
+	for i := fs; i < 4*fs; i++ {
+		want := 2 + math.Sin(2*math.Pi*5*float64(i)/fs)
+		if math.Abs(y[i]-want) > 0.01 {
+			t.Fatalf("sample %d = %g, want %g", i, y[i], want)
+		}
+	}
+	// 정상 상태로 시작하므로 가장자리에서도 DC가 유지됨
+	if math.Abs(y[0]-x[0]) > 0.3 {
+		t.Errorf("edge sample %g, input %g", y[0], x[0])
+	}
+	// 인과 필터는 같은 길이
+	if len(notch.Filter(x)) != len(x) {
+		t.Error("Filter changed the length")
+	}
+}
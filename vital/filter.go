@@ -0,0 +1,93 @@
+package vital
+
+import "fmt"
+
+// WaveSegment is a contiguous stretch of a WAVE track (see Segments) as
+// calibrated samples.
+type WaveSegment struct {
+	Start  float64   // Values[0]의 시각
+	SRate  float64   // 트랙의 SRate
+	Values []float64 // 보정한 값 (Track.Calibrate)
+	Chunks []int     // 각 레코드의 첫 샘플 위치 (Values 인덱스)
+}
+
+// Time returns the time of sample i.
+func (s WaveSegment) Time(i int) float64 {
+	return s.Start + float64(i)/s.SRate
+}
+
+// WaveSegments calls fn with each contiguous segment of a WAVE track, in
+// stored order. Only one segment is held in memory at a time, and fn may
+// keep it.
+func (t *Track) WaveSegments(fn func(WaveSegment) error) error {
+	if t.Type != 1 || t.SRate <= 0 {
+		return fmt.Errorf("track %q: not a WAVE track", t.Name)
+	}
+	var (
+		sb  = newSegmenter(t, SegmentOptions{})
+		seg WaveSegment
+	)
+	flush := func() error {
+		if len(seg.Values) == 0 {
+			return nil
+		}
+		err := fn(seg)
+		seg = WaveSegment{}
+		return err
+	}
+	err := t.ForEachRec(func(rec Rec) error {
+		vals, ok := samplesFloat64(rec.Val)
+		if !ok || len(vals) == 0 {
+			return nil
+		}
+		n := len(sb.segs)
+		sb.add(rec.Dt, t.recEnd(&rec))
+		if len(sb.segs) != n {
+			if err := flush(); err != nil {
+				return err
+			}
+			seg.Start, seg.SRate = rec.Dt, float64(t.SRate)
+		}
+		seg.Chunks = append(seg.Chunks, len(seg.Values))
+		for _, v := range vals {
+			seg.Values = append(seg.Values, t.Calibrate(v))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+// FilterWave returns a copy of a WAVE track with each contiguous segment
+// replaced by fn(values, srate), for example a dsp.Chain's Apply. fn gets
+// calibrated values and must return as many as it was given. The copy
+// keeps the chunking of t, timed from each segment's start as in Resample,
+// holds its records in memory and stores float64 values (fmt 2) with gain 1
+// and offset 0.
+func (t *Track) FilterWave(fn func(x []float64, srate float64) ([]float64, error)) (*Track, error) {
+	out := t.copyMeta()
+	out.Fmt, out.Gain, out.Offset = 2, 1, 0
+	err := t.WaveSegments(func(seg WaveSegment) error {
+		ys, err := fn(seg.Values, seg.SRate)
+		if err != nil {
+			return err
+		}
+		if len(ys) != len(seg.Values) {
+			return fmt.Errorf("filter returned %d samples for %d", len(ys), len(seg.Values))
+		}
+		for i, from := range seg.Chunks {
+			to := len(ys)
+			if i+1 < len(seg.Chunks) {
+				to = seg.Chunks[i+1]
+			}
+			out.Recs = append(out.Recs, Rec{Dt: seg.Time(from), Val: ys[from:to]})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("track %q: %w", t.Name, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,61 @@
+package vital
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mdsung/vitaldb_processor/vital/dsp"
+)
+
+func TestWaveSegments(t *testing.T) {
+	vf := readVital(t, syntheticRecording(3))
+	ecg := vf.Trks["Bx50/ECG_II"]
+	var segs []WaveSegment
+	if err := ecg.WaveSegments(func(s WaveSegment) error { segs = append(segs, s); return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if len(segs) != 1 || len(segs[0].Values) != 1500 || len(segs[0].Chunks) != 3 || segs[0].Chunks[1] != 500 {
+		t.Fatalf("segments = %d", len(segs))
+	}
+	raw := ecg.Recs[0].Val.([]int16)
+	if v := segs[0].Values[7]; v != float64(raw[7])*ecg.Gain || !near(segs[0].Time(500), syntheticStart+1) {
+		t.Errorf("sample 7 = %g, raw %d", v, raw[7])
+	}
+	if err := vf.Trks["Bx50/HR"].WaveSegments(func(WaveSegment) error { return nil }); err == nil {
+		t.Error("WaveSegments of a NUMERIC track should fail")
+	}
+}
+
+func TestFilterWave(t *testing.T) {
+	// 5 Hz + 200 Hz, 0-4초와 10-12초 두 구간
+	vf := readVital(t, sineRecording(5, 200))
+	chain, err := dsp.ParseChain("lowpass:40")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := vf.Trks["Bx50/ECG_II"].FilterWave(chain.Apply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Fmt != 2 || out.Gain != 1 || len(out.Recs) != 6 || !near(out.Recs[4].Dt, syntheticStart+10) {
+		t.Fatalf("fmt %d gain %g with %d chunks", out.Fmt, out.Gain, len(out.Recs))
+	}
+	var worst float64
+	for _, rec := range out.Recs {
+		for i, v := range rec.Val.([]float64) {
+			tm := rec.Dt - syntheticStart + float64(i)/500
+			if tm < 0.2 || (tm > 3.8 && tm < 10.2) || tm > 11.8 {
+				continue // 구간 가장자리
+			}
+			worst = math.Max(worst, math.Abs(v-math.Sin(2*math.Pi*5*tm)))
+		}
+	}
+	if worst > 0.01 {
+		t.Errorf("max error %g after low-pass", worst)
+	}
+
+	short := func(x []float64, _ float64) ([]float64, error) { return x[1:], nil }
+	if _, err := vf.Trks["Bx50/ECG_II"].FilterWave(short); err == nil {
+		t.Error("a filter that drops samples should fail")
+	}
+}
@@ -0,0 +1,206 @@
+package vital
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"runtime"
+	"slices"
+	"testing"
+
+	vt "github.com/mdsung/vitaldb_processor/vital/vitaltest"
+)
+
+// fuzzAllocLimit bounds the bytes a single fuzz input may allocate relative
+// to its own size; corrupt lengths must not translate into large buffers.
+func fuzzAllocLimit(inputLen int) uint64 {
+	return uint64(64*inputLen) + 4*readBufferSize + 1<<20
+}
+
+// emptyVitalFile returns a VitalFile as readHeader leaves it.
+func emptyVitalFile() *VitalFile {
+	return &VitalFile{
+		Devs:   make(map[string]Device),
+		Trks:   make(map[string]*Track),
+		Order:  []string{},
+		DevIDs: make(map[uint32]string),
+		TrkIDs: make(map[uint16]string),
+	}
+}
+
+// fuzzTracks returns a VitalFile with a WAVE and a NUMERIC track for every
+// fmt code (tids 1-8 and 11-18) and a STRING track (tid 20), so that
+// parseRec inputs reach every decoder.
+func fuzzTracks() *VitalFile {
+	b := vt.New(syntheticStart)
+	for f := vt.FmtFloat32; f <= vt.FmtUint32; f++ {
+		b.Track(vt.Track{ID: uint16(f), Type: vt.TypeWave, Fmt: f, Name: "WAVE" + string('0'+f), Unit: "mV", SRate: 100})
+		b.Track(vt.Track{ID: 10 + uint16(f), Type: vt.TypeNumeric, Fmt: f, Name: "NUM" + string('0'+f), Unit: "mV"})
+	}
+	b.Track(vt.Track{ID: 20, Type: vt.TypeString, Fmt: vt.FmtFloat32, Name: "EVENT", Unit: "-"})
+	vf, err := ReadVitalFile(bytes.NewReader(b.Gzip()))
+	if err != nil {
+		panic(err)
+	}
+	return vf
+}
+
+func FuzzParseDevInfo(f *testing.F) {
+	f.Add(vt.DevInfoPacket(1, "Bx50", "Bx50", "COM1"))
+	f.Add(vt.DevInfoPacket(2, "", "", ""))
+	f.Add([]byte{1, 0, 0, 0, 0xff, 0xff, 0xff, 0xff})
+	f.Fuzz(func(t *testing.T, pkt []byte) {
+		vf := emptyVitalFile()
+		parseDevInfo(pkt, vf)
+		if len(vf.Devs) > 1 || len(vf.DevIDs) > 1 {
+			t.Fatalf("one packet defined %d devices", len(vf.Devs))
+		}
+	})
+}
+
+func FuzzParseTrkInfo(f *testing.F) {
+	f.Add(vt.TrkInfoPacket(vt.Track{ID: 1, Type: vt.TypeWave, Fmt: vt.FmtInt16, Name: "ECG_II", Unit: "mV", SRate: 500, Gain: 0.01, DevID: 1}))
+	f.Add(vt.TrkInfoPacket(vt.Track{ID: 0xffff, Type: vt.TypeString, Fmt: 0, Name: "EVENT"}))
+	f.Fuzz(func(t *testing.T, pkt []byte) {
+		vf := emptyVitalFile()
+		vf.DevIDs[1] = "Bx50"
+		parseTrkInfo(pkt, vf)
+		parseTrkInfo(pkt, vf) // 같은 트랙 재정의
+		for _, name := range vf.Order {
+			if vf.Trks[name] == nil {
+				t.Fatalf("Order names missing track %q", name)
+			}
+		}
+	})
+}
+
+func FuzzParseRec(f *testing.F) {
+	for f8 := vt.FmtFloat32; f8 <= vt.FmtUint32; f8++ {
+		f.Add(vt.RecPacket(uint16(f8), syntheticStart, vt.WavePayload([]uint8{1, 2, 3, 4, 5, 6, 7, 8})))
+		f.Add(vt.RecPacket(10+uint16(f8), syntheticStart, []byte{1, 2, 3, 4, 5, 6, 7, 8}))
+	}
+	f.Add(vt.RecPacket(20, syntheticStart, vt.StringPayload("Intubation")))
+	f.Add(vt.RecPacket(1, syntheticStart, []byte{0xff, 0xff, 0xff, 0xff, 0, 0}))
+	f.Add(vt.RecPacket(20, syntheticStart, []byte{0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff}))
+	f.Fuzz(func(t *testing.T, pkt []byte) {
+		vf := fuzzTracks()
+		parseRec(pkt, vf)
+		for _, trk := range vf.Trks {
+			for _, rec := range trk.Recs {
+				if n := sampleCount(rec.Val); n > len(pkt) {
+					t.Fatalf("track %s decoded %d samples from a %d-byte packet", trk.Name, n, len(pkt))
+				}
+			}
+		}
+	})
+}
+
+func FuzzParseCmd(f *testing.F) {
+	f.Add(vt.CmdOrderPacket(3, 1, 2))
+	f.Add(vt.CmdOrderPacket(0, 0xffff))
+	f.Add([]byte{1, 0, 0, 0, 0xff, 0xff})
+	f.Add([]byte{2, 0, 0, 0})
+	f.Fuzz(func(t *testing.T, pkt []byte) {
+		vf := emptyVitalFile()
+		for _, name := range []string{"A", "B", "C"} {
+			vf.Trks[name] = &Track{Name: name}
+			vf.Order = append(vf.Order, name)
+		}
+		parseCmd(pkt, vf)
+		for _, name := range vf.Order {
+			if vf.Trks[name] == nil {
+				t.Fatalf("order names unknown track %q", name)
+			}
+		}
+		if slices.Equal(vf.Order, []string{"A", "B", "C"}) {
+			return // 순서가 바뀌지 않음
+		}
+		// 바뀐 순서는 패킷의 count와 실제로 담긴 ID 수를 넘을 수 없음
+		if len(pkt) < 6 || binary.LittleEndian.Uint32(pkt) != 1 {
+			t.Fatalf("order changed by a non-TRK_ORDER packet %x: %v", pkt, vf.Order)
+		}
+		count := int(binary.LittleEndian.Uint16(pkt[4:]))
+		if avail := (len(pkt) - 6) / 2; avail < count {
+			count = avail
+		}
+		if len(vf.Order) > count {
+			t.Fatalf("order has %d entries from count %d in a %d-byte packet", len(vf.Order), count, len(pkt))
+		}
+	})
+}
+
+// FuzzReadVitalFile feeds the decompressed stream through the full loader,
+// compressing it first so the fuzzer mutates packets rather than DEFLATE
+// blocks.
+func FuzzReadVitalFile(f *testing.F) {
+	f.Add(syntheticVital(2))
+	f.Add(vt.New(syntheticStart).Bytes())
+
+	trunc := syntheticRecording(1)
+	trunc.Truncated(vt.PacketRec, maxPacketLen, vt.RecPacket(1, syntheticStart, nil))
+	f.Add(trunc.Bytes())
+
+	var huge bytes.Buffer
+	huge.Write(vt.New(syntheticStart).Bytes())
+	huge.WriteByte(vt.PacketRec)
+	binary.Write(&huge, binary.LittleEndian, uint32(maxPacketLen+1))
+	f.Add(huge.Bytes())
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var buf bytes.Buffer
+		zw, _ := gzip.NewWriterLevel(&buf, gzip.NoCompression)
+		zw.Write(data)
+		zw.Close()
+
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		vf, err := ReadVitalFile(&buf)
+		runtime.ReadMemStats(&after)
+
+		if alloc := after.TotalAlloc - before.TotalAlloc; alloc > fuzzAllocLimit(len(data)) {
+			t.Fatalf("allocated %d bytes for a %d-byte stream", alloc, len(data))
+		}
+		if err != nil {
+			return
+		}
+		for _, name := range vf.Order {
+			if vf.Trks[name] == nil {
+				t.Fatalf("Order names missing track %q", name)
+			}
+		}
+	})
+}
+
+// TestCorruptLengthsAllocateLittle pins the allocation bound for the
+// lengths the fuzz targets are seeded with.
+func TestCorruptLengthsAllocateLittle(t *testing.T) {
+	cases := map[string]*vt.Builder{
+		"truncated pktLen": syntheticRecording(1).Truncated(vt.PacketRec, maxPacketLen, vt.RecPacket(1, syntheticStart, nil)),
+		"huge nsamples":    syntheticRecording(1).Raw(vt.PacketRec, vt.RecPacket(1, syntheticStart, []byte{0xff, 0xff, 0xff, 0xff})),
+		"huge strlen":      syntheticRecording(1).Device(2, "X", "X", "").Raw(vt.PacketDevInfo, []byte{3, 0, 0, 0, 0xff, 0xff, 0xff, 0x7f}),
+		"huge order count": syntheticRecording(1).Raw(vt.PacketCmd, []byte{1, 0, 0, 0, 0xff, 0xff, 1, 0}),
+	}
+	for name, b := range cases {
+		data := b.Gzip()
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		if _, err := ReadVitalFile(bytes.NewReader(data)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		runtime.ReadMemStats(&after)
+		if alloc := after.TotalAlloc - before.TotalAlloc; alloc > fuzzAllocLimit(len(b.Bytes())) {
+			t.Errorf("%s: allocated %d bytes", name, alloc)
+		}
+	}
+}
+
+// TestTrackOrderIgnoresInvalidIDs checks that track IDs 0 and past the end
+// are dropped instead of wrapping around.
+func TestTrackOrderIgnoresInvalidIDs(t *testing.T) {
+	vf := emptyVitalFile()
+	vf.Order = []string{"A", "B"}
+	parseCmd(vt.CmdOrderPacket(0, 2, 3, 1), vf)
+	if len(vf.Order) != 2 || vf.Order[0] != "B" || vf.Order[1] != "A" {
+		t.Errorf("got %v, want [B A]", vf.Order)
+	}
+}
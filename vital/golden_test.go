@@ -0,0 +1,309 @@
+package vital
+
+import (
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	vt "github.com/mdsung/vitaldb_processor/vital/vitaltest"
+)
+
+var update = flag.Bool("update", false, "rewrite the synthetic fixtures and their goldens in testdata")
+
+// goldenRecordLimit matches the number of records per track that
+// test/generate_golden_files.py keeps.
+const goldenRecordLimit = 100
+
+// goldenFixtures are the synthetic recordings checked in under
+// testdata/synthetic. They contain no patient data, so CI can run them.
+var goldenFixtures = []struct {
+	name  string
+	build func() *vt.Builder
+}{
+	{"all_formats", allFormatsFixture},
+	{"multi_device", multiDeviceFixture},
+	{"malformed", malformedFixture},
+}
+
+// TestGoldenFixtures compares the parse of each synthetic fixture with its
+// golden. The checked-in goldens are snapshots of the Go parser written by
+// -update, not output of Python VitalDB, so the test catches regressions in
+// the Go reader but not disagreements with the reference reader. Run with
+// -update to regenerate both from the builders after an intentional change.
+func TestGoldenFixtures(t *testing.T) {
+	for _, fx := range goldenFixtures {
+		t.Run(fx.name, func(t *testing.T) {
+			vitalFile := filepath.Join("testdata", "synthetic", fx.name+".vital")
+			goldenFile := filepath.Join("testdata", "golden", "synthetic", fx.name+".json")
+
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(vitalFile), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := fx.build().WriteFile(vitalFile); err != nil {
+					t.Fatal(err)
+				}
+			}
+			vf, err := NewVitalFile(vitalFile)
+			if err != nil {
+				t.Fatalf("Go parser failed: %v", err)
+			}
+			if *update {
+				writeGolden(t, goldenFile, goldenFromVitalFile(vf))
+			}
+			diffGolden(vf, loadGolden(t, goldenFile), defaultTolerance).report(t)
+		})
+	}
+}
+
+// TestDiffGoldenReportsEachRecord checks that the comparison helper lists
+// differences per track and per record rather than stopping at the first.
+func TestDiffGoldenReportsEachRecord(t *testing.T) {
+	vf := readVital(t, syntheticRecording(5))
+	golden := goldenFromVitalFile(vf)
+
+	ecg := golden.Tracks["Bx50/ECG_II"]
+	ecg.Records[1].Val.([]float64)[3] += 1
+	ecg.Records[4].Dt += 0.5
+	golden.Tracks["Bx50/ECG_II"] = ecg
+	hr := golden.Tracks["Bx50/HR"]
+	hr.Records[2].Val = hr.Records[2].Val.(float64) + 1e-9 // 허용 오차 이내
+	hr.Unit = "bpm"
+	golden.Tracks["Bx50/HR"] = hr
+
+	d := diffGolden(vf, golden, defaultTolerance)
+	if len(d.file) != 0 {
+		t.Errorf("unexpected file differences: %v", d.file)
+	}
+	if got := d.tracks["Bx50/ECG_II"]; len(got) != 2 {
+		t.Errorf("ECG_II: got %d differences, want 2: %v", len(got), got)
+	}
+	if got := d.tracks["Bx50/HR"]; len(got) != 1 {
+		t.Errorf("HR: got %d differences, want 1 (unit only): %v", len(got), got)
+	}
+	if d := diffGolden(vf, goldenFromVitalFile(vf), defaultTolerance); !d.empty() {
+		t.Errorf("a file differs from its own golden: %+v", d)
+	}
+}
+
+// goldenFromVitalFile renders a parsed file in the layout written by
+// test/generate_golden_files.py.
+func goldenFromVitalFile(vf *VitalFile) GoldenData {
+	g := GoldenData{
+		FileInfo: GoldenFileInfo{
+			DtStart:   vf.DtStart,
+			DtEnd:     vf.DtEnd,
+			GMTOffset: vf.Dgmt,
+			Duration:  vf.DtEnd - vf.DtStart,
+		},
+		Devices: make(map[string]GoldenDevice),
+		Tracks:  make(map[string]GoldenTrack),
+	}
+	for name, dev := range vf.Devs {
+		g.Devices[name] = GoldenDevice{Name: dev.Name, TypeName: dev.TypeName, Port: dev.Port}
+	}
+	for name, trk := range vf.Trks {
+		gt := GoldenTrack{
+			Name: trk.Name, Type: trk.Type, Fmt: trk.Fmt, Unit: trk.Unit,
+			SampleRate: trk.SRate, Gain: trk.Gain, Offset: trk.Offset,
+			MinDisplay: trk.Mindisp, MaxDisplay: trk.Maxdisp, Color: trk.Col,
+			MonitorType: trk.Montype, DeviceName: trk.DName,
+			RecordsCount: trk.NumRecs(), Records: []GoldenRecord{},
+		}
+		for i := 0; i < trk.NumRecs() && i < goldenRecordLimit; i++ {
+			rec, _ := trk.Rec(i)
+			gt.Records = append(gt.Records, GoldenRecord{Dt: rec.Dt, Val: goldenValue(rec.Val)})
+		}
+		g.Tracks[name] = gt
+	}
+	return g
+}
+
+// goldenValue converts a record value to what Python's json.dump writes:
+// numbers as float64 and WAVE chunks as number arrays.
+func goldenValue(val any) any {
+	if s, ok := val.(string); ok {
+		return s
+	}
+	if v, ok := toFloat64(val); ok {
+		return v
+	}
+	v, _ := samplesAsFloat64(val)
+	return v
+}
+
+func writeGolden(t *testing.T, path string, g GoldenData) {
+	t.Helper()
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// allFormatsFixture has a WAVE and a NUMERIC track for every fmt code and a
+// STRING track.
+func allFormatsFixture() *vt.Builder {
+	b := vt.New(syntheticStart)
+	b.Dgmt = -540
+	b.Device(1, "SynthMon", "Monitor", "COM1")
+	names := []string{"", "F32", "F64", "I8", "U8", "I16", "U16", "I32", "U32"}
+	for f := vt.FmtFloat32; f <= vt.FmtUint32; f++ {
+		b.Track(vt.Track{ID: uint16(f), Type: vt.TypeWave, Fmt: f, Name: "WAVE_" + names[f],
+			Unit: "mV", Mindisp: -10, Maxdisp: 10, Col: 0xff00ff00, SRate: 100, Gain: 0.5, Offset: 1, DevID: 1})
+		b.Track(vt.Track{ID: 10 + uint16(f), Type: vt.TypeNumeric, Fmt: f, Name: "NUM_" + names[f],
+			Unit: "/min", Maxdisp: 200, Gain: 1, DevID: 1})
+	}
+	b.Track(vt.Track{ID: 20, Type: vt.TypeString, Fmt: vt.FmtFloat32, Name: "EVENT", Unit: "", DevID: 1})
+
+	for i := 0; i < 3; i++ {
+		dt := syntheticStart + float64(i)
+		for f := vt.FmtFloat32; f <= vt.FmtUint32; f++ {
+			b.Wave(uint16(f), dt, fixtureSamples(f, 100, i))
+			b.Numeric(10+uint16(f), dt, fixtureValue(f, i+7))
+		}
+	}
+	b.String(20, syntheticStart+1.5, "Intubation")
+	b.String(20, syntheticStart+2.5, "한글 이벤트")
+	return b
+}
+
+// multiDeviceFixture has two devices with same-named tracks, waves at
+// different rates and a TRK_ORDER command.
+func multiDeviceFixture() *vt.Builder {
+	b := vt.New(syntheticStart)
+	b.Device(1, "Bx50", "Bx50", "COM1")
+	b.Device(2, "Intellivue", "Intellivue", "COM2")
+	b.Track(vt.Track{ID: 1, Type: vt.TypeWave, Fmt: vt.FmtInt16, Name: "ECG_II", Unit: "mV", SRate: 500, Gain: 0.01, DevID: 1})
+	b.Track(vt.Track{ID: 2, Type: vt.TypeNumeric, Fmt: vt.FmtFloat32, Name: "HR", Unit: "/min", DevID: 1})
+	b.Track(vt.Track{ID: 3, Type: vt.TypeWave, Fmt: vt.FmtFloat32, Name: "ART", Unit: "mmHg", SRate: 125, DevID: 2})
+	b.Track(vt.Track{ID: 4, Type: vt.TypeNumeric, Fmt: vt.FmtFloat32, Name: "HR", Unit: "/min", DevID: 2})
+	b.TrackOrder(3, 4, 1, 2)
+
+	for s := 0; s < 4; s++ {
+		dt := syntheticStart + float64(s)
+		ecg := make([]int16, 500)
+		for i := range ecg {
+			ecg[i] = int16(1000 * math.Sin(2*math.Pi*float64(s*500+i)/500))
+		}
+		art := make([]float32, 125)
+		for i := range art {
+			art[i] = float32(80 + 40*math.Sin(2*math.Pi*float64(s*125+i)/125))
+		}
+		b.Wave(1, dt, ecg)
+		b.Wave(3, dt+0.01, art)
+		b.Numeric(2, dt, float32(60+s))
+		b.Numeric(4, dt+0.5, float32(61+s))
+	}
+	return b
+}
+
+// malformedFixture interleaves damaged packets with valid ones and ends in
+// a truncated packet.
+func malformedFixture() *vt.Builder {
+	b := syntheticRecording(2)
+	b.Raw(vt.PacketDevInfo, []byte{1, 2})
+	b.Raw(vt.PacketTrkInfo, make([]byte, 10))
+	b.Raw(42, []byte("unknown packet type"))
+	b.Wave(9, syntheticStart+2, []int16{1, 2})
+	b.Raw(vt.PacketRec, vt.RecPacket(1, syntheticStart+2, []byte{255, 255, 255, 255, 1, 0}))
+	b.Numeric(2, syntheticStart+2, float32(75))
+	b.Truncated(vt.PacketRec, 1000, vt.RecPacket(1, syntheticStart+3, nil))
+	return b
+}
+
+// fixtureSamples returns n samples of the Go type of a fmt code, spanning
+// negative values for signed types and the type's extremes.
+func fixtureSamples(fmtcode uint8, n, seed int) any {
+	vals := make([]float64, n)
+	for i := range vals {
+		vals[i] = float64((seed*31+i*7)%50) - 20 // 정수와 2진 소수만 사용해 float32 반올림 없이 표현
+	}
+	switch fmtcode {
+	case vt.FmtFloat32:
+		s := make([]float32, n)
+		for i, v := range vals {
+			s[i] = float32(v / 4)
+		}
+		return s
+	case vt.FmtFloat64:
+		s := make([]float64, n)
+		for i, v := range vals {
+			s[i] = v / 8
+		}
+		return s
+	case vt.FmtInt8:
+		s := make([]int8, n)
+		for i, v := range vals {
+			s[i] = int8(v)
+		}
+		s[0], s[n-1] = math.MinInt8, math.MaxInt8
+		return s
+	case vt.FmtUint8:
+		s := make([]uint8, n)
+		for i, v := range vals {
+			s[i] = uint8(v + 20)
+		}
+		s[n-1] = math.MaxUint8
+		return s
+	case vt.FmtInt16:
+		s := make([]int16, n)
+		for i, v := range vals {
+			s[i] = int16(v * 100)
+		}
+		s[0], s[n-1] = math.MinInt16, math.MaxInt16
+		return s
+	case vt.FmtUint16:
+		s := make([]uint16, n)
+		for i, v := range vals {
+			s[i] = uint16((v + 20) * 100)
+		}
+		s[n-1] = math.MaxUint16
+		return s
+	case vt.FmtInt32:
+		s := make([]int32, n)
+		for i, v := range vals {
+			s[i] = int32(v * 10000)
+		}
+		s[0], s[n-1] = math.MinInt32, math.MaxInt32
+		return s
+	default:
+		s := make([]uint32, n)
+		for i, v := range vals {
+			s[i] = uint32((v + 20) * 10000)
+		}
+		s[n-1] = math.MaxUint32
+		return s
+	}
+}
+
+// fixtureValue returns a single NUMERIC value of the Go type of a fmt code.
+func fixtureValue(fmtcode uint8, seed int) any {
+	v := float64(seed*3 + 1)
+	switch fmtcode {
+	case vt.FmtFloat32:
+		return float32(v + 0.5)
+	case vt.FmtFloat64:
+		return v + 0.25
+	case vt.FmtInt8:
+		return int8(-v)
+	case vt.FmtUint8:
+		return uint8(v)
+	case vt.FmtInt16:
+		return int16(-v * 100)
+	case vt.FmtUint16:
+		return uint16(v * 100)
+	case vt.FmtInt32:
+		return int32(-v * 100000)
+	default:
+		return uint32(v * 100000)
+	}
+}
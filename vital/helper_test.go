@@ -1,5 +1,14 @@
 package vital
 
+import (
+	"bytes"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/mdsung/vitaldb_processor/vital/vitaltest"
+)
+
 // min returns the minimum of two integers.
 // This helper function is used across multiple test files.
 func min(a, b int) int {
@@ -8,3 +17,62 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+// syntheticStart is the header start time of syntheticRecording.
+const syntheticStart = 1721811600.0
+
+// syntheticRecording returns a builder for a recording with one device, an
+// int16 500 Hz WAVE track and a float32 1 Hz NUMERIC track spanning the
+// given number of seconds.
+func syntheticRecording(seconds int) *vitaltest.Builder {
+	b := vitaltest.New(syntheticStart)
+	b.Device(1, "Bx50", "Bx50", "COM1")
+	b.Track(vitaltest.Track{ID: 1, Type: vitaltest.TypeWave, Fmt: vitaltest.FmtInt16,
+		Name: "ECG_II", Unit: "mV", Maxdisp: 100, SRate: 500, Gain: 0.01, DevID: 1})
+	b.Track(vitaltest.Track{ID: 2, Type: vitaltest.TypeNumeric, Fmt: vitaltest.FmtFloat32,
+		Name: "HR", Unit: "/min", Maxdisp: 100, Gain: 1, DevID: 1})
+
+	samples := make([]int16, 500)
+	for i := range samples {
+		samples[i] = int16(1000 * math.Sin(2*math.Pi*float64(i)/500))
+	}
+	for s := 0; s < seconds; s++ {
+		dt := syntheticStart + float64(s)
+		b.Wave(1, dt, samples)
+		b.Numeric(2, dt, float32(60+s%20))
+	}
+	return b
+}
+
+// syntheticVital returns the decompressed stream of syntheticRecording.
+func syntheticVital(seconds int) []byte {
+	return syntheticRecording(seconds).Bytes()
+}
+
+// writeSyntheticVital writes syntheticRecording into a temporary .vital
+// file and returns its path.
+func writeSyntheticVital(tb testing.TB, seconds int) string {
+	tb.Helper()
+	return writeVital(tb, syntheticRecording(seconds))
+}
+
+// writeVital writes a builder's stream into a temporary .vital file and
+// returns its path.
+func writeVital(tb testing.TB, b *vitaltest.Builder) string {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), "synthetic.vital")
+	if err := b.WriteFile(path); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
+
+// readVital parses a builder's stream without touching the file system.
+func readVital(tb testing.TB, b *vitaltest.Builder) *VitalFile {
+	tb.Helper()
+	vf, err := ReadVitalFile(bytes.NewReader(b.Gzip()))
+	if err != nil {
+		tb.Fatalf("ReadVitalFile: %v", err)
+	}
+	return vf
+}
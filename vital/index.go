@@ -0,0 +1,404 @@
+package vital
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/mdsung/vitaldb_processor/vital/internal/inflate"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// indexVersion is bumped whenever the sidecar layout changes; sidecars with
+// another version are rebuilt.
+const indexVersion = 1
+
+// DefaultIndexSpacing is the default minimum distance, in uncompressed
+// bytes, between two index checkpoints. Each checkpoint stores a 32KB
+// decompressor window, so the sidecar is roughly 1% of the inflated size.
+const DefaultIndexSpacing = 4 << 20
+
+// IndexOptions controls how BuildIndex places checkpoints.
+type IndexOptions struct {
+	Spacing int64 // 체크포인트 간 최소 간격 (0 = DefaultIndexSpacing)
+}
+
+// Index describes checkpoints inside the gzip stream of a .vital file from
+// which decoding can start without inflating the data before them.
+type Index struct {
+	Version int   `msgpack:"version"`
+	Size    int64 `msgpack:"size"`  // 인덱스 생성 시점의 파일 크기
+	MTime   int64 `msgpack:"mtime"` // 인덱스 생성 시점의 수정 시각 (UnixNano)
+
+	DtStart float64 `msgpack:"dt_start"` // 헤더의 시작 시간
+	DtEnd   float64 `msgpack:"dt_end"`   // 헤더의 종료 시간
+	Dgmt    int16   `msgpack:"dgmt"`
+
+	// Meta holds the DEVINFO, TRKINFO and CMD packets of the file in order
+	// so that track definitions can be restored without reading its start.
+	Meta        []IndexPacket     `msgpack:"meta"`
+	Checkpoints []IndexCheckpoint `msgpack:"checkpoints"`
+}
+
+// IndexPacket is a raw metadata packet.
+type IndexPacket struct {
+	Type uint8  `msgpack:"type"`
+	Data []byte `msgpack:"data"`
+}
+
+// IndexCheckpoint is a DEFLATE block boundary followed by the packets up to
+// the next checkpoint.
+type IndexCheckpoint struct {
+	In       int64  `msgpack:"in"`        // 압축 스트림 바이트 오프셋
+	Bits     uint8  `msgpack:"bits"`      // 해당 바이트에서 이미 소비한 비트 수
+	BlockOut int64  `msgpack:"block_out"` // 블록 경계의 비압축 오프셋
+	Out      int64  `msgpack:"out"`       // 경계 이후 첫 패킷의 비압축 오프셋
+	Window   []byte `msgpack:"window"`    // 경계 직전 32KB (inflate 사전)
+
+	// Recs is the number of REC packets between this checkpoint and the
+	// next; DtStart and DtEnd span their records.
+	Recs    int                  `msgpack:"recs"`
+	DtStart float64              `msgpack:"dt_start"`
+	DtEnd   float64              `msgpack:"dt_end"`
+	Tracks  map[string]TimeRange `msgpack:"tracks"`
+}
+
+// TimeRange is a closed interval of Unix timestamps.
+type TimeRange struct {
+	Start float64 `msgpack:"start"`
+	End   float64 `msgpack:"end"`
+}
+
+func (r *TimeRange) extend(start, end float64) {
+	if start < r.Start {
+		r.Start = start
+	}
+	if end > r.End {
+		r.End = end
+	}
+}
+
+// IndexPath returns the sidecar path holding the index of a .vital file.
+func IndexPath(path string) string {
+	return path + ".vidx"
+}
+
+// BuildIndex inflates the .vital file at path once and records checkpoints
+// at DEFLATE block boundaries at least opts.Spacing bytes apart, together
+// with the time range covered by each track after every checkpoint.
+func BuildIndex(path string, opts IndexOptions) (*Index, error) {
+	spacing := opts.Spacing
+	if spacing <= 0 {
+		spacing = DefaultIndexSpacing
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	z, err := inflate.NewReader(bufio.NewReaderSize(f, readBufferSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	idx := &Index{Version: indexVersion, Size: st.Size(), MTime: st.ModTime().UnixNano()}
+	z.OnBlock = func(cp inflate.Checkpoint) {
+		if n := len(idx.Checkpoints); n > 0 && cp.Out-idx.Checkpoints[n-1].BlockOut < spacing {
+			return
+		}
+		idx.Checkpoints = append(idx.Checkpoints, IndexCheckpoint{
+			In:       cp.In,
+			Bits:     cp.Bits,
+			BlockOut: cp.Out,
+			Out:      -1,
+			Window:   bytes.Clone(cp.Window),
+			Tracks:   make(map[string]TimeRange),
+		})
+	}
+
+	d := newDecoder(z)
+	meta, err := d.readHeader()
+	if err != nil {
+		return nil, err
+	}
+	idx.DtStart, idx.DtEnd, idx.Dgmt = meta.DtStart, meta.DtEnd, meta.Dgmt
+
+	next := 0 // 아직 첫 패킷이 정해지지 않은 체크포인트
+	cur := -1 // 현재 패킷이 속한 체크포인트
+	for pktCount := 0; ; pktCount++ {
+		start := d.off
+		pktType, pkt, err := d.next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("packet %d: %w", pktCount, err)
+		}
+		for next < len(idx.Checkpoints) && idx.Checkpoints[next].BlockOut <= start {
+			idx.Checkpoints[next].Out = start
+			cur = next
+			next++
+		}
+
+		switch pktType {
+		case 0, 6, 9:
+			idx.Meta = append(idx.Meta, IndexPacket{Type: pktType, Data: bytes.Clone(pkt)})
+			dispatchPacket(pktType, pkt, meta)
+		case 1:
+			if cur >= 0 {
+				idx.Checkpoints[cur].addRec(pkt, meta)
+			}
+		}
+	}
+
+	// 같은 패킷을 가리키는 체크포인트는 마지막 것만, 패킷이 없는 것은 제거
+	kept := idx.Checkpoints[:0]
+	for i, cp := range idx.Checkpoints {
+		if cp.Out < 0 || (i+1 < len(idx.Checkpoints) && idx.Checkpoints[i+1].Out == cp.Out) {
+			continue
+		}
+		kept = append(kept, cp)
+	}
+	idx.Checkpoints = kept
+	return idx, nil
+}
+
+// addRec accounts for a REC packet in the checkpoint's time ranges.
+func (cp *IndexCheckpoint) addRec(pkt []byte, meta *VitalFile) {
+	if len(pkt) < 12 || int(binary.LittleEndian.Uint16(pkt[0:2])) > len(pkt) {
+		return
+	}
+	dt := bytesToFloat64(pkt[2:10])
+	trk := meta.trackByID(binary.LittleEndian.Uint16(pkt[10:12]))
+	if trk == nil {
+		return
+	}
+	end := dt
+	if trk.Type == 1 && trk.SRate > 0 && len(pkt) >= 16 {
+		end = dt + float64(binary.LittleEndian.Uint32(pkt[12:16]))/float64(trk.SRate)
+	}
+
+	if cp.Recs == 0 || dt < cp.DtStart {
+		cp.DtStart = dt
+	}
+	if cp.Recs == 0 || end > cp.DtEnd {
+		cp.DtEnd = end
+	}
+	cp.Recs++
+	r, ok := cp.Tracks[trk.Name]
+	if !ok {
+		r = TimeRange{Start: dt, End: end}
+	}
+	r.extend(dt, end)
+	cp.Tracks[trk.Name] = r
+}
+
+// LoadIndex reads an index sidecar written by Save.
+func LoadIndex(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index: %w", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	defer gz.Close()
+
+	idx := &Index{}
+	if err := msgpack.NewDecoder(gz).Decode(idx); err != nil {
+		return nil, fmt.Errorf("failed to decode index: %w", err)
+	}
+	if idx.Version != indexVersion {
+		return nil, fmt.Errorf("unsupported index version %d", idx.Version)
+	}
+	return idx, nil
+}
+
+// Save writes the index to path, replacing any existing sidecar atomically.
+func (idx *Index) Save(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".vidx-*")
+	if err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	if err := msgpack.NewEncoder(gz).Encode(idx); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to encode index: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// matches reports whether the index was built from the file described by st.
+func (idx *Index) matches(st os.FileInfo) bool {
+	return idx.Size == st.Size() && idx.MTime == st.ModTime().UnixNano()
+}
+
+// newVitalFile returns a VitalFile holding the header fields and track
+// definitions recorded in the index, without any records.
+func (idx *Index) newVitalFile() *VitalFile {
+	vf := &VitalFile{
+		Devs:    make(map[string]Device),
+		Trks:    make(map[string]*Track),
+		DtStart: idx.DtStart,
+		DtEnd:   idx.DtEnd,
+		Dgmt:    idx.Dgmt,
+		Order:   []string{},
+		DevIDs:  make(map[uint32]string),
+		TrkIDs:  make(map[uint16]string),
+	}
+	for _, p := range idx.Meta {
+		dispatchPacket(p.Type, p.Data, vf)
+	}
+	return vf
+}
+
+// IndexedReader reads time windows of a .vital file through its index.
+type IndexedReader struct {
+	f   *os.File
+	idx *Index
+}
+
+// OpenIndexed opens the .vital file at path for time-based access. The
+// sidecar index at IndexPath(path) is used if it matches the file;
+// otherwise the index is rebuilt and saved. A sidecar that cannot be
+// written (e.g. a read-only directory) is not an error; the freshly built
+// index is used from memory.
+func OpenIndexed(path string) (*IndexedReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	idx, err := LoadIndex(IndexPath(path))
+	if err != nil || !idx.matches(st) {
+		idx, err = BuildIndex(path, IndexOptions{})
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		_ = idx.Save(IndexPath(path))
+	}
+	return &IndexedReader{f: f, idx: idx}, nil
+}
+
+// NewIndexedReader returns a reader over the .vital file at path using an
+// index the caller already holds.
+func NewIndexedReader(path string, idx *Index) (*IndexedReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return &IndexedReader{f: f, idx: idx}, nil
+}
+
+// Index returns the index used by the reader.
+func (r *IndexedReader) Index() *Index {
+	return r.idx
+}
+
+// Close closes the underlying file.
+func (r *IndexedReader) Close() error {
+	return r.f.Close()
+}
+
+// ReadRange returns a VitalFile holding every track definition and the
+// records that overlap [dtFrom, dtTo]. Only the checkpoints covering the
+// window are inflated. WAVE chunks are kept whole; DtStart and DtEnd span
+// the returned records.
+func (r *IndexedReader) ReadRange(dtFrom, dtTo float64) (*VitalFile, error) {
+	if dtTo < dtFrom {
+		return nil, errors.New("invalid time range: end before start")
+	}
+	cps := r.idx.Checkpoints
+	first, last := -1, -1
+	for i := range cps {
+		if cps[i].Recs == 0 {
+			continue
+		}
+		if first < 0 && cps[i].DtEnd >= dtFrom {
+			first = i
+		}
+		if cps[i].DtStart <= dtTo {
+			last = i
+		}
+	}
+
+	vf := r.idx.newVitalFile()
+	if first < 0 || last < first {
+		vf.recomputeTimeRange()
+		return vf, nil
+	}
+
+	cp := cps[first]
+	if _, err := r.f.Seek(cp.In, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek: %w", err)
+	}
+	z, err := inflate.Resume(bufio.NewReaderSize(r.f, readBufferSize), inflate.Checkpoint{
+		In: cp.In, Bits: cp.Bits, Out: cp.BlockOut, Window: cp.Window,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resume at checkpoint %d: %w", first, err)
+	}
+	if _, err := io.CopyN(io.Discard, z, cp.Out-cp.BlockOut); err != nil {
+		return nil, fmt.Errorf("failed to resume at checkpoint %d: %w", first, err)
+	}
+
+	stop := int64(-1)
+	if last+1 < len(cps) {
+		stop = cps[last+1].Out
+	}
+	d := newDecoder(z)
+	d.off = cp.Out
+	for stop < 0 || d.off < stop {
+		pktType, pkt, err := d.next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("offset %d: %w", d.off, err)
+		}
+		// 트랙 정의는 인덱스에서 복원했으므로 REC 패킷만 파싱
+		if pktType == 1 {
+			parseRec(pkt, vf)
+		}
+	}
+
+	for _, trk := range vf.Trks {
+		kept := trk.Recs[:0]
+		for i := range trk.Recs {
+			if trk.recEnd(&trk.Recs[i]) >= dtFrom && trk.Recs[i].Dt <= dtTo {
+				kept = append(kept, trk.Recs[i])
+			}
+		}
+		trk.Recs = kept
+	}
+	vf.recomputeTimeRange()
+	return vf, nil
+}
@@ -0,0 +1,86 @@
+package vital
+
+import (
+	"os"
+	"testing"
+)
+
+func TestIndexedReadRange(t *testing.T) {
+	path := writeSyntheticVital(t, 1800)
+	full, err := NewVitalFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := BuildIndex(path, IndexOptions{Spacing: 64 << 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Checkpoints) < 3 {
+		t.Fatalf("expected several checkpoints, got %d", len(idx.Checkpoints))
+	}
+	if err := idx.Save(IndexPath(path)); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenIndexed(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if got := len(r.Index().Checkpoints); got != len(idx.Checkpoints) {
+		t.Fatalf("OpenIndexed did not reuse the sidecar: %d checkpoints, want %d", got, len(idx.Checkpoints))
+	}
+
+	from, to := full.DtStart+600, full.DtStart+660.5
+	part, err := r.ReadRange(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(part.Trks) != len(full.Trks) {
+		t.Fatalf("track count: got %d, want %d", len(part.Trks), len(full.Trks))
+	}
+	for name, trk := range full.Trks {
+		var want []Rec
+		for i := range trk.Recs {
+			if trk.recEnd(&trk.Recs[i]) >= from && trk.Recs[i].Dt <= to {
+				want = append(want, trk.Recs[i])
+			}
+		}
+		got := part.Trks[name].Recs
+		if len(got) != len(want) {
+			t.Fatalf("track %s: got %d records, want %d", name, len(got), len(want))
+		}
+		for i := range want {
+			if got[i].Dt != want[i].Dt || sampleCount(got[i].Val) != sampleCount(want[i].Val) {
+				t.Fatalf("track %s record %d: got dt=%f, want dt=%f", name, i, got[i].Dt, want[i].Dt)
+			}
+		}
+	}
+	if part.DtStart < from-1 || part.DtEnd > to+1 {
+		t.Errorf("time range [%f, %f] outside requested window", part.DtStart, part.DtEnd)
+	}
+}
+
+func TestOpenIndexedRebuildsStaleSidecar(t *testing.T) {
+	path := writeSyntheticVital(t, 60)
+	if err := os.WriteFile(IndexPath(path), []byte("not an index"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := OpenIndexed(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	vf, err := r.ReadRange(0, 1e12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(vf.Trks["Bx50/ECG_II"].Recs); n != 60 {
+		t.Errorf("got %d ECG records, want 60", n)
+	}
+	if _, err := LoadIndex(IndexPath(path)); err != nil {
+		t.Errorf("sidecar was not rewritten: %v", err)
+	}
+}
@@ -0,0 +1,616 @@
+// Package inflate implements a gzip/DEFLATE decompressor that reports the
+// boundaries between DEFLATE blocks and can resume decompression from them.
+//
+// The standard library's compress/flate does not expose its internal state,
+// which makes random access into a gzip stream impossible without inflating
+// everything before the target. At every block boundary this Reader can
+// describe the compressed bit position, the uncompressed offset and the
+// preceding 32KB of output; Resume restarts decompression from such a
+// checkpoint (the same technique as zlib's examples/zran.c).
+package inflate
+
+import (
+	"bufio"
+	"errors"
+	"hash/crc32"
+	"io"
+)
+
+// WindowSize is the maximum DEFLATE back-reference distance and therefore
+// the amount of history a checkpoint has to carry.
+const WindowSize = 1 << 15
+
+const (
+	maxBits  = 15
+	fastBits = 9
+)
+
+var (
+	// ErrCorrupt is returned for malformed gzip framing or DEFLATE data.
+	ErrCorrupt = errors.New("inflate: corrupt input")
+	// ErrChecksum is returned when a gzip member trailer does not match the
+	// decompressed data.
+	ErrChecksum = errors.New("inflate: checksum mismatch")
+)
+
+var (
+	lenBase   = [29]uint16{3, 4, 5, 6, 7, 8, 9, 10, 11, 13, 15, 17, 19, 23, 27, 31, 35, 43, 51, 59, 67, 83, 99, 115, 131, 163, 195, 227, 258}
+	lenExtra  = [29]uint8{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 0}
+	distBase  = [30]uint16{1, 2, 3, 4, 5, 7, 9, 13, 17, 25, 33, 49, 65, 97, 129, 193, 257, 385, 513, 769, 1025, 1537, 2049, 3073, 4097, 6145, 8193, 12289, 16385, 24577}
+	distExtra = [30]uint8{0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 6, 7, 7, 8, 8, 9, 9, 10, 10, 11, 11, 12, 12, 13, 13}
+	clOrder   = [19]uint8{16, 17, 18, 0, 8, 7, 9, 6, 10, 5, 11, 4, 12, 3, 13, 2, 14, 1, 15}
+)
+
+var fixedLit, fixedDist huffman
+
+func init() {
+	var lengths [288]uint8
+	for i := range lengths {
+		switch {
+		case i < 144:
+			lengths[i] = 8
+		case i < 256:
+			lengths[i] = 9
+		case i < 280:
+			lengths[i] = 7
+		default:
+			lengths[i] = 8
+		}
+	}
+	if err := fixedLit.init(lengths[:]); err != nil {
+		panic(err)
+	}
+	for i := 0; i < 30; i++ {
+		lengths[i] = 5
+	}
+	if err := fixedDist.init(lengths[:30]); err != nil {
+		panic(err)
+	}
+}
+
+// Checkpoint is a DEFLATE block boundary from which decompression can be
+// resumed with Resume.
+type Checkpoint struct {
+	In     int64  // offset of the compressed byte holding the next unread bit
+	Bits   uint8  // number of low bits of that byte already consumed
+	Out    int64  // uncompressed offset of the boundary
+	Window []byte // up to WindowSize bytes of output preceding Out
+}
+
+// Reader decompresses a (possibly multi-member) gzip stream.
+type Reader struct {
+	// OnBlock, if set, is called at every block boundary before the next
+	// block is decoded. The Window slice is only valid during the call.
+	OnBlock func(Checkpoint)
+
+	r    io.ByteReader
+	raw  io.Reader
+	in   int64  // compressed bytes taken from r
+	bits uint64 // bit buffer, least significant bit first
+	nb   uint   // number of valid bits in the buffer
+
+	buf  []byte // history window followed by the output of the last block
+	rpos int    // read position of the consumer in buf
+	out  int64  // uncompressed offset of the end of buf
+
+	final    bool // final block of the current member has been decoded
+	checkCRC bool // the member was read from its start, so the trailer can be verified
+	crc      uint32
+	size     uint32
+	err      error
+
+	lit, dist, codelen huffman
+}
+
+func newReader(r io.Reader) *Reader {
+	br, ok := r.(interface {
+		io.ByteReader
+		io.Reader
+	})
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &Reader{r: br, raw: br}
+}
+
+// NewReader returns a Reader decompressing the gzip stream in r from its
+// beginning.
+func NewReader(r io.Reader) (*Reader, error) {
+	z := newReader(r)
+	if err := z.readHeader(); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return z, nil
+}
+
+// Resume returns a Reader continuing from cp. r must be positioned at the
+// compressed offset cp.In. Trailer checksums of the member containing cp are
+// not verified because the data before cp is not seen.
+func Resume(r io.Reader, cp Checkpoint) (*Reader, error) {
+	if len(cp.Window) > WindowSize || cp.Bits > 7 {
+		return nil, ErrCorrupt
+	}
+	z := newReader(r)
+	z.in = cp.In
+	if cp.Bits > 0 {
+		b, err := z.r.ReadByte()
+		if err != nil {
+			return nil, noEOF(err)
+		}
+		z.in++
+		z.bits = uint64(b >> cp.Bits)
+		z.nb = 8 - uint(cp.Bits)
+	}
+	z.buf = append(make([]byte, 0, 2*WindowSize), cp.Window...)
+	z.rpos = len(z.buf)
+	z.out = cp.Out
+	return z, nil
+}
+
+// Offset returns the uncompressed offset of the next byte returned by Read.
+func (z *Reader) Offset() int64 {
+	return z.out - int64(len(z.buf)-z.rpos)
+}
+
+func (z *Reader) Read(p []byte) (int, error) {
+	for z.rpos == len(z.buf) {
+		if z.err != nil {
+			return 0, z.err
+		}
+		z.err = z.step()
+	}
+	n := copy(p, z.buf[z.rpos:])
+	z.rpos += n
+	return n, nil
+}
+
+// step decodes the next block, moving on to the next gzip member when the
+// current one is finished.
+func (z *Reader) step() error {
+	if z.final {
+		if err := z.finishMember(); err != nil {
+			return err
+		}
+	}
+	z.slide()
+	if z.OnBlock != nil {
+		pos := z.in*8 - int64(z.nb)
+		z.OnBlock(Checkpoint{In: pos / 8, Bits: uint8(pos % 8), Out: z.out, Window: z.buf})
+	}
+	start := len(z.buf)
+	if err := z.block(); err != nil {
+		return noEOF(err)
+	}
+	if z.checkCRC {
+		z.crc = crc32.Update(z.crc, crc32.IEEETable, z.buf[start:])
+	}
+	z.size += uint32(len(z.buf) - start)
+	z.out += int64(len(z.buf) - start)
+	return nil
+}
+
+// slide drops output that is both consumed and outside the history window.
+func (z *Reader) slide() {
+	if len(z.buf) <= WindowSize {
+		return
+	}
+	n := copy(z.buf, z.buf[len(z.buf)-WindowSize:])
+	z.buf = z.buf[:n]
+	z.rpos = n
+}
+
+// finishMember checks the trailer of the current member and starts the next
+// one, returning io.EOF if the stream ends cleanly.
+func (z *Reader) finishMember() error {
+	z.bits >>= z.nb % 8
+	z.nb -= z.nb % 8
+	var trailer [8]byte
+	for i := range trailer {
+		b, err := z.readByte()
+		if err != nil {
+			return noEOF(err)
+		}
+		trailer[i] = b
+	}
+	crc := uint32(trailer[0]) | uint32(trailer[1])<<8 | uint32(trailer[2])<<16 | uint32(trailer[3])<<24
+	size := uint32(trailer[4]) | uint32(trailer[5])<<8 | uint32(trailer[6])<<16 | uint32(trailer[7])<<24
+	if z.checkCRC && (crc != z.crc || size != z.size) {
+		return ErrChecksum
+	}
+	if err := z.readHeader(); err != nil {
+		return err
+	}
+	z.buf = z.buf[:0]
+	z.rpos = 0
+	return nil
+}
+
+// readHeader parses a gzip member header. It returns io.EOF if the stream
+// ends before the first byte.
+func (z *Reader) readHeader() error {
+	var hdr [10]byte
+	for i := range hdr {
+		b, err := z.readByte()
+		if err != nil {
+			if i > 0 {
+				err = noEOF(err)
+			}
+			return err
+		}
+		hdr[i] = b
+	}
+	if hdr[0] != 0x1f || hdr[1] != 0x8b || hdr[2] != 8 {
+		return ErrCorrupt
+	}
+	flags := hdr[3]
+	if flags&0x04 != 0 { // FEXTRA
+		lo, err := z.readByte()
+		if err != nil {
+			return noEOF(err)
+		}
+		hi, err := z.readByte()
+		if err != nil {
+			return noEOF(err)
+		}
+		for n := int(lo) | int(hi)<<8; n > 0; n-- {
+			if _, err := z.readByte(); err != nil {
+				return noEOF(err)
+			}
+		}
+	}
+	for _, f := range []byte{0x08, 0x10} { // FNAME, FCOMMENT
+		if flags&f == 0 {
+			continue
+		}
+		for {
+			b, err := z.readByte()
+			if err != nil {
+				return noEOF(err)
+			}
+			if b == 0 {
+				break
+			}
+		}
+	}
+	if flags&0x02 != 0 { // FHCRC
+		for i := 0; i < 2; i++ {
+			if _, err := z.readByte(); err != nil {
+				return noEOF(err)
+			}
+		}
+	}
+	z.final = false
+	z.checkCRC = true
+	z.crc = 0
+	z.size = 0
+	return nil
+}
+
+// readByte returns the next byte-aligned input byte, draining the bit
+// buffer first.
+func (z *Reader) readByte() (byte, error) {
+	if z.nb >= 8 {
+		b := byte(z.bits)
+		z.bits >>= 8
+		z.nb -= 8
+		return b, nil
+	}
+	b, err := z.r.ReadByte()
+	if err == nil {
+		z.in++
+	}
+	return b, err
+}
+
+// need ensures at least n bits are buffered.
+func (z *Reader) need(n uint) error {
+	for z.nb < n {
+		b, err := z.r.ReadByte()
+		if err != nil {
+			return noEOF(err)
+		}
+		z.in++
+		z.bits |= uint64(b) << z.nb
+		z.nb += 8
+	}
+	return nil
+}
+
+func (z *Reader) getBits(n uint) (int, error) {
+	if err := z.need(n); err != nil {
+		return 0, err
+	}
+	v := int(z.bits & (1<<n - 1))
+	z.bits >>= n
+	z.nb -= n
+	return v, nil
+}
+
+// block decodes one DEFLATE block, appending its output to z.buf.
+func (z *Reader) block() error {
+	hdr, err := z.getBits(3)
+	if err != nil {
+		return err
+	}
+	z.final = hdr&1 == 1
+	switch hdr >> 1 {
+	case 0:
+		return z.stored()
+	case 1:
+		return z.codes(&fixedLit, &fixedDist)
+	case 2:
+		if err := z.dynamic(); err != nil {
+			return err
+		}
+		return z.codes(&z.lit, &z.dist)
+	default:
+		return ErrCorrupt
+	}
+}
+
+func (z *Reader) stored() error {
+	z.bits >>= z.nb % 8
+	z.nb -= z.nb % 8
+	var hdr [4]byte
+	for i := range hdr {
+		b, err := z.readByte()
+		if err != nil {
+			return err
+		}
+		hdr[i] = b
+	}
+	n := int(hdr[0]) | int(hdr[1])<<8
+	if n != ^(int(hdr[2])|int(hdr[3])<<8)&0xffff {
+		return ErrCorrupt
+	}
+	for ; n > 0 && z.nb >= 8; n-- {
+		b, _ := z.readByte()
+		z.buf = append(z.buf, b)
+	}
+	start := len(z.buf)
+	z.buf = append(z.buf, make([]byte, n)...)
+	m, err := io.ReadFull(z.raw, z.buf[start:])
+	z.in += int64(m)
+	return err
+}
+
+func (z *Reader) dynamic() error {
+	nlen, err := z.getBits(5)
+	if err != nil {
+		return err
+	}
+	ndist, err := z.getBits(5)
+	if err != nil {
+		return err
+	}
+	ncode, err := z.getBits(4)
+	if err != nil {
+		return err
+	}
+	nlen += 257
+	ndist++
+	ncode += 4
+	if nlen > 286 || ndist > 30 {
+		return ErrCorrupt
+	}
+
+	var cl [19]uint8
+	for i := 0; i < ncode; i++ {
+		v, err := z.getBits(3)
+		if err != nil {
+			return err
+		}
+		cl[clOrder[i]] = uint8(v)
+	}
+	if err := z.codelen.init(cl[:]); err != nil {
+		return err
+	}
+
+	var lengths [286 + 30]uint8
+	for i := 0; i < nlen+ndist; {
+		sym, err := z.decode(&z.codelen)
+		if err != nil {
+			return err
+		}
+		if sym < 16 {
+			lengths[i] = uint8(sym)
+			i++
+			continue
+		}
+		var val uint8
+		var rep int
+		switch sym {
+		case 16:
+			if i == 0 {
+				return ErrCorrupt
+			}
+			val = lengths[i-1]
+			rep, err = z.getBits(2)
+			rep += 3
+		case 17:
+			rep, err = z.getBits(3)
+			rep += 3
+		default:
+			rep, err = z.getBits(7)
+			rep += 11
+		}
+		if err != nil {
+			return err
+		}
+		if i+rep > nlen+ndist {
+			return ErrCorrupt
+		}
+		for ; rep > 0; rep-- {
+			lengths[i] = val
+			i++
+		}
+	}
+	if lengths[256] == 0 {
+		return ErrCorrupt
+	}
+	if err := z.lit.init(lengths[:nlen]); err != nil {
+		return err
+	}
+	return z.dist.init(lengths[nlen : nlen+ndist])
+}
+
+// codes decodes the literal/length and distance symbols of a compressed
+// block up to its end-of-block code.
+func (z *Reader) codes(lit, dist *huffman) error {
+	for {
+		sym, err := z.decode(lit)
+		if err != nil {
+			return err
+		}
+		if sym < 256 {
+			z.buf = append(z.buf, byte(sym))
+			continue
+		}
+		if sym == 256 {
+			return nil
+		}
+		sym -= 257
+		if sym >= len(lenBase) {
+			return ErrCorrupt
+		}
+		extra, err := z.getBits(uint(lenExtra[sym]))
+		if err != nil {
+			return err
+		}
+		length := int(lenBase[sym]) + extra
+
+		dsym, err := z.decode(dist)
+		if err != nil {
+			return err
+		}
+		if dsym >= len(distBase) {
+			return ErrCorrupt
+		}
+		extra, err = z.getBits(uint(distExtra[dsym]))
+		if err != nil {
+			return err
+		}
+		d := int(distBase[dsym]) + extra
+		start := len(z.buf) - d
+		if start < 0 {
+			return ErrCorrupt
+		}
+		if d >= length {
+			z.buf = append(z.buf, z.buf[start:start+length]...)
+			continue
+		}
+		for i := 0; i < length; i++ {
+			z.buf = append(z.buf, z.buf[start+i])
+		}
+	}
+}
+
+// decode reads one symbol of the Huffman code h.
+func (z *Reader) decode(h *huffman) (int, error) {
+	// 가능한 만큼 fastBits를 채우되 스트림 끝에서는 부족해도 진행
+	for z.nb < fastBits {
+		b, err := z.r.ReadByte()
+		if err != nil {
+			break
+		}
+		z.in++
+		z.bits |= uint64(b) << z.nb
+		z.nb += 8
+	}
+	if e := h.fast[z.bits&(1<<fastBits-1)]; e != 0 && uint(e&15) <= z.nb {
+		z.bits >>= e & 15
+		z.nb -= uint(e & 15)
+		return int(e >> 4), nil
+	}
+
+	// Codes longer than fastBits are decoded one bit at a time.
+	code, first, index := 0, 0, 0
+	for l := 1; l <= maxBits; l++ {
+		if err := z.need(1); err != nil {
+			return 0, err
+		}
+		code |= int(z.bits & 1)
+		z.bits >>= 1
+		z.nb--
+		count := int(h.count[l])
+		if code-first < count {
+			return int(h.symbol[index+code-first]), nil
+		}
+		index += count
+		first = (first + count) << 1
+		code <<= 1
+	}
+	return 0, ErrCorrupt
+}
+
+// huffman is a canonical Huffman code with a lookup table for short codes.
+type huffman struct {
+	fast   [1 << fastBits]uint16 // symbol<<4 | length, 0 if the code is longer
+	count  [maxBits + 1]uint16   // number of codes of each length
+	symbol []uint16              // symbols ordered by code
+}
+
+func (h *huffman) init(lengths []uint8) error {
+	h.count = [maxBits + 1]uint16{}
+	for _, l := range lengths {
+		h.count[l]++
+	}
+	h.count[0] = 0
+	left := 1
+	for l := 1; l <= maxBits; l++ {
+		left = left<<1 - int(h.count[l])
+		if left < 0 {
+			return ErrCorrupt
+		}
+	}
+
+	var offs [maxBits + 2]uint16
+	for l := 1; l <= maxBits; l++ {
+		offs[l+1] = offs[l] + h.count[l]
+	}
+	if cap(h.symbol) < len(lengths) {
+		h.symbol = make([]uint16, len(lengths))
+	}
+	h.symbol = h.symbol[:len(lengths)]
+	for sym, l := range lengths {
+		if l != 0 {
+			h.symbol[offs[l]] = uint16(sym)
+			offs[l]++
+		}
+	}
+
+	// DEFLATE은 허프만 코드를 MSB부터 기록하므로 테이블 인덱스는 비트 반전
+	h.fast = [1 << fastBits]uint16{}
+	code, idx := 0, 0
+	for l := 1; l <= fastBits; l++ {
+		for k := 0; k < int(h.count[l]); k++ {
+			entry := h.symbol[idx]<<4 | uint16(l)
+			for j := reverse(code, l); j < 1<<fastBits; j += 1 << l {
+				h.fast[j] = entry
+			}
+			idx++
+			code++
+		}
+		code <<= 1
+	}
+	return nil
+}
+
+func reverse(code, n int) int {
+	r := 0
+	for i := 0; i < n; i++ {
+		r = r<<1 | code&1
+		code >>= 1
+	}
+	return r
+}
+
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
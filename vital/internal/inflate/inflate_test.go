@@ -0,0 +1,151 @@
+package inflate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+// testData returns compressible data mixing repeated phrases, runs and
+// random bytes so that all block types and long matches are exercised.
+func testData(n int, seed int64) []byte {
+	rng := rand.New(rand.NewSource(seed))
+	words := []string{"VITA", "Bx50/ECG_II", "Solar8000/ART", "mmHg", "/min", "\x00\x00\x00\x00"}
+	var b bytes.Buffer
+	for b.Len() < n {
+		switch rng.Intn(4) {
+		case 0:
+			b.WriteString(words[rng.Intn(len(words))])
+		case 1:
+			b.Write(bytes.Repeat([]byte{byte(rng.Intn(256))}, rng.Intn(300)))
+		case 2:
+			chunk := make([]byte, rng.Intn(64))
+			rng.Read(chunk)
+			b.Write(chunk)
+		default:
+			if b.Len() > 1000 {
+				off := rng.Intn(b.Len() - 500)
+				b.Write(b.Bytes()[off : off+rng.Intn(500)])
+			}
+		}
+	}
+	return b.Bytes()[:n]
+}
+
+func gzipBytes(t *testing.T, level int, members ...[]byte) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	for _, m := range members {
+		zw, err := gzip.NewWriterLevel(&out, level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zw.Name = "sample.vital"
+		if _, err := zw.Write(m); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return out.Bytes()
+}
+
+func TestReaderMatchesStdlib(t *testing.T) {
+	for _, level := range []int{gzip.NoCompression, gzip.BestSpeed, gzip.DefaultCompression, gzip.BestCompression, gzip.HuffmanOnly} {
+		data := testData(400_000, int64(level+10))
+		z, err := NewReader(bytes.NewReader(gzipBytes(t, level, data)))
+		if err != nil {
+			t.Fatalf("level %d: %v", level, err)
+		}
+		got, err := io.ReadAll(z)
+		if err != nil {
+			t.Fatalf("level %d: %v", level, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("level %d: output differs (got %d bytes, want %d)", level, len(got), len(data))
+		}
+	}
+}
+
+func TestReaderMultiMember(t *testing.T) {
+	a, b := testData(70_000, 1), testData(50_000, 2)
+	z, err := NewReader(bytes.NewReader(gzipBytes(t, gzip.DefaultCompression, a, b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(z)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, append(append([]byte{}, a...), b...)) {
+		t.Fatal("multi-member output differs")
+	}
+}
+
+func TestResumeFromEveryCheckpoint(t *testing.T) {
+	data := testData(600_000, 3)
+	comp := gzipBytes(t, gzip.DefaultCompression, data[:350_000], data[350_000:])
+
+	var cps []Checkpoint
+	z, err := NewReader(bytes.NewReader(comp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	z.OnBlock = func(cp Checkpoint) {
+		cp.Window = append([]byte(nil), cp.Window...)
+		cps = append(cps, cp)
+	}
+	if _, err := io.Copy(io.Discard, z); err != nil {
+		t.Fatal(err)
+	}
+	if len(cps) < 3 {
+		t.Fatalf("expected several block boundaries, got %d", len(cps))
+	}
+
+	for _, cp := range cps {
+		if cp.Out > 0 && !bytes.Equal(cp.Window, data[cp.Out-int64(len(cp.Window)):cp.Out]) {
+			t.Fatalf("checkpoint at %d carries a wrong window", cp.Out)
+		}
+		r, err := Resume(bytes.NewReader(comp[cp.In:]), cp)
+		if err != nil {
+			t.Fatalf("resume at %d: %v", cp.Out, err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("resume at %d: %v", cp.Out, err)
+		}
+		if !bytes.Equal(got, data[cp.Out:]) {
+			t.Fatalf("resume at %d: output differs", cp.Out)
+		}
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	comp := gzipBytes(t, gzip.DefaultCompression, testData(10_000, 4))
+
+	truncated := comp[:len(comp)/2]
+	z, err := NewReader(bytes.NewReader(truncated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(z); err != io.ErrUnexpectedEOF {
+		t.Errorf("truncated stream: got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+
+	badCRC := append([]byte(nil), comp...)
+	badCRC[len(badCRC)-8] ^= 0xff
+	z, err = NewReader(bytes.NewReader(badCRC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(z); err != ErrChecksum {
+		t.Errorf("corrupt trailer: got %v, want %v", err, ErrChecksum)
+	}
+
+	if _, err := NewReader(bytes.NewReader([]byte("VITA plain"))); err != ErrCorrupt {
+		t.Errorf("non-gzip input: got %v, want %v", err, ErrCorrupt)
+	}
+}
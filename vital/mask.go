@@ -0,0 +1,70 @@
+package vital
+
+import (
+	"fmt"
+	"math"
+)
+
+// Mask returns a copy of t without the samples at times for which flagged
+// returns true, or, unless drop, with them set to NaN. Dropping keeps the
+// track's fmt and splits WAVE records around the removed samples (each
+// remaining run is a record timed at its first sample), so the gaps show
+// in Segments. Setting NaN stores the track as calibrated float64 values
+// (fmt 2, gain 1, offset 0) as ConvertUnit does. STRING records are
+// dropped when flagged and otherwise kept. The copy holds its records in
+// memory.
+func (t *Track) Mask(flagged func(tm float64) bool, drop bool) (*Track, error) {
+	out := t.copyMeta()
+	if !drop && t.Type != 5 {
+		out.Fmt, out.Gain, out.Offset = 2, 1, 0
+	}
+	err := t.ForEachRec(func(rec Rec) error {
+		if v, ok := rec.GetNumericValue(); ok {
+			switch {
+			case !flagged(rec.Dt):
+				if !drop {
+					rec.Val = t.Calibrate(v)
+				}
+				out.Recs = append(out.Recs, rec)
+			case !drop:
+				out.Recs = append(out.Recs, Rec{Dt: rec.Dt, Val: math.NaN()})
+			}
+			return nil
+		}
+		vals, ok := samplesFloat64(rec.Val)
+		if !ok || t.Type != 1 || t.SRate <= 0 {
+			if !flagged(rec.Dt) {
+				out.Recs = append(out.Recs, rec)
+			}
+			return nil
+		}
+
+		if !drop {
+			for i, v := range vals {
+				vals[i] = t.Calibrate(v)
+				if flagged(rec.Dt + float64(i)/float64(t.SRate)) {
+					vals[i] = math.NaN()
+				}
+			}
+			out.Recs = append(out.Recs, Rec{Dt: rec.Dt, Val: vals})
+			return nil
+		}
+		// 표시되지 않은 연속 샘플마다 레코드 하나
+		n, start := len(vals), -1
+		for i := 0; i <= n; i++ {
+			keep := i < n && !flagged(rec.Dt+float64(i)/float64(t.SRate))
+			switch {
+			case keep && start < 0:
+				start = i
+			case !keep && start >= 0:
+				out.Recs = append(out.Recs, Rec{Dt: rec.Dt + float64(start)/float64(t.SRate), Val: sliceSamples(rec.Val, start, i)})
+				start = -1
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("track %q: %w", t.Name, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,51 @@
+package vital
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMask(t *testing.T) {
+	vf := readVital(t, syntheticRecording(4))
+	flagged := func(tm float64) bool { return tm >= syntheticStart+1.5 && tm < syntheticStart+2.5 }
+
+	ecg, err := vf.Trks["Bx50/ECG_II"].Mask(flagged, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ecg.Recs) != 4 || ecg.Fmt != 5 || !near(ecg.Recs[2].Dt, syntheticStart+2.5) || sampleCount(ecg.Recs[1].Val) != 250 {
+		t.Fatalf("dropped: %d records", len(ecg.Recs))
+	}
+	segs, _ := ecg.Segments(SegmentOptions{})
+	if len(segs) != 2 || !near(segs[0].End, syntheticStart+1.5) {
+		t.Errorf("segments after drop: %+v", segs)
+	}
+	hr, _ := vf.Trks["Bx50/HR"].Mask(flagged, true)
+	if len(hr.Recs) != 3 {
+		t.Errorf("HR kept %d records", len(hr.Recs))
+	}
+
+	ecg, err = vf.Trks["Bx50/ECG_II"].Mask(flagged, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nan := 0
+	for _, rec := range ecg.Recs {
+		for _, v := range rec.Val.([]float64) {
+			if math.IsNaN(v) {
+				nan++
+			}
+		}
+	}
+	if len(ecg.Recs) != 4 || ecg.Fmt != 2 || ecg.Gain != 1 || nan != 500 {
+		t.Errorf("NaN: %d records, fmt %d, %d NaN", len(ecg.Recs), ecg.Fmt, nan)
+	}
+	// 보정한 값: 1000 * 0.01
+	if v := ecg.Recs[0].Val.([]float64)[125]; math.Abs(v-10) > 0.01 {
+		t.Errorf("calibrated sample %g", v)
+	}
+	hr, _ = vf.Trks["Bx50/HR"].Mask(flagged, false)
+	if len(hr.Recs) != 4 || !math.IsNaN(hr.Recs[2].Val.(float64)) || hr.Recs[1].Val.(float64) != 61 {
+		t.Errorf("HR records %+v", hr.Recs)
+	}
+}
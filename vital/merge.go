@@ -0,0 +1,232 @@
+package vital
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Merge joins recordings of one bed that Vital Recorder split into several
+// files (e.g. MICUA01_240724_180000.vital, MICUA01_240724_180622.vital) into
+// a single VitalFile.
+//
+// The files are taken in order of DtStart. Devices and tracks are unified
+// by name, keeping the metadata of the first file that defines them; a
+// track whose type, fmt, sample rate, gain or offset differs between files
+// is an error because its raw values could not be combined. Records are
+// concatenated per track. Where a file overlaps the time span of a track's
+// records already taken from earlier files, the earlier file wins: records
+// of that track that end before the span ends are dropped as duplicates,
+// WAVE chunks straddling it are trimmed at sample granularity, and a value
+// exactly at its end is dropped if the track already has one at that time.
+// A track first seen in a later file keeps all its records.
+//
+// The result holds all records in memory, including those of spilled
+// input tracks, and shares sample slices with in-memory inputs. It has no
+// spill files of its own; the inputs may be closed once Merge returns.
+func Merge(files ...*VitalFile) (*VitalFile, error) {
+	if len(files) == 0 {
+		return nil, errors.New("no files to merge")
+	}
+	sorted := append([]*VitalFile(nil), files...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].DtStart < sorted[j].DtStart })
+
+	out := newEmptyFile(sorted[0].Dgmt)
+	covered := make(map[string]float64) // 트랙별로 이전 파일들의 레코드가 끝나는 시간
+
+	for n, vf := range sorted {
+		for name, dev := range vf.Devs {
+			if _, ok := out.Devs[name]; !ok {
+				out.Devs[name] = dev
+			}
+		}
+
+		ends := make(map[string]float64)
+		for _, trk := range vf.writeOrder() {
+			dst, ok := out.Trks[trk.Name]
+			if !ok {
+				dst = trk.copyMeta()
+				out.Trks[trk.Name] = dst
+				out.Order = append(out.Order, trk.Name)
+			} else if err := dst.checkCompatible(trk); err != nil {
+				return nil, fmt.Errorf("file %d: %w", n+1, err)
+			}
+
+			from, ok := covered[trk.Name]
+			if !ok {
+				from = math.Inf(-1)
+			}
+			prev := len(dst.Recs) // 이전 파일들에서 가져온 레코드 수
+			err := trk.ForEachRec(func(rec Rec) error {
+				var keep bool
+				if rec, keep = dst.trimBefore(rec, from); !keep {
+					return nil
+				}
+				// 경계 시각의 순간 레코드는 이전 파일에 같은 시각 레코드가 있을 때만 중복
+				if rec.Dt == from && prev > 0 && dst.Recs[prev-1].Dt == rec.Dt {
+					return nil
+				}
+				dst.Recs = append(dst.Recs, rec)
+				if end, ok := ends[trk.Name]; !ok || dst.recEnd(&rec) > end {
+					ends[trk.Name] = dst.recEnd(&rec)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("file %d: track %q: %w", n+1, trk.Name, err)
+			}
+		}
+		// 같은 파일 안의 중복은 그대로 두고, 다음 파일부터 겹침을 판단
+		for name, end := range ends {
+			if c, ok := covered[name]; !ok || end > c {
+				covered[name] = end
+			}
+		}
+	}
+
+	out.renumber()
+	out.recomputeTimeRange()
+	if len(covered) == 0 {
+		// 레코드가 없으면 헤더의 시간 범위를 합침
+		out.DtStart, out.DtEnd = sorted[0].DtStart, sorted[0].DtEnd
+		for _, vf := range sorted[1:] {
+			out.DtEnd = math.Max(out.DtEnd, vf.DtEnd)
+		}
+	}
+	return out, nil
+}
+
+// newEmptyFile returns a VitalFile without devices, tracks or records.
+func newEmptyFile(dgmt int16) *VitalFile {
+	return &VitalFile{
+		Devs:   make(map[string]Device),
+		Trks:   make(map[string]*Track),
+		Dgmt:   dgmt,
+		Order:  []string{},
+		DevIDs: make(map[uint32]string),
+		TrkIDs: make(map[uint16]string),
+	}
+}
+
+// copyStructure returns a file with the devices, track IDs and order of vf
+// and no tracks. Add the tracks under their original names, then call
+// linkTracks.
+func (vf *VitalFile) copyStructure() *VitalFile {
+	out := newEmptyFile(vf.Dgmt)
+	for name, dev := range vf.Devs {
+		out.Devs[name] = dev
+	}
+	for did, name := range vf.DevIDs {
+		out.DevIDs[did] = name
+	}
+	for tid, name := range vf.TrkIDs {
+		out.TrkIDs[tid] = name
+	}
+	out.Order = append(out.Order, vf.Order...)
+	return out
+}
+
+// linkTracks points the original track IDs at the tracks of vf.
+func (vf *VitalFile) linkTracks() {
+	vf.tids = make(map[uint16]*Track, len(vf.TrkIDs))
+	for tid, name := range vf.TrkIDs {
+		if trk, ok := vf.Trks[name]; ok {
+			vf.tids[tid] = trk
+		}
+	}
+}
+
+// AddTrack adds trk to vf under trk.Name ("Device/Name") with a new track
+// ID, after the existing tracks in Order. It fails if vf already has a
+// track of that name.
+func (vf *VitalFile) AddTrack(trk *Track) error {
+	if _, exists := vf.Trks[trk.Name]; exists {
+		return fmt.Errorf("track %q already exists", trk.Name)
+	}
+	var tid uint16
+	for id := range vf.TrkIDs {
+		if id > tid {
+			tid = id
+		}
+	}
+	if vf.TrkIDs == nil {
+		vf.TrkIDs = make(map[uint16]string)
+	}
+	if vf.tids == nil {
+		vf.tids = make(map[uint16]*Track)
+	}
+	vf.Trks[trk.Name] = trk
+	vf.TrkIDs[tid+1] = trk.Name
+	vf.tids[tid+1] = trk
+	vf.Order = append(vf.Order, trk.Name)
+	return nil
+}
+
+// copyMeta returns a track with the metadata of t and no records.
+func (t *Track) copyMeta() *Track {
+	c := *t
+	c.Recs = []Rec{}
+	c.spill = nil
+	return &c
+}
+
+// checkCompatible reports whether records of other can be stored in t
+// without changing their meaning.
+func (t *Track) checkCompatible(other *Track) error {
+	switch {
+	case t.Type != other.Type:
+		return fmt.Errorf("track %q: type %d differs from %d", t.Name, other.Type, t.Type)
+	case t.Type != 5 && t.Fmt != other.Fmt:
+		return fmt.Errorf("track %q: fmt %d differs from %d", t.Name, other.Fmt, t.Fmt)
+	case t.Type == 1 && t.SRate != other.SRate:
+		return fmt.Errorf("track %q: sample rate %v differs from %v", t.Name, other.SRate, t.SRate)
+	case t.Gain != other.Gain || t.Offset != other.Offset:
+		return fmt.Errorf("track %q: gain/offset %v/%v differ from %v/%v", t.Name, other.Gain, other.Offset, t.Gain, t.Offset)
+	}
+	return nil
+}
+
+// trimBefore drops the part of rec before time from. Instantaneous records
+// before from are dropped; WAVE chunks lose their leading samples.
+func (t *Track) trimBefore(rec Rec, from float64) (Rec, bool) {
+	end := t.recEnd(&rec)
+	if t.Type != 1 || t.SRate <= 0 {
+		return rec, rec.Dt >= from
+	}
+	if end <= from {
+		return rec, false
+	}
+	if rec.Dt >= from {
+		return rec, true
+	}
+	skip := samplesBefore(rec.Dt, from, t.SRate)
+	n := sampleCount(rec.Val)
+	if skip >= n {
+		return rec, false
+	}
+	return Rec{
+		Dt:  rec.Dt + float64(skip)/float64(t.SRate),
+		Val: sliceSamples(rec.Val, skip, n),
+	}, true
+}
+
+// renumber assigns device and track IDs in the order Write uses, so that
+// DevIDs and TrkIDs of a constructed file match the file it writes.
+func (vf *VitalFile) renumber() {
+	names := make([]string, 0, len(vf.Devs))
+	for name := range vf.Devs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	vf.DevIDs = make(map[uint32]string, len(names))
+	for i, name := range names {
+		vf.DevIDs[uint32(i+1)] = name
+	}
+	vf.TrkIDs = make(map[uint16]string, len(vf.Trks))
+	vf.tids = make(map[uint16]*Track, len(vf.Trks))
+	for i, trk := range vf.writeOrder() {
+		vf.TrkIDs[uint16(i+1)] = trk.Name
+		vf.tids[uint16(i+1)] = trk
+	}
+}
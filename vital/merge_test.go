@@ -0,0 +1,146 @@
+package vital
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	vt "github.com/mdsung/vitaldb_processor/vital/vitaltest"
+)
+
+// splitPart returns one file of a split session: a 500 Hz ECG chunk and an
+// HR value every second from start for the given number of seconds.
+func splitPart(t *testing.T, start float64, seconds int) *VitalFile {
+	t.Helper()
+	b := vt.New(start)
+	b.Device(1, "Bx50", "Bx50", "COM1")
+	b.Track(vt.Track{ID: 1, Type: vt.TypeWave, Fmt: vt.FmtInt16, Name: "ECG_II", Unit: "mV", SRate: 500, Gain: 0.01, DevID: 1})
+	b.Track(vt.Track{ID: 2, Type: vt.TypeNumeric, Fmt: vt.FmtFloat32, Name: "HR", Unit: "/min", DevID: 1})
+	for s := 0; s < seconds; s++ {
+		dt := start + float64(s)
+		samples := make([]int16, 500)
+		for i := range samples {
+			samples[i] = int16(s*500 + i) // 세션 안에서 증가하는 값으로 잘린 위치 확인
+		}
+		b.Wave(1, dt, samples)
+		b.Numeric(2, dt, float32(dt-start))
+	}
+	return readVital(t, b)
+}
+
+func TestMergeConsecutiveFiles(t *testing.T) {
+	a := splitPart(t, syntheticStart, 3)
+	b := splitPart(t, syntheticStart+3, 2)
+	c := splitPart(t, syntheticStart+10, 1)
+
+	vf, err := Merge(c, a, b) // 입력 순서와 무관하게 시작 시간 순으로 합침
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vf.DtStart != syntheticStart || vf.DtEnd != syntheticStart+11 {
+		t.Errorf("time range = [%f, %f]", vf.DtStart, vf.DtEnd)
+	}
+	for name, want := range map[string]int{"Bx50/ECG_II": 6, "Bx50/HR": 6} {
+		trk := vf.Trks[name]
+		if trk == nil || trk.NumRecs() != want {
+			t.Fatalf("%s: %v records, want %d", name, trk, want)
+		}
+		for i := 1; i < len(trk.Recs); i++ {
+			if trk.Recs[i].Dt <= trk.Recs[i-1].Dt {
+				t.Errorf("%s: records out of order at %d", name, i)
+			}
+		}
+	}
+	if len(vf.Devs) != 1 || len(vf.Order) != 2 {
+		t.Errorf("devices %v, order %v", vf.Devs, vf.Order)
+	}
+
+	// 합친 결과는 .vital로 다시 쓸 수 있어야 함
+	diffGolden(roundTrip(t, vf), goldenFromVitalFile(vf), goldenTolerance{}).report(t)
+}
+
+func TestMergeResolvesOverlap(t *testing.T) {
+	a := splitPart(t, syntheticStart, 3)
+	b := splitPart(t, syntheticStart+2.5, 2) // a의 마지막 0.5초와 겹침
+
+	vf, err := Merge(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecg := vf.Trks["Bx50/ECG_II"].Recs
+	if len(ecg) != 5 {
+		t.Fatalf("ECG records = %d, want 5", len(ecg))
+	}
+	// b의 첫 청크는 앞 250 샘플이 잘려 a의 끝에서 이어짐
+	trimmed := ecg[3]
+	if math.Abs(trimmed.Dt-(syntheticStart+3)) > 1e-9 {
+		t.Errorf("trimmed chunk starts at %f", trimmed.Dt-syntheticStart)
+	}
+	if got := trimmed.Val.([]int16); len(got) != 250 || got[0] != 250 {
+		t.Errorf("trimmed chunk has %d samples starting with %d", len(got), got[0])
+	}
+
+	// HR은 트랙별로 판단: a의 HR은 2초에서 끝나므로 b의 2.5초, 3.5초 값이 이어짐
+	hr := vf.Trks["Bx50/HR"].Recs
+	if len(hr) != 5 || hr[3].Dt != syntheticStart+2.5 || hr[4].Dt != syntheticStart+3.5 {
+		t.Errorf("HR records = %+v", hr)
+	}
+
+	// 같은 파일을 두 번 합치면 중복이 모두 제거됨
+	dup, err := Merge(a, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := dup.Trks["Bx50/HR"].NumRecs(); n != 3 {
+		t.Errorf("duplicate merge kept %d HR records, want 3", n)
+	}
+}
+
+func TestMergeTrackFirstSeenLater(t *testing.T) {
+	a := splitPart(t, syntheticStart, 10)
+	// 두 번째 파일에서 처음 나타난 디바이스: a와 겹치는 구간의 레코드도 유지
+	b := vt.New(syntheticStart + 5)
+	b.Device(2, "Solar8000", "Solar8000", "COM2")
+	b.Track(vt.Track{ID: 1, Type: vt.TypeNumeric, Fmt: vt.FmtFloat32, Name: "PLETH_SPO2", Unit: "%", DevID: 2})
+	for s := 5; s < 15; s++ {
+		b.Numeric(1, syntheticStart+float64(s), float32(98))
+	}
+
+	vf, err := Merge(a, readVital(t, b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	spo2 := vf.Trks["Solar8000/PLETH_SPO2"]
+	if spo2 == nil || spo2.NumRecs() != 10 || spo2.Recs[0].Dt != syntheticStart+5 {
+		t.Errorf("SPO2 records %+v", spo2)
+	}
+	if n := vf.Trks["Bx50/ECG_II"].NumRecs(); n != 10 {
+		t.Errorf("ECG records = %d, want 10", n)
+	}
+}
+
+func TestMergeUnifiesDevicesAndRejectsConflicts(t *testing.T) {
+	a := splitPart(t, syntheticStart, 1)
+	b := vt.New(syntheticStart + 1)
+	b.Device(7, "Intellivue", "Intellivue", "COM2")
+	b.Track(vt.Track{ID: 3, Type: vt.TypeNumeric, Fmt: vt.FmtFloat32, Name: "SpO2", Unit: "%", DevID: 7})
+	b.Numeric(3, syntheticStart+1, float32(98))
+
+	vf, err := Merge(a, readVital(t, b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vf.Devs) != 2 || vf.Trks["Intellivue/SpO2"].NumRecs() != 1 || vf.Trks["Bx50/HR"].NumRecs() != 1 {
+		t.Errorf("devices %v, tracks %v", vf.Devs, vf.Order)
+	}
+
+	c := vt.New(syntheticStart + 1)
+	c.Device(1, "Bx50", "Bx50", "COM1")
+	c.Track(vt.Track{ID: 1, Type: vt.TypeWave, Fmt: vt.FmtInt16, Name: "ECG_II", Unit: "mV", SRate: 250, Gain: 0.01, DevID: 1})
+	if _, err := Merge(a, readVital(t, c)); err == nil || !strings.Contains(err.Error(), "sample rate") {
+		t.Errorf("conflicting sample rate: err = %v", err)
+	}
+	if _, err := Merge(); err == nil {
+		t.Error("Merge() without files should fail")
+	}
+}
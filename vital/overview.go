@@ -0,0 +1,255 @@
+package vital
+
+import (
+	"fmt"
+	"math"
+)
+
+// Overview reduction methods.
+const (
+	OverviewMinMax = "minmax" // 버킷마다 최소/최대값 (파형 외곽선 보존)
+	OverviewLTTB   = "lttb"   // Largest-Triangle-Three-Buckets, 버킷마다 샘플 하나
+)
+
+// defaultOverviewBuckets is the number of buckets when
+// OverviewOptions.Buckets is not set, about the width of a plot in pixels.
+const defaultOverviewBuckets = 1000
+
+// OverviewOptions configures Track.Overview. Zero values select the
+// defaults.
+type OverviewOptions struct {
+	Start   float64 // 구간 시작 (Unix 초, 0 = 트랙 처음부터)
+	End     float64 // 구간 끝, 포함하지 않음 (Unix 초, 0 = 트랙 끝까지)
+	Buckets int     // 버킷(픽셀) 수, 기본 1000
+	Method  string  // OverviewMinMax (기본) 또는 OverviewLTTB
+}
+
+// Overview is a track reduced to a number of points a plot can draw
+// directly. [Start, End) is split into buckets of equal length; buckets
+// without samples, as in gaps, are left out.
+type Overview struct {
+	Track   string    `json:"track"`
+	Unit    string    `json:"unit"`
+	Method  string    `json:"method"`
+	Start   float64   `json:"start"`
+	End     float64   `json:"end"`
+	Bucket  float64   `json:"bucket"`  // 버킷 길이 (초)
+	Samples int       `json:"samples"` // 구간 안의 유한한 값 수
+	Times   []float64 `json:"t"`       // minmax: 버킷 시작, lttb: 고른 샘플 시각
+	Min     []float64 `json:"min,omitempty"`
+	Max     []float64 `json:"max,omitempty"`
+	Values  []float64 `json:"v,omitempty"` // lttb
+}
+
+// Overview reduces the calibrated values of a WAVE or NUMERIC track in
+// [opts.Start, opts.End) to opts.Buckets buckets without holding the
+// samples in memory. OverviewMinMax keeps the smallest and largest value of
+// each bucket, so a plot drawing a vertical line per bucket shows every
+// peak. OverviewLTTB keeps the sample of each bucket that forms the largest
+// triangle with the sample kept in the previous bucket and the mean of the
+// next one (the first and last sample are always kept), which suits line
+// plots; ranges with no more samples than buckets are returned as is. NaN
+// and infinite values are skipped. Records are taken to be in time order,
+// as in Segments.
+//
+// When only one of Start and End is set and the track lies outside it (it
+// ends before Start, or starts after End), the overview is empty; Start
+// and End both set with End not after Start is an error.
+func (t *Track) Overview(opts OverviewOptions) (*Overview, error) {
+	if t.Type != 1 && t.Type != 2 {
+		return nil, fmt.Errorf("track %q: not a WAVE or NUMERIC track", t.Name)
+	}
+	if opts.Method == "" {
+		opts.Method = OverviewMinMax
+	}
+	if opts.Method != OverviewMinMax && opts.Method != OverviewLTTB {
+		return nil, fmt.Errorf("unknown overview method %q", opts.Method)
+	}
+	n := opts.Buckets
+	if n <= 0 {
+		n = defaultOverviewBuckets
+	}
+
+	start, end := opts.Start, opts.End
+	if start == 0 || end == 0 {
+		first, last, ok, err := t.span()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("track %q: no records", t.Name)
+		}
+		if start == 0 {
+			start = first
+		}
+		if end == 0 {
+			end = last
+		}
+	}
+	ov := &Overview{Track: t.Name, Unit: t.Unit, Method: opts.Method, Start: start, End: end, Times: []float64{}}
+	if !(end > start) {
+		if opts.Start != 0 && opts.End != 0 {
+			return nil, fmt.Errorf("track %q: empty range %f-%f", t.Name, start, end)
+		}
+		// 트랙이 구간 밖에 있음 (예: Start가 마지막 샘플 뒤)
+		ov.End = start
+		return ov, nil
+	}
+	ov.Bucket = (end - start) / float64(n)
+	bucket := func(tm float64) int {
+		i := int((tm - start) / ov.Bucket)
+		if i >= n {
+			i = n - 1
+		}
+		return i
+	}
+
+	// 첫 번째 패스: 버킷별 개수, 최소/최대, 평균(LTTB의 다음 버킷 기준점)
+	count := make([]int, n)
+	lo, hi := make([]float64, n), make([]float64, n)
+	var tsum, vsum []float64
+	if opts.Method == OverviewLTTB {
+		tsum, vsum = make([]float64, n), make([]float64, n)
+	}
+	err := t.forEachSample(start, end, func(tm, v float64) {
+		i := bucket(tm)
+		if count[i] == 0 || v < lo[i] {
+			lo[i] = v
+		}
+		if count[i] == 0 || v > hi[i] {
+			hi[i] = v
+		}
+		count[i]++
+		if tsum != nil {
+			tsum[i] += tm - start
+			vsum[i] += v
+		}
+		ov.Samples++
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Method == OverviewMinMax {
+		for i, c := range count {
+			if c > 0 {
+				ov.Times = append(ov.Times, start+float64(i)*ov.Bucket)
+				ov.Min = append(ov.Min, lo[i])
+				ov.Max = append(ov.Max, hi[i])
+			}
+		}
+		return ov, nil
+	}
+	if ov.Samples <= n {
+		err := t.forEachSample(start, end, func(tm, v float64) {
+			ov.Times = append(ov.Times, tm)
+			ov.Values = append(ov.Values, v)
+		})
+		return ov, err
+	}
+
+	// 두 번째 패스: 버킷마다 이전에 고른 점(a)과 다음 버킷의 평균(c)으로
+	// 만든 삼각형이 가장 큰 샘플
+	next := make([]int, n)
+	firstBucket, lastBucket := -1, -1
+	for i, following := n-1, -1; i >= 0; i-- {
+		next[i] = following
+		if count[i] > 0 {
+			following = i
+			if lastBucket < 0 {
+				lastBucket = i
+			}
+			firstBucket = i
+		}
+	}
+	var (
+		cur          = -1
+		ax, ay       float64 // 이전 버킷에서 고른 점 (시각은 start 기준)
+		bx, by, best float64 // 현재 버킷의 후보
+		hasCandidate bool
+	)
+	keep := func() {
+		ov.Times = append(ov.Times, start+bx)
+		ov.Values = append(ov.Values, by)
+		ax, ay = bx, by
+	}
+	err = t.forEachSample(start, end, func(tm, v float64) {
+		i, x := bucket(tm), tm-start
+		if i != cur {
+			if hasCandidate {
+				keep()
+			}
+			cur, hasCandidate = i, false
+		}
+		switch i {
+		case firstBucket:
+			if !hasCandidate {
+				bx, by, hasCandidate = x, v, true
+			}
+		case lastBucket:
+			bx, by, hasCandidate = x, v, true
+		default:
+			c := next[i]
+			cx, cy := tsum[c]/float64(count[c]), vsum[c]/float64(count[c])
+			area := math.Abs((ax-cx)*(v-ay) - (ax-x)*(cy-ay))
+			if !hasCandidate || area > best {
+				bx, by, best, hasCandidate = x, v, area, true
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if hasCandidate {
+		keep()
+	}
+	return ov, nil
+}
+
+// span returns the time of the first record and the time just after the
+// last sample (just after the last record for instantaneous records), so
+// that [first, last) covers every record.
+func (t *Track) span() (first, last float64, ok bool, err error) {
+	err = t.ForEachRec(func(rec Rec) error {
+		end := t.recEnd(&rec)
+		if end == rec.Dt {
+			end = math.Nextafter(end, math.Inf(1))
+		}
+		if !ok || rec.Dt < first {
+			first = rec.Dt
+		}
+		if !ok || end > last {
+			last = end
+		}
+		ok = true
+		return nil
+	})
+	return first, last, ok, err
+}
+
+// forEachSample calls fn with the time and calibrated value of each finite
+// sample of a WAVE or NUMERIC track in [from, to), in record order.
+func (t *Track) forEachSample(from, to float64, fn func(tm, v float64)) error {
+	emit := func(tm, v float64) {
+		if tm >= from && tm < to && !math.IsNaN(v) && !math.IsInf(v, 0) {
+			fn(tm, v)
+		}
+	}
+	return t.ForEachRec(func(rec Rec) error {
+		if rec.Dt >= to || t.recEnd(&rec) < from {
+			return nil
+		}
+		if v, ok := rec.GetNumericValue(); ok {
+			emit(rec.Dt, t.Calibrate(v))
+			return nil
+		}
+		vals, ok := samplesFloat64(rec.Val)
+		if !ok || t.SRate <= 0 {
+			return nil
+		}
+		for i, v := range vals {
+			emit(rec.Dt+float64(i)/float64(t.SRate), t.Calibrate(v))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,71 @@
+package vital
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOverview(t *testing.T) {
+	vf := readVital(t, syntheticRecording(4))
+	ecg := vf.Trks["Bx50/ECG_II"]
+
+	// 1 Hz 사인파 ±10: 0.5 s 버킷마다 반주기
+	ov, err := ecg.Overview(OverviewOptions{Buckets: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ov.Samples != 2000 || len(ov.Times) != 8 || !near(ov.Start, syntheticStart) || !near(ov.End, syntheticStart+4) || !near(ov.Bucket, 0.5) {
+		t.Fatalf("overview %d samples, %d buckets, %f-%f", ov.Samples, len(ov.Times), ov.Start, ov.End)
+	}
+	if ov.Min[0] != 0 || math.Abs(ov.Max[0]-10) > 0.01 || math.Abs(ov.Min[1]+10) > 0.01 || ov.Max[1] > 0.1 || !near(ov.Times[1], syntheticStart+0.5) {
+		t.Errorf("buckets: min %v, max %v", ov.Min[:2], ov.Max[:2])
+	}
+
+	ov, _ = ecg.Overview(OverviewOptions{Start: syntheticStart + 1, End: syntheticStart + 2, Buckets: 2})
+	if ov.Samples != 500 || len(ov.Times) != 2 {
+		t.Errorf("range: %d samples, %d buckets", ov.Samples, len(ov.Times))
+	}
+
+	// gap의 버킷은 빠짐
+	cut, _ := ecg.Mask(func(tm float64) bool { return tm >= syntheticStart+1.5 && tm < syntheticStart+2.5 }, true)
+	ov, _ = cut.Overview(OverviewOptions{Buckets: 8})
+	if len(ov.Times) != 6 || !near(ov.Times[3], syntheticStart+2.5) {
+		t.Errorf("gap: buckets at %v", ov.Times)
+	}
+
+	ov, err = ecg.Overview(OverviewOptions{Buckets: 8, Method: OverviewLTTB})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ov.Times) != 8 || len(ov.Values) != 8 || ov.Min != nil || !near(ov.Times[0], syntheticStart) || !near(ov.Times[7], syntheticStart+4-0.002) {
+		t.Fatalf("LTTB: %v at %v", ov.Values, ov.Times)
+	}
+	lo, hi := ov.Values[0], ov.Values[0]
+	for _, v := range ov.Values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	if lo > -9 || hi < 9 {
+		t.Errorf("LTTB missed the peaks: %v", ov.Values)
+	}
+	if ov, _ = ecg.Overview(OverviewOptions{Buckets: 5000, Method: OverviewLTTB}); len(ov.Values) != 2000 {
+		t.Errorf("LTTB with more buckets than samples kept %d", len(ov.Values))
+	}
+
+	// NUMERIC: 60, 61, 62, 63 (마지막 레코드 포함)
+	ov, err = vf.Trks["Bx50/HR"].Overview(OverviewOptions{Buckets: 2})
+	if err != nil || ov.Samples != 4 || ov.Min[0] != 60 || ov.Max[0] != 61 || ov.Max[1] != 63 {
+		t.Errorf("HR overview %+v, %v", ov, err)
+	}
+
+	// 트랙이 끝난 뒤부터의 구간은 빈 결과
+	ov, err = ecg.Overview(OverviewOptions{Start: syntheticStart + 10})
+	if err != nil || ov.Samples != 0 || len(ov.Times) != 0 {
+		t.Errorf("range after the track: %+v, %v", ov, err)
+	}
+	if _, err := ecg.Overview(OverviewOptions{Start: syntheticStart + 2, End: syntheticStart + 1}); err == nil {
+		t.Error("End before Start should fail")
+	}
+	if _, err := ecg.Overview(OverviewOptions{Method: "mean"}); err == nil {
+		t.Error("unknown method should fail")
+	}
+}
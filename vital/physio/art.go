@@ -0,0 +1,235 @@
+package physio
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+// Arterial waveform quality flags, the values of the "<name>_FLAG" track.
+const (
+	FlagOK     = 0
+	FlagDamped = 1 // 맥압이 낮음 (과감쇠, 기포, 꺾인 카테터)
+	FlagFlat   = 2 // 파형이 거의 평평함 (flush, zeroing, 분리)
+)
+
+// ARTOptions configures AnalyzeART. Zero values select the defaults.
+type ARTOptions struct {
+	Pulse         PulseOptions
+	PPVWindow     float64 // 초, 기본 30
+	PPVStep       float64 // 초, 기본 5
+	QualityWindow float64 // 초, 기본 5
+	FlatRange     float64 // 창 안의 범위가 이보다 작으면 flat (mmHg, 기본 5)
+	DampedPP      float64 // 맥압 중앙값이 이보다 작으면 damped (mmHg, 기본 20)
+}
+
+func (o *ARTOptions) defaults() {
+	if o.PPVWindow <= 0 {
+		o.PPVWindow = 30
+	}
+	if o.PPVStep <= 0 {
+		o.PPVStep = 5
+	}
+	if o.QualityWindow <= 0 {
+		o.QualityWindow = 5
+	}
+	if o.FlatRange <= 0 {
+		o.FlatRange = 5
+	}
+	if o.DampedPP <= 0 {
+		o.DampedPP = 20
+	}
+}
+
+// ArterialBeat is one cardiac cycle of an arterial pressure waveform, from
+// its onset to the next one.
+type ArterialBeat struct {
+	Time    float64 `json:"time"` // onset
+	SBP     float64 `json:"sbp"`  // 수축기 피크
+	DBP     float64 `json:"dbp"`  // onset의 값 (이완기 말)
+	MAP     float64 `json:"map"`  // 다음 onset까지의 평균
+	PP      float64 `json:"pp"`   // SBP - DBP
+	Segment int     `json:"segment"`
+}
+
+// QualityFlag marks a stretch of waveform as damped or flat.
+type QualityFlag struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Flag  int     `json:"flag"`
+}
+
+// ArterialBeats segments an arterial pressure WAVE track into cardiac
+// cycles with DetectPulses and measures each. The last pulse of a segment
+// has no next onset and is not a beat; cycles outside 0.2-3 s and beats
+// whose pulse pressure is not positive are dropped.
+func ArterialBeats(trk *vital.Track, opts PulseOptions) ([]ArterialBeat, error) {
+	var beats []ArterialBeat
+	segment := 0
+	err := trk.WaveSegments(func(seg vital.WaveSegment) error {
+		pulses, err := DetectPulses(seg.Values, seg.SRate, opts)
+		if err != nil {
+			return err
+		}
+		for k := 0; k+1 < len(pulses); k++ {
+			p, next := pulses[k], pulses[k+1].Onset
+			if cycle := float64(next-p.Onset) / seg.SRate; cycle < minRR || cycle > maxRR {
+				continue
+			}
+			b := ArterialBeat{Time: seg.Time(p.Onset), SBP: seg.Values[p.Peak], DBP: seg.Values[p.Onset], Segment: segment}
+			b.MAP = mean(seg.Values[p.Onset:next])
+			b.PP = b.SBP - b.DBP
+			if b.PP > 0 {
+				beats = append(beats, b)
+			}
+		}
+		segment++
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("track %q: %w", trk.Name, err)
+	}
+	return beats, nil
+}
+
+// ArterialQuality splits each segment of an arterial pressure WAVE track
+// into windows of opts.QualityWindow seconds and flags those whose range
+// is below opts.FlatRange as flat and those whose median pulse pressure
+// (of the beats starting in the window, zero without any) is below
+// opts.DampedPP as damped.
+func ArterialQuality(trk *vital.Track, beats []ArterialBeat, opts ARTOptions) ([]QualityFlag, error) {
+	opts.defaults()
+	var flags []QualityFlag
+	err := trk.WaveSegments(func(seg vital.WaveSegment) error {
+		w := max(1, int(opts.QualityWindow*seg.SRate))
+		for from := 0; from < len(seg.Values); from += w {
+			to := min(from+w, len(seg.Values))
+			q := QualityFlag{Start: seg.Time(from), End: seg.Time(to)}
+			lo, hi := seg.Values[from], seg.Values[from]
+			for _, v := range seg.Values[from:to] {
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+			}
+			var pps []float64
+			for _, b := range beats {
+				if b.Time >= q.Start && b.Time < q.End {
+					pps = append(pps, b.PP)
+				}
+			}
+			switch {
+			case hi-lo < opts.FlatRange:
+				q.Flag = FlagFlat
+			case median(pps) < opts.DampedPP:
+				q.Flag = FlagDamped
+			}
+			flags = append(flags, q)
+		}
+		return nil
+	})
+	return flags, err
+}
+
+// PPV returns the pulse pressure variation (%) over sliding windows of
+// beats: 100 (PPmax - PPmin) / ((PPmax + PPmin) / 2), valued at the end of
+// each window. Windows with fewer than 10 beats are skipped, and beats in
+// flagged stretches are left out.
+func PPV(beats []ArterialBeat, flags []QualityFlag, window, step float64) Series {
+	var pp Series
+	for _, b := range beats {
+		if flagAt(flags, b.Time) == FlagOK {
+			pp.Add(b.Time, b.PP)
+		}
+	}
+	return pp.Windowed(window, step, 10, func(vals []float64) float64 {
+		lo, hi := vals[0], vals[0]
+		for _, v := range vals {
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+		return 200 * (hi - lo) / (hi + lo)
+	})
+}
+
+// flagAt returns the quality flag of the stretch containing t.
+func flagAt(flags []QualityFlag, t float64) int {
+	i := sort.Search(len(flags), func(i int) bool { return flags[i].End > t })
+	if i < len(flags) && flags[i].Start <= t {
+		return flags[i].Flag
+	}
+	return FlagOK
+}
+
+// ARTResult summarizes AnalyzeART.
+type ARTResult struct {
+	Track   string        `json:"track"`
+	Beats   int           `json:"beats"`
+	SBP     float64       `json:"sbp"` // 박동 값의 중앙값
+	DBP     float64       `json:"dbp"`
+	MAP     float64       `json:"map"`
+	PPV     float64       `json:"ppv"` // 창별 PPV의 중앙값
+	Flagged []QualityFlag `json:"flagged"`
+	Tracks  []string      `json:"tracks"` // 추가한 트랙
+}
+
+// AnalyzeART segments the arterial pressure WAVE track trackName into beats
+// and adds NUMERIC tracks to vf: per beat "<name>_SBP", "<name>_DBP",
+// "<name>_MAP" and "<name>_PP" in the track's unit, windowed "<name>_PPV"
+// (%), and "<name>_FLAG" with the quality flag of each window (FlagOK,
+// FlagDamped or FlagFlat). Beats in flat stretches are not added.
+func AnalyzeART(vf *vital.VitalFile, trackName string, opts ARTOptions) (*ARTResult, error) {
+	opts.defaults()
+	trk, ok := vf.Trks[trackName]
+	if !ok {
+		return nil, fmt.Errorf("track %q not found", trackName)
+	}
+	beats, err := ArterialBeats(trk, opts.Pulse)
+	if err != nil {
+		return nil, err
+	}
+	flags, err := ArterialQuality(trk, beats, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ARTResult{Track: trackName, Flagged: []QualityFlag{}, Tracks: []string{}}
+	var sbp, dbp, mbp, pp, flag Series
+	for _, b := range beats {
+		if flagAt(flags, b.Time) == FlagFlat {
+			continue
+		}
+		sbp.Add(b.Time, b.SBP)
+		dbp.Add(b.Time, b.DBP)
+		mbp.Add(b.Time, b.MAP)
+		pp.Add(b.Time, b.PP)
+	}
+	for _, q := range flags {
+		flag.Add(q.Start, float64(q.Flag))
+		if q.Flag == FlagOK {
+			continue
+		}
+		// 같은 종류의 이어진 창은 합침
+		if n := len(res.Flagged); n > 0 && res.Flagged[n-1].Flag == q.Flag && res.Flagged[n-1].End == q.Start {
+			res.Flagged[n-1].End = q.End
+		} else {
+			res.Flagged = append(res.Flagged, q)
+		}
+	}
+	ppv := PPV(beats, flags, opts.PPVWindow, opts.PPVStep)
+	res.Beats, res.SBP, res.DBP, res.MAP, res.PPV = sbp.Len(), median(sbp.Values), median(dbp.Values), median(mbp.Values), median(ppv.Values)
+
+	base := shortName(trk)
+	for _, out := range []struct {
+		suffix, unit string
+		s            Series
+	}{
+		{"SBP", trk.Unit, sbp}, {"DBP", trk.Unit, dbp}, {"MAP", trk.Unit, mbp}, {"PP", trk.Unit, pp},
+		{"PPV", "%", ppv}, {"FLAG", "", flag},
+	} {
+		t := out.s.Track(trk, base+"_"+out.suffix, out.unit)
+		if err := vf.AddTrack(t); err != nil {
+			return nil, err
+		}
+		res.Tracks = append(res.Tracks, t.Name)
+	}
+	return res, nil
+}
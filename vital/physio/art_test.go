@@ -0,0 +1,95 @@
+package physio
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// pulsePressure is the synthetic pulse pressure at t seconds: 40 mmHg
+// varying by ±4 mmHg with a 4 s breathing cycle (PPV 20%).
+func pulsePressure(t float64) float64 {
+	return 40 + 4*math.Sin(2*math.Pi*t/4)
+}
+
+// art returns a synthetic arterial pressure (mmHg) with diastolic 70 mmHg,
+// a systolic peak 0.15 s and a dicrotic wave 0.4 s after each beat, damped
+// over 40-45 s and flushed (0 mmHg) over 50-55 s.
+func art() func(t float64) float64 {
+	var beats []float64
+	for _, seg := range beatTimes() {
+		beats = append(beats, seg...)
+	}
+	rng := rand.New(rand.NewSource(2))
+	return func(t float64) float64 {
+		if t >= 50 && t < 55 {
+			return 0
+		}
+		v := 70 + 0.3*rng.NormFloat64()
+		for _, c := range beats {
+			if t >= c && t < c+rr(c) {
+				pp := pulsePressure(c)
+				if t >= 40 && t < 45 {
+					pp *= 0.3
+				}
+				v += pp * (gauss(t, c+0.15, 1, 0.05) + gauss(t, c+0.4, 0.3, 0.05))
+			}
+		}
+		return v
+	}
+}
+
+func TestAnalyzeART(t *testing.T) {
+	vf := read(t, recording(map[string]func(float64) float64{"ART": art()}, 0.01))
+	beats, err := ArterialBeats(vf.Trks["Bx50/ART"], PulseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := beatTimes()[0]
+	n := 0
+	for _, b := range beats {
+		if b.Segment != 0 {
+			continue
+		}
+		// 마지막 박동은 다음 onset이 없으므로 제외
+		if n >= len(want)-1 {
+			t.Fatalf("extra beat at %.2f s", b.Time-start)
+		}
+		c := want[n]
+		if d := b.Time - start - c; d < -0.12 || d > 0.02 {
+			t.Errorf("beat %d onset %.3f s off", n, d)
+		}
+		if math.Abs(b.SBP-70-pulsePressure(c)) > 1.5 || math.Abs(b.DBP-70) > 1.5 {
+			t.Errorf("beat %d: SBP %.1f, DBP %.1f", n, b.SBP, b.DBP)
+		}
+		if mapWant := 70 + pulsePressure(c)*0.05*math.Sqrt(2*math.Pi)*1.3/rr(c); math.Abs(b.MAP-mapWant) > 1.5 {
+			t.Errorf("beat %d: MAP %.1f, want %.1f", n, b.MAP, mapWant)
+		}
+		n++
+	}
+	if n != len(want)-1 {
+		t.Errorf("%d beats in the first segment, want %d", n, len(want)-1)
+	}
+
+	res, err := AnalyzeART(vf, "Bx50/ART", ARTOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flagged := []QualityFlag{{start + 40, start + 45, FlagDamped}, {start + 50, start + 55, FlagFlat}}
+	if len(res.Flagged) != 2 || res.Flagged[0] != flagged[0] || res.Flagged[1] != flagged[1] {
+		t.Errorf("flagged %+v", res.Flagged)
+	}
+	if math.Abs(res.PPV-20) > 3 || math.Abs(res.SBP-110) > 5 || math.Abs(res.DBP-70) > 1 {
+		t.Errorf("result %+v", res)
+	}
+	for _, name := range []string{"SBP", "DBP", "MAP", "PP", "PPV", "FLAG"} {
+		if trk := vf.Trks["Bx50/ART_"+name]; trk == nil || len(trk.Recs) == 0 {
+			t.Errorf("track ART_%s missing or empty", name)
+		}
+	}
+	for _, rec := range vf.Trks["Bx50/ART_SBP"].Recs {
+		if tm := rec.Dt - start; tm >= 50 && tm < 55 {
+			t.Errorf("SBP at %.2f s in the flat stretch", tm)
+		}
+	}
+}
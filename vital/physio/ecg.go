@@ -0,0 +1,398 @@
+package physio
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+	"github.com/mdsung/vitaldb_processor/vital/dsp"
+)
+
+// QRS detector defaults.
+const (
+	defaultRefractory = 0.2  // 초, 이보다 가까운 QRS는 없음
+	defaultTWave      = 0.36 // 초, 이 안의 기울기가 작은 피크는 T파로 봄
+	minRR, maxRR      = 0.2, 3.0
+)
+
+// QRSOptions configures DetectQRS. Zero values select the defaults.
+type QRSOptions struct {
+	Refractory float64 // 최소 R-R 간격 (초, 기본 0.2)
+	LowCut     float64 // 대역통과 아래 끝 (Hz, 기본 5)
+	HighCut    float64 // 대역통과 위 끝 (Hz, 기본 15)
+}
+
+// Beat is a detected heart beat or pulse.
+type Beat struct {
+	Time      float64 `json:"time"`
+	Amplitude float64 `json:"amplitude"` // 피크의 보정한 값
+	Segment   int     `json:"segment"`   // 연속 구간 번호 (구간을 넘는 간격은 계산하지 않음)
+}
+
+// DetectQRS finds the R peaks of an ECG sampled at fs Hz, in the manner of
+// Pan and Tompkins (1985): the signal is band-pass filtered, differentiated,
+// squared and integrated over 150 ms, and peaks of the result are
+// classified against adaptive signal and noise levels, with a search back
+// for missed beats and a slope test against T waves. It returns the sample
+// indices of the R peaks, located on x itself (upright or inverted).
+func DetectQRS(x []float64, fs float64, opts QRSOptions) ([]int, error) {
+	if opts.Refractory <= 0 {
+		opts.Refractory = defaultRefractory
+	}
+	if opts.LowCut <= 0 {
+		opts.LowCut = 5
+	}
+	if opts.HighCut <= 0 {
+		opts.HighCut = 15
+	}
+	bp, err := dsp.ButterBandpass(2, opts.LowCut, opts.HighCut, fs)
+	if err != nil {
+		return nil, fmt.Errorf("QRS band-pass: %w", err)
+	}
+	if len(x) < int(fs) {
+		return nil, nil
+	}
+	filtered := bp.FiltFilt(x)
+
+	// 5점 미분(중앙), 제곱, 150 ms 이동 평균(중앙)
+	n := len(x)
+	slope := make([]float64, n)
+	for i := 2; i < n-2; i++ {
+		slope[i] = (2*filtered[i+1] + filtered[i+2] - filtered[i-2] - 2*filtered[i-1]) * fs / 8
+	}
+	mwi := movingAverage(square(slope), int(math.Round(0.15*fs)))
+
+	refractory := int(opts.Refractory * fs)
+	tWave := int(defaultTWave * fs)
+	slopeAt := func(i int) float64 { return maxAbs(slope, i-int(0.075*fs), i+int(0.075*fs)) }
+
+	// 첫 2초로 신호/잡음 수준 초기화
+	init := mwi[:min(n, int(2*fs))]
+	spki, npki := 0.25*maxAbs(init, 0, len(init)), 0.5*mean(init)
+	var (
+		qrs       []int     // 채택한 MWI 피크
+		qrsSlope  []float64 // 채택한 피크의 최대 기울기
+		rejected  []int     // 마지막 QRS 이후 버린 피크 (search back 후보)
+		recentRRs []int
+	)
+	accept := func(p int) {
+		if len(qrs) > 0 {
+			recentRRs = append(recentRRs, p-qrs[len(qrs)-1])
+			if len(recentRRs) > 8 {
+				recentRRs = recentRRs[1:]
+			}
+		}
+		qrs = append(qrs, p)
+		qrsSlope = append(qrsSlope, slopeAt(p))
+		rejected = rejected[:0]
+	}
+	for _, p := range localMaxima(mwi) {
+		v := mwi[p]
+		thr := npki + 0.25*(spki-npki)
+		last := -1
+		if len(qrs) > 0 {
+			last = qrs[len(qrs)-1]
+		}
+
+		// search back: 평균 R-R의 1.66배 동안 QRS가 없으면 낮은 문턱값으로 다시 찾음
+		if last >= 0 && len(recentRRs) > 0 && float64(p-last) > 1.66*float64(sum(recentRRs))/float64(len(recentRRs)) {
+			best := -1
+			for _, c := range rejected {
+				if c-last >= refractory && p-c >= refractory && mwi[c] > thr/2 && (best < 0 || mwi[c] > mwi[best]) {
+					best = c
+				}
+			}
+			if best >= 0 {
+				spki = 0.25*mwi[best] + 0.75*spki
+				accept(best)
+				last = best
+			}
+		}
+
+		switch {
+		case v <= thr || (last >= 0 && p-last < refractory):
+			npki = 0.125*v + 0.875*npki
+			rejected = append(rejected, p)
+		case last >= 0 && p-last < tWave && slopeAt(p) < 0.5*qrsSlope[len(qrsSlope)-1]:
+			// T파
+			npki = 0.125*v + 0.875*npki
+			rejected = append(rejected, p)
+		default:
+			spki = 0.125*v + 0.875*spki
+			accept(p)
+		}
+	}
+
+	// MWI 피크 근처에서 대역통과 신호가 가장 큰 곳, 그 근처 원 신호의 극값이 R 피크
+	peaks := make([]int, 0, len(qrs))
+	for _, p := range qrs {
+		c := argMaxAbs(filtered, p-int(0.1*fs), p+int(0.1*fs))
+		lo, hi := c-int(0.03*fs), c+int(0.03*fs)
+		r := argMaxSigned(x, lo, hi, filtered[c] >= 0)
+		if len(peaks) > 0 && r-peaks[len(peaks)-1] < refractory {
+			continue
+		}
+		peaks = append(peaks, r)
+	}
+	return peaks, nil
+}
+
+// RPeaks detects the R peaks of an ECG WAVE track, segment by segment.
+func RPeaks(trk *vital.Track, opts QRSOptions) ([]Beat, error) {
+	var beats []Beat
+	segment := 0
+	err := trk.WaveSegments(func(seg vital.WaveSegment) error {
+		peaks, err := DetectQRS(seg.Values, seg.SRate, opts)
+		if err != nil {
+			return err
+		}
+		for _, i := range peaks {
+			beats = append(beats, Beat{Time: seg.Time(i), Amplitude: seg.Values[i], Segment: segment})
+		}
+		segment++
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("track %q: %w", trk.Name, err)
+	}
+	return beats, nil
+}
+
+// HeartRate returns the instantaneous rate (beats per minute) at each beat
+// from the interval to the previous beat of the same segment. Intervals
+// outside 0.2-3 s (20-300 /min) are skipped.
+func HeartRate(beats []Beat) Series {
+	var s Series
+	for i := 1; i < len(beats); i++ {
+		if beats[i].Segment != beats[i-1].Segment {
+			continue
+		}
+		if rr := beats[i].Time - beats[i-1].Time; rr >= minRR && rr <= maxRR {
+			s.Add(beats[i].Time, 60/rr)
+		}
+	}
+	return s
+}
+
+// HRComparison compares a derived heart rate with the monitor's: each
+// monitor value is paired with the median derived rate over the preceding
+// window.
+type HRComparison struct {
+	Track  string  `json:"track"` // 모니터 HR 트랙
+	Window float64 `json:"window"`
+	Pairs  int     `json:"pairs"`
+	Bias   float64 `json:"bias"` // 평균(유도 - 모니터)
+	MAE    float64 `json:"mae"`
+	RMSE   float64 `json:"rmse"`
+	Within float64 `json:"within_5"` // 차이가 5/min 이내인 비율
+}
+
+// CompareHR compares derived with the monitor HR series over windows of
+// window seconds (default 10).
+func CompareHR(derived, monitor Series, window float64) HRComparison {
+	if window <= 0 {
+		window = 10
+	}
+	c := HRComparison{Window: window}
+	var sumD, sumAbs, sumSq float64
+	within := 0
+	for i, t := range monitor.Times {
+		vals := derived.Between(t-window, t)
+		if len(vals) == 0 || monitor.Values[i] <= 0 {
+			continue
+		}
+		d := median(vals) - monitor.Values[i]
+		c.Pairs++
+		sumD += d
+		sumAbs += math.Abs(d)
+		sumSq += d * d
+		if math.Abs(d) <= 5 {
+			within++
+		}
+	}
+	if c.Pairs > 0 {
+		n := float64(c.Pairs)
+		c.Bias, c.MAE, c.RMSE, c.Within = sumD/n, sumAbs/n, math.Sqrt(sumSq/n), float64(within)/n
+	}
+	return c
+}
+
+// ECGOptions configures AnalyzeECG.
+type ECGOptions struct {
+	QRS QRSOptions
+	// MonitorHR is the HR NUMERIC track compared with the derived rate
+	// (default: "<device>/HR" of the ECG's device, if present).
+	MonitorHR     string
+	CompareWindow float64 // 초, 기본 10
+}
+
+// ECGResult summarizes AnalyzeECG.
+type ECGResult struct {
+	Track      string        `json:"track"`
+	Beats      int           `json:"beats"`
+	MeanHR     float64       `json:"mean_hr"`
+	PeakTrack  string        `json:"peak_track"`
+	HRTrack    string        `json:"hr_track"`
+	Comparison *HRComparison `json:"comparison,omitempty"`
+}
+
+// AnalyzeECG detects the R peaks of the ECG track trackName and adds two
+// NUMERIC tracks to vf: "<name>_RPEAK" with the R amplitude at each peak
+// and "<name>_HR" with the instantaneous heart rate. The derived rate is
+// compared with the monitor's HR track when there is one.
+func AnalyzeECG(vf *vital.VitalFile, trackName string, opts ECGOptions) (*ECGResult, error) {
+	trk, ok := vf.Trks[trackName]
+	if !ok {
+		return nil, fmt.Errorf("track %q not found", trackName)
+	}
+	beats, err := RPeaks(trk, opts.QRS)
+	if err != nil {
+		return nil, err
+	}
+	var peaks Series
+	for _, b := range beats {
+		peaks.Add(b.Time, b.Amplitude)
+	}
+	hr := HeartRate(beats)
+
+	base := shortName(trk)
+	peakTrk := peaks.Track(trk, base+"_RPEAK", trk.Unit)
+	hrTrk := hr.Track(trk, base+"_HR", "/min")
+	hrTrk.Mindisp, hrTrk.Maxdisp = 0, 200
+	for _, t := range []*vital.Track{peakTrk, hrTrk} {
+		if err := vf.AddTrack(t); err != nil {
+			return nil, err
+		}
+	}
+
+	res := &ECGResult{Track: trackName, Beats: len(beats), MeanHR: mean(hr.Values), PeakTrack: peakTrk.Name, HRTrack: hrTrk.Name}
+	monitor := opts.MonitorHR
+	if monitor == "" && trk.DName != "" {
+		monitor = trk.DName + "/HR"
+		if _, ok := vf.Trks[monitor]; !ok {
+			monitor = ""
+		}
+	}
+	if monitor != "" {
+		mtrk, ok := vf.Trks[monitor]
+		if !ok {
+			return nil, fmt.Errorf("monitor HR track %q not found", monitor)
+		}
+		ms, err := NumericSeries(mtrk)
+		if err != nil {
+			return nil, err
+		}
+		c := CompareHR(hr, ms, opts.CompareWindow)
+		c.Track = monitor
+		res.Comparison = &c
+	}
+	return res, nil
+}
+
+// localMaxima returns the indices of the peaks of x: samples greater than
+// the previous one and not less than the next one.
+func localMaxima(x []float64) []int {
+	var peaks []int
+	for i := 1; i+1 < len(x); i++ {
+		if x[i] > x[i-1] && x[i] >= x[i+1] && x[i] > 0 {
+			peaks = append(peaks, i)
+		}
+	}
+	return peaks
+}
+
+// movingAverage returns the centred moving average of x over w samples.
+func movingAverage(x []float64, w int) []float64 {
+	if w < 1 {
+		w = 1
+	}
+	cum := make([]float64, len(x)+1)
+	for i, v := range x {
+		cum[i+1] = cum[i] + v
+	}
+	out := make([]float64, len(x))
+	for i := range x {
+		lo, hi := clamp(i-w/2, len(x)), clamp(i-w/2+w, len(x))
+		if hi > lo {
+			out[i] = (cum[hi] - cum[lo]) / float64(hi-lo)
+		}
+	}
+	return out
+}
+
+func square(x []float64) []float64 {
+	out := make([]float64, len(x))
+	for i, v := range x {
+		out[i] = v * v
+	}
+	return out
+}
+
+// clamp limits i to [0, n].
+func clamp(i, n int) int {
+	return max(0, min(i, n))
+}
+
+// maxAbs returns the largest |x[i]| for i in [lo, hi), clamped to x.
+func maxAbs(x []float64, lo, hi int) float64 {
+	if i := argMaxAbs(x, lo, hi); i >= 0 {
+		return math.Abs(x[i])
+	}
+	return 0
+}
+
+// argMaxAbs returns the index of the largest |x[i]| in [lo, hi), clamped
+// to x, or -1 if the range is empty.
+func argMaxAbs(x []float64, lo, hi int) int {
+	best := -1
+	for i := clamp(lo, len(x)); i < clamp(hi, len(x)); i++ {
+		if best < 0 || math.Abs(x[i]) > math.Abs(x[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// argMaxSigned returns the index of the largest (up) or smallest value of
+// x in [lo, hi), clamped to x.
+func argMaxSigned(x []float64, lo, hi int, up bool) int {
+	best := -1
+	for i := clamp(lo, len(x)); i < clamp(hi, len(x)); i++ {
+		if best < 0 || (up && x[i] > x[best]) || (!up && x[i] < x[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+func sum(x []int) int {
+	s := 0
+	for _, v := range x {
+		s += v
+	}
+	return s
+}
+
+func mean(x []float64) float64 {
+	if len(x) == 0 {
+		return 0
+	}
+	var s float64
+	for _, v := range x {
+		s += v
+	}
+	return s / float64(len(x))
+}
+
+func median(x []float64) float64 {
+	if len(x) == 0 {
+		return 0
+	}
+	s := append([]float64(nil), x...)
+	sort.Float64s(s)
+	if len(s)%2 == 1 {
+		return s[len(s)/2]
+	}
+	return (s[len(s)/2-1] + s[len(s)/2]) / 2
+}
@@ -0,0 +1,81 @@
+package physio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAnalyzeECG(t *testing.T) {
+	vf := read(t, recording(map[string]func(float64) float64{"ECG_II": ecg()}, 0.001))
+	res, err := AnalyzeECG(vf, "Bx50/ECG_II", ECGOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := beatTimes()
+	if n := len(want[0]) + len(want[1]); res.Beats != n {
+		t.Errorf("%d beats, want %d", res.Beats, n)
+	}
+	peaks := vf.Trks["Bx50/ECG_II_RPEAK"]
+	if peaks == nil || peaks.Unit != "mV" {
+		t.Fatalf("R peak track: %+v", peaks)
+	}
+	i := 0
+	for _, seg := range want {
+		for _, bt := range seg {
+			if i >= len(peaks.Recs) {
+				break
+			}
+			if d := peaks.Recs[i].Dt - start - bt; math.Abs(d) > 0.005 {
+				t.Errorf("beat %d at %.3f s detected %.3f s off", i, bt, d)
+			}
+			i++
+		}
+	}
+
+	hr := vf.Trks["Bx50/ECG_II_HR"]
+	for _, rec := range hr.Recs {
+		tm := rec.Dt - start
+		if tm > 30 && tm < 40.5 {
+			t.Errorf("HR at %.2f s spans the gap", tm)
+		}
+		if v := float64(rec.Val.(float32)); v < 65 || v > 85 {
+			t.Errorf("HR %g at %.2f s", v, tm)
+		}
+	}
+	c := res.Comparison
+	if c == nil || c.Track != "Bx50/HR" || c.Pairs < 40 || math.Abs(c.Bias) > 2 || c.Within < 0.9 {
+		t.Errorf("comparison %+v", c)
+	}
+
+	if _, err := AnalyzeECG(vf, "Bx50/ECG_II", ECGOptions{}); err == nil {
+		t.Error("analyzing twice should fail: the tracks exist")
+	}
+}
+
+func TestDetectQRSInverted(t *testing.T) {
+	f := ecg()
+	x := make([]float64, 5000)
+	for i := range x {
+		x[i] = -f(float64(i) / 500)
+	}
+	peaks, err := DetectQRS(x, 500, QRSOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := beatTimes()[0]
+	n := 0
+	for _, bt := range want {
+		if bt < 10 {
+			n++
+		}
+	}
+	if len(peaks) != n {
+		t.Fatalf("%d peaks, want %d", len(peaks), n)
+	}
+	for i, p := range peaks {
+		if d := float64(p)/500 - want[i]; math.Abs(d) > 0.005 {
+			t.Errorf("peak %d: %.3f s off", i, d)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package physio
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+// HRV frequency bands (Hz), as in the 1996 Task Force standard.
+const (
+	VLFLow  = 0.0033
+	LFLow   = 0.04
+	LFHigh  = 0.15
+	HFHigh  = 0.4
+	psdStep = 0.001 // Lomb-Scargle 주파수 간격 (Hz)
+)
+
+// HRVOptions configures the windowed HRV analysis. Zero values select the
+// defaults.
+type HRVOptions struct {
+	Window       float64 // 초, 기본 300 (5분)
+	Step         float64 // 초, 기본 30
+	MinIntervals int     // 창마다 필요한 최소 R-R 간격 수, 기본 30
+	// MaxChange drops intervals that differ from the previous one by more
+	// than this fraction (ectopic beats and missed detections; default 0.2,
+	// negative keeps every interval).
+	MaxChange float64
+	QRS       QRSOptions // ECG 트랙에서 R 피크를 찾을 때
+}
+
+func (o *HRVOptions) defaults() {
+	if o.Window <= 0 {
+		o.Window = 300
+	}
+	if o.Step <= 0 {
+		o.Step = 30
+	}
+	if o.MinIntervals <= 0 {
+		o.MinIntervals = 30
+	}
+	if o.MaxChange == 0 {
+		o.MaxChange = 0.2
+	}
+}
+
+// HRV holds the heart rate variability of one window of R-R intervals.
+// Intervals are in ms and spectral powers in ms²; LF, HF and LFHF are zero
+// when the window spans less than two LF periods (50 s).
+type HRV struct {
+	Time      float64 `json:"time"` // 창의 끝
+	Intervals int     `json:"intervals"`
+	MeanRR    float64 `json:"mean_rr"`
+	SDNN      float64 `json:"sdnn"`
+	RMSSD     float64 `json:"rmssd"`
+	PNN50     float64 `json:"pnn50"` // %
+	LF        float64 `json:"lf,omitempty"`
+	HF        float64 `json:"hf,omitempty"`
+	LFHF      float64 `json:"lf_hf,omitempty"`
+	SD1       float64 `json:"sd1"`
+	SD2       float64 `json:"sd2"`
+}
+
+// RRIntervals returns the R-R intervals (ms) between consecutive beats of
+// the same segment, timed at the second beat. Intervals outside 0.2-3 s
+// are dropped, as are those that differ from the previous interval by more
+// than maxChange (a fraction; zero or negative keeps them).
+func RRIntervals(beats []Beat, maxChange float64) Series {
+	var s Series
+	prev := 0.0
+	for i := 1; i < len(beats); i++ {
+		if beats[i].Segment != beats[i-1].Segment {
+			prev = 0
+			continue
+		}
+		rr := beats[i].Time - beats[i-1].Time
+		if rr < minRR || rr > maxRR {
+			prev = 0
+			continue
+		}
+		ok := prev == 0 || maxChange <= 0 || math.Abs(rr-prev) <= maxChange*prev
+		prev = rr
+		if ok {
+			s.Add(beats[i].Time, 1000*rr)
+		}
+	}
+	return s
+}
+
+// RRSeries reads the R-R intervals of a NUMERIC track holding one interval
+// per beat, converted to ms: values are taken as seconds if the unit is "s"
+// or "sec", or, without a unit, if they are all below 10.
+func RRSeries(trk *vital.Track) (Series, error) {
+	s, err := NumericSeries(trk)
+	if err != nil {
+		return s, err
+	}
+	unit := strings.ToLower(trk.Unit)
+	seconds := unit == "s" || unit == "sec"
+	if unit == "" {
+		seconds = len(s.Values) > 0
+		for _, v := range s.Values {
+			if v >= 10 {
+				seconds = false
+				break
+			}
+		}
+	}
+	if seconds {
+		for i := range s.Values {
+			s.Values[i] *= 1000
+		}
+	}
+	return s, nil
+}
+
+// ComputeHRV returns the HRV of a series of R-R intervals (ms). Successive
+// differences (RMSSD, pNN50, SD1) only use pairs of adjacent intervals: the
+// second must end one interval after the first. It returns false if there
+// are fewer than three intervals.
+func ComputeHRV(rr Series) (HRV, bool) {
+	n := rr.Len()
+	if n < 3 {
+		return HRV{}, false
+	}
+	h := HRV{Time: rr.Times[n-1], Intervals: n, MeanRR: mean(rr.Values)}
+	var ss float64
+	for _, v := range rr.Values {
+		ss += (v - h.MeanRR) * (v - h.MeanRR)
+	}
+	h.SDNN = math.Sqrt(ss / float64(n-1))
+
+	var diffs []float64
+	nn50 := 0
+	for i := 1; i < n; i++ {
+		gap := 1000 * (rr.Times[i] - rr.Times[i-1])
+		if math.Abs(gap-rr.Values[i]) > 0.1*rr.Values[i] {
+			continue
+		}
+		d := rr.Values[i] - rr.Values[i-1]
+		diffs = append(diffs, d)
+		if math.Abs(d) > 50 {
+			nn50++
+		}
+	}
+	if len(diffs) > 0 {
+		var sq, md float64
+		md = mean(diffs)
+		for _, d := range diffs {
+			sq += d * d
+		}
+		h.RMSSD = math.Sqrt(sq / float64(len(diffs)))
+		h.PNN50 = 100 * float64(nn50) / float64(len(diffs))
+		var vd float64
+		for _, d := range diffs {
+			vd += (d - md) * (d - md)
+		}
+		if len(diffs) > 1 {
+			vd /= float64(len(diffs) - 1)
+		}
+		h.SD1 = math.Sqrt(vd / 2)
+		h.SD2 = math.Sqrt(math.Max(0, 2*h.SDNN*h.SDNN-h.SD1*h.SD1))
+	}
+
+	if rr.Times[n-1]-rr.Times[0] >= 2/LFLow {
+		freqs, psd := LombScargle(rr, VLFLow, HFHigh, psdStep)
+		h.LF = bandPower(freqs, psd, LFLow, LFHigh)
+		h.HF = bandPower(freqs, psd, LFHigh, HFHigh)
+		if h.HF > 0 {
+			h.LFHF = h.LF / h.HF
+		}
+	}
+	return h, true
+}
+
+// LombScargle returns the Lomb-Scargle power spectral density of an
+// unevenly sampled series at frequencies from lo to hi Hz in steps of df.
+// The mean is removed and the density is scaled so that it integrates to
+// the variance (units²/Hz), which suits R-R interval series without
+// resampling.
+func LombScargle(s Series, lo, hi, df float64) (freqs, psd []float64) {
+	n := s.Len()
+	if n < 2 || df <= 0 {
+		return nil, nil
+	}
+	m := mean(s.Values)
+	span := s.Times[n-1] - s.Times[0]
+	// 평균 간격만큼 더해 n개 샘플이 덮는 시간
+	span += span / float64(n-1)
+	// 절대 시각(epoch 초)은 삼각함수 정밀도를 떨어뜨리므로 첫 시각 기준
+	t0 := s.Times[0]
+	for k := 0; lo+float64(k)*df <= hi+df/2; k++ {
+		f := lo + float64(k)*df
+		w := 2 * math.Pi * f
+		var s2, c2 float64
+		for _, t := range s.Times {
+			s2 += math.Sin(2 * w * (t - t0))
+			c2 += math.Cos(2 * w * (t - t0))
+		}
+		tau := math.Atan2(s2, c2) / (2 * w)
+		var yc, ys, cc, sn float64
+		for i, t := range s.Times {
+			c, si := math.Cos(w*(t-t0-tau)), math.Sin(w*(t-t0-tau))
+			y := s.Values[i] - m
+			yc += y * c
+			ys += y * si
+			cc += c * c
+			sn += si * si
+		}
+		var p float64
+		if cc > 0 {
+			p += yc * yc / cc
+		}
+		if sn > 0 {
+			p += ys * ys / sn
+		}
+		freqs = append(freqs, f)
+		psd = append(psd, p*span/float64(n))
+	}
+	return freqs, psd
+}
+
+// bandPower integrates psd over [lo, hi) Hz with the trapezoidal rule.
+func bandPower(freqs, psd []float64, lo, hi float64) float64 {
+	var p float64
+	for i := 1; i < len(freqs); i++ {
+		if freqs[i-1] >= lo && freqs[i] <= hi+1e-9 {
+			p += (psd[i-1] + psd[i]) / 2 * (freqs[i] - freqs[i-1])
+		}
+	}
+	return p
+}
+
+// WindowedHRV computes the HRV over sliding windows of R-R intervals (ms)
+// ending at the first interval's time plus opts.Window, then every
+// opts.Step seconds. As in Series.Windowed, windows with fewer than
+// opts.MinIntervals intervals or ending in a gap are skipped.
+func WindowedHRV(rr Series, opts HRVOptions) []HRV {
+	opts.defaults()
+	var out []HRV
+	if rr.Len() == 0 {
+		return out
+	}
+	first, last := rr.Times[0], rr.Times[rr.Len()-1]
+	for end := first + opts.Window; end < last+opts.Step; end += opts.Step {
+		i := sort.SearchFloat64s(rr.Times, end-opts.Window)
+		j := sort.SearchFloat64s(rr.Times, end)
+		if j-i < opts.MinIntervals || rr.Times[j-1] < end-opts.Step {
+			continue
+		}
+		h, ok := ComputeHRV(Series{Times: rr.Times[i:j], Values: rr.Values[i:j]})
+		if ok {
+			h.Time = end
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// HRVResult summarizes AnalyzeHRV.
+type HRVResult struct {
+	Track     string   `json:"track"`
+	Intervals int      `json:"intervals"`
+	Windows   []HRV    `json:"windows"`
+	Tracks    []string `json:"tracks"` // 추가한 트랙
+}
+
+// hrvMetrics are the windowed HRV tracks: name suffix, unit, value and
+// whether it is spectral (left out of windows too short for LF).
+var hrvMetrics = []struct {
+	suffix, unit string
+	value        func(HRV) float64
+	spectral     bool
+}{
+	{"SDNN", "ms", func(h HRV) float64 { return h.SDNN }, false},
+	{"RMSSD", "ms", func(h HRV) float64 { return h.RMSSD }, false},
+	{"PNN50", "%", func(h HRV) float64 { return h.PNN50 }, false},
+	{"LF", "ms2", func(h HRV) float64 { return h.LF }, true},
+	{"HF", "ms2", func(h HRV) float64 { return h.HF }, true},
+	{"LFHF", "", func(h HRV) float64 { return h.LFHF }, true},
+	{"SD1", "ms", func(h HRV) float64 { return h.SD1 }, false},
+	{"SD2", "ms", func(h HRV) float64 { return h.SD2 }, false},
+}
+
+// AnalyzeHRV computes windowed HRV from trackName, either an ECG WAVE track
+// (R peaks are detected with RPeaks) or a NUMERIC track of R-R intervals
+// (see RRSeries), and adds a NUMERIC track per metric to vf, named
+// "<name>_SDNN", "<name>_RMSSD", "<name>_PNN50", "<name>_LF", "<name>_HF",
+// "<name>_LFHF", "<name>_SD1" and "<name>_SD2" and valued at each window's
+// end. The spectral tracks skip windows too short for LF.
+func AnalyzeHRV(vf *vital.VitalFile, trackName string, opts HRVOptions) (*HRVResult, error) {
+	opts.defaults()
+	trk, ok := vf.Trks[trackName]
+	if !ok {
+		return nil, fmt.Errorf("track %q not found", trackName)
+	}
+	var rr Series
+	switch trk.Type {
+	case 1:
+		beats, err := RPeaks(trk, opts.QRS)
+		if err != nil {
+			return nil, err
+		}
+		rr = RRIntervals(beats, opts.MaxChange)
+	case 2:
+		var err error
+		if rr, err = RRSeries(trk); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("track %q: not a WAVE or NUMERIC track", trackName)
+	}
+
+	res := &HRVResult{Track: trackName, Intervals: rr.Len(), Windows: WindowedHRV(rr, opts), Tracks: []string{}}
+	base := shortName(trk)
+	for _, m := range hrvMetrics {
+		var s Series
+		for _, h := range res.Windows {
+			if !m.spectral || h.HF > 0 {
+				s.Add(h.Time, m.value(h))
+			}
+		}
+		out := s.Track(trk, base+"_"+m.suffix, m.unit)
+		if err := vf.AddTrack(out); err != nil {
+			return nil, err
+		}
+		res.Tracks = append(res.Tracks, out.Name)
+	}
+	return res, nil
+}
@@ -0,0 +1,105 @@
+package physio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mdsung/vitaldb_processor/vital/vitaltest"
+)
+
+// rrSeries returns consecutive R-R intervals (ms) from fn over seconds.
+func rrSeries(seconds float64, fn func(t float64) float64) Series {
+	var s Series
+	for t := 0.0; t < seconds; {
+		rr := fn(t)
+		t += rr / 1000
+		s.Add(t, rr)
+	}
+	return s
+}
+
+func TestComputeHRV(t *testing.T) {
+	// 800, 900 ms 교대: 연속 차이는 모두 ±100 ms
+	h, ok := ComputeHRV(rrSeries(40, func(t float64) float64 {
+		if int(math.Round(t/0.85))%2 == 0 {
+			return 800
+		}
+		return 900
+	}))
+	if !ok || math.Abs(h.MeanRR-850) > 3 || math.Abs(h.RMSSD-100) > 1e-9 || h.PNN50 != 100 ||
+		math.Abs(h.SDNN-50) > 1 || math.Abs(h.SD1-70.7) > 2 {
+		t.Errorf("alternating: %+v", h)
+	}
+	if h.LF != 0 {
+		t.Errorf("LF over %g s", h.Time)
+	}
+
+	// 0.1 Hz (LF) 진폭 50 ms, 0.25 Hz (HF) 진폭 30 ms: 각 대역 파워는 진폭²/2
+	h, _ = ComputeHRV(rrSeries(300, func(t float64) float64 {
+		return 1000 + 50*math.Sin(2*math.Pi*0.1*t) + 30*math.Sin(2*math.Pi*0.25*t)
+	}))
+	if math.Abs(h.LF-1250) > 125 || math.Abs(h.HF-450) > 45 || math.Abs(h.LFHF-1250.0/450) > 0.4 {
+		t.Errorf("LF %g, HF %g, LF/HF %g", h.LF, h.HF, h.LFHF)
+	}
+	if _, ok := ComputeHRV(Series{Times: []float64{1, 2}, Values: []float64{1000, 1000}}); ok {
+		t.Error("two intervals should not give HRV")
+	}
+}
+
+func TestRRIntervals(t *testing.T) {
+	beats := []Beat{{Time: 0}, {Time: 1}, {Time: 2}, {Time: 2.5}, {Time: 3.5}, {Time: 4.5}, {Time: 10, Segment: 1}, {Time: 11, Segment: 1}}
+	rr := RRIntervals(beats, 0.2)
+	// 2.5 s 조기 박동의 간격(500 ms)과 그 다음 간격(1000 ms, 직전 대비 100%)은 제외
+	if rr.Len() != 4 || rr.Times[2] != 4.5 || rr.Times[3] != 11 {
+		t.Fatalf("intervals %+v", rr)
+	}
+	// 4.5 s, 11 s 간격은 직전 간격과 이어지지 않으므로 연속 차이에서 제외
+	h, _ := ComputeHRV(rr)
+	if h.RMSSD != 0 || h.SDNN != 0 {
+		t.Errorf("HRV %+v", h)
+	}
+}
+
+func TestAnalyzeHRV(t *testing.T) {
+	// R-R 간격 NUMERIC 트랙 (초 단위, 단위 없음)
+	b := vitaltest.New(start)
+	b.Device(1, "Bx50", "Bx50", "COM1")
+	b.Track(vitaltest.Track{ID: 1, Type: vitaltest.TypeNumeric, Fmt: vitaltest.FmtFloat32, Name: "RR", Gain: 1, DevID: 1})
+	rr := rrSeries(600, func(t float64) float64 { return 1000 + 50*math.Sin(2*math.Pi*0.1*t) })
+	for i, tm := range rr.Times {
+		b.Numeric(1, start+tm, float32(rr.Values[i]/1000))
+	}
+	vf := read(t, b)
+	res, err := AnalyzeHRV(vf, "Bx50/RR", HRVOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 300 s 창, 30 s 간격: 300-600 s
+	if len(res.Windows) != 11 || len(res.Tracks) != 8 {
+		t.Fatalf("%d windows, tracks %v", len(res.Windows), res.Tracks)
+	}
+	sdnn := vf.Trks["Bx50/RR_SDNN"]
+	if sdnn == nil || sdnn.Unit != "ms" || len(sdnn.Recs) != 11 {
+		t.Fatalf("SDNN track %+v", sdnn)
+	}
+	if v := float64(sdnn.Recs[0].Val.(float32)); math.Abs(v-35.4) > 1 {
+		t.Errorf("SDNN %g, want 50/√2", v)
+	}
+	if lf := vf.Trks["Bx50/RR_LF"]; len(lf.Recs) != 11 || math.Abs(float64(lf.Recs[0].Val.(float32))-1250) > 125 {
+		t.Errorf("LF %+v", lf.Recs[0])
+	}
+
+	// ECG에서 R 피크 검출: 짧은 창은 LF를 계산하지 않음
+	vf = read(t, recording(map[string]func(float64) float64{"ECG_II": ecg()}, 0.001))
+	res, err = AnalyzeHRV(vf, "Bx50/ECG_II", HRVOptions{Window: 20, Step: 10, MinIntervals: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Windows) == 0 || res.Windows[0].SDNN < 30 || len(vf.Trks["Bx50/ECG_II_RMSSD"].Recs) != len(res.Windows) ||
+		len(vf.Trks["Bx50/ECG_II_LF"].Recs) != 0 {
+		t.Errorf("ECG HRV: %+v", res.Windows)
+	}
+	if _, err := AnalyzeHRV(vf, "Bx50/HR_MISSING", HRVOptions{}); err == nil {
+		t.Error("missing track should fail")
+	}
+}
@@ -0,0 +1,116 @@
+// Package physio derives physiological measurements from the waveforms of
+// a .vital recording: beats detected in ECG, arterial pressure and pleth
+// tracks and the rates, pressures and indices computed from them. Analyses
+// read calibrated samples one contiguous segment at a time (see
+// vital.Track.WaveSegments), so nothing is computed across gaps, and add
+// their results to the VitalFile as NUMERIC tracks named after the source
+// track.
+package physio
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+// Series is a sequence of time stamped values, such as one value per beat
+// or per window. Times are in increasing order.
+type Series struct {
+	Times  []float64
+	Values []float64
+}
+
+// Add appends a value.
+func (s *Series) Add(t, v float64) {
+	s.Times = append(s.Times, t)
+	s.Values = append(s.Values, v)
+}
+
+// Len returns the number of values.
+func (s Series) Len() int {
+	return len(s.Times)
+}
+
+// Between returns the values with times in [from, to).
+func (s Series) Between(from, to float64) []float64 {
+	i := sort.SearchFloat64s(s.Times, from)
+	j := sort.SearchFloat64s(s.Times, to)
+	return s.Values[i:j]
+}
+
+// Windowed summarizes s over sliding windows: fn is called with the values
+// in [end-window, end) for end from the first time plus window, then every
+// step seconds, and its result is valued at end. Windows with fewer than
+// minCount values, or none in their last step (ending in a gap), are
+// skipped.
+func (s Series) Windowed(window, step float64, minCount int, fn func([]float64) float64) Series {
+	var out Series
+	if s.Len() == 0 || !(window > 0) || !(step > 0) {
+		return out
+	}
+	first, last := s.Times[0], s.Times[s.Len()-1]
+	for end := first + window; end < last+step; end += step {
+		if len(s.Between(end-step, end)) == 0 {
+			continue
+		}
+		if vals := s.Between(end-window, end); len(vals) >= max(1, minCount) {
+			out.Add(end, fn(vals))
+		}
+	}
+	return out
+}
+
+// Track returns the series as a NUMERIC track (float32 values) of the
+// source track's device, named "<device>/<name>".
+func (s Series) Track(src *vital.Track, name, unit string) *vital.Track {
+	trk := &vital.Track{
+		Name:  name,
+		Type:  2,
+		Fmt:   1,
+		Unit:  unit,
+		DName: src.DName,
+		Recs:  make([]vital.Rec, len(s.Times)),
+	}
+	if src.DName != "" {
+		trk.Name = src.DName + "/" + name
+	}
+	for i, t := range s.Times {
+		trk.Recs[i] = vital.Rec{Dt: t, Val: float32(s.Values[i])}
+	}
+	return trk
+}
+
+// NumericSeries reads the values of a NUMERIC track, calibrated and in
+// time order.
+func NumericSeries(trk *vital.Track) (Series, error) {
+	var s Series
+	err := trk.ForEachRec(func(rec vital.Rec) error {
+		if v, ok := rec.GetNumericValue(); ok {
+			s.Add(rec.Dt, trk.Calibrate(v))
+		}
+		return nil
+	})
+	if !sort.Float64sAreSorted(s.Times) {
+		sort.Sort(byTime(s))
+	}
+	return s, err
+}
+
+// byTime sorts a Series by time.
+type byTime Series
+
+func (s byTime) Len() int           { return len(s.Times) }
+func (s byTime) Less(i, j int) bool { return s.Times[i] < s.Times[j] }
+func (s byTime) Swap(i, j int) {
+	s.Times[i], s.Times[j] = s.Times[j], s.Times[i]
+	s.Values[i], s.Values[j] = s.Values[j], s.Values[i]
+}
+
+// shortName returns the track name without its device.
+func shortName(trk *vital.Track) string {
+	if name, ok := strings.CutPrefix(trk.Name, trk.DName+"/"); ok && trk.DName != "" {
+		return name
+	}
+	return trk.Name
+}
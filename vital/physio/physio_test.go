@@ -0,0 +1,142 @@
+package physio
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+	"github.com/mdsung/vitaldb_processor/vital/vitaltest"
+)
+
+// start is the header start time of the synthetic recordings.
+const start = 1721811600.0
+
+// segments are the stretches (seconds from start) covered by the synthetic
+// waveforms; nothing is recorded between them.
+var segments = [][2]float64{{0, 30}, {40, 60}}
+
+// rr returns the synthetic R-R interval at t seconds: 0.8 s (75 /min)
+// varying by ±0.08 s over 10 s.
+func rr(t float64) float64 {
+	return 0.8 + 0.08*math.Sin(2*math.Pi*t/10)
+}
+
+// monitorHR returns the rate a monitor would show at t seconds: the mean
+// over the preceding 10 s.
+func monitorHR(t float64) float64 {
+	var sum float64
+	for i := 0; i < 100; i++ {
+		sum += 60 / rr(t-float64(i)/10)
+	}
+	return sum / 100
+}
+
+// beatTimes returns the beat times (seconds from start) in each segment.
+func beatTimes() [][]float64 {
+	beats := make([][]float64, len(segments))
+	for i, seg := range segments {
+		for t := seg[0] + 0.3; t < seg[1]; t += rr(t) {
+			beats[i] = append(beats[i], t)
+		}
+	}
+	return beats
+}
+
+// gauss is a gaussian wave of amplitude a and width w centred on c.
+func gauss(t, c, a, w float64) float64 {
+	d := (t - c) / w
+	return a * math.Exp(-d*d/2)
+}
+
+// recording returns a builder with a device and one int16 500 Hz WAVE track
+// per name, each sampled from fn over segments in 1 s chunks, and a 1 Hz
+// monitor HR track.
+func recording(fn map[string]func(t float64) float64, gain float64) *vitaltest.Builder {
+	b := vitaltest.New(start)
+	b.Device(1, "Bx50", "Bx50", "COM1")
+	b.Track(vitaltest.Track{ID: 1, Type: vitaltest.TypeNumeric, Fmt: vitaltest.FmtFloat32,
+		Name: "HR", Unit: "/min", Maxdisp: 200, Gain: 1, DevID: 1})
+	id := uint16(2)
+	for _, name := range []string{"ECG_II", "ART", "PLETH"} {
+		f, ok := fn[name]
+		if !ok {
+			continue
+		}
+		b.Track(vitaltest.Track{ID: id, Type: vitaltest.TypeWave, Fmt: vitaltest.FmtInt16,
+			Name: name, Unit: "mV", Maxdisp: 200, SRate: 500, Gain: gain, DevID: 1})
+		for _, seg := range segments {
+			for sec := seg[0]; sec < seg[1]; sec++ {
+				chunk := make([]int16, 500)
+				for i := range chunk {
+					chunk[i] = int16(math.Round(f(sec+float64(i)/500) / gain))
+				}
+				b.Wave(id, start+sec, chunk)
+			}
+		}
+		id++
+	}
+	for _, seg := range segments {
+		for sec := seg[0]; sec < seg[1]; sec++ {
+			b.Numeric(1, start+sec, float32(math.Round(monitorHR(sec))))
+		}
+	}
+	return b
+}
+
+// ecg returns a synthetic ECG (mV) with QRS complexes and T waves at
+// beatTimes, baseline wander and noise.
+func ecg() func(t float64) float64 {
+	var beats []float64
+	for _, seg := range beatTimes() {
+		beats = append(beats, seg...)
+	}
+	rng := rand.New(rand.NewSource(1))
+	return func(t float64) float64 {
+		v := 0.3*math.Sin(2*math.Pi*0.2*t) + 0.02*rng.NormFloat64()
+		for _, c := range beats {
+			if math.Abs(t-c) < 0.5 {
+				v += gauss(t, c-0.025, -0.1, 0.008) + gauss(t, c, 1, 0.01) +
+					gauss(t, c+0.025, -0.2, 0.008) + gauss(t, c+0.25, 0.3, 0.04)
+			}
+		}
+		return v
+	}
+}
+
+// read loads a builder's stream.
+func read(t *testing.T, b *vitaltest.Builder) *vital.VitalFile {
+	t.Helper()
+	vf, err := vital.ReadVitalFile(bytes.NewReader(b.Gzip()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return vf
+}
+
+func TestSeries(t *testing.T) {
+	var s Series
+	for i := 0; i < 5; i++ {
+		s.Add(float64(i), float64(10*i))
+	}
+	if got := s.Between(1, 3); len(got) != 2 || got[0] != 10 || got[1] != 20 {
+		t.Errorf("Between(1, 3) = %v", got)
+	}
+
+	vf := read(t, recording(nil, 1))
+	hr, err := NumericSeries(vf.Trks["Bx50/HR"])
+	if err != nil || hr.Len() != 50 || hr.Values[0] != 75 {
+		t.Fatalf("NumericSeries: %d values, %v", hr.Len(), err)
+	}
+	trk := s.Track(vf.Trks["Bx50/HR"], "HR_AVG", "/min")
+	if trk.Name != "Bx50/HR_AVG" || trk.Type != 2 || len(trk.Recs) != 5 || trk.Recs[4].Val != float32(40) {
+		t.Errorf("Track: %+v", trk)
+	}
+	if err := vf.AddTrack(trk); err != nil || vf.Trks["Bx50/HR_AVG"] != trk {
+		t.Errorf("AddTrack: %v", err)
+	}
+	if err := vf.AddTrack(trk); err == nil {
+		t.Error("adding a duplicate track should fail")
+	}
+}
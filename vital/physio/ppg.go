@@ -0,0 +1,138 @@
+package physio
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+// PPGOptions configures AnalyzePPG. Zero values select the defaults.
+type PPGOptions struct {
+	Pulse     PulseOptions
+	Window    float64 // 맥박수, 진폭 창 (초, 기본 10)
+	PVIWindow float64 // PVI 창 (초, 기본 30, 호흡 주기 여러 개)
+	Step      float64 // 창 간격 (초, 기본 5)
+}
+
+func (o *PPGOptions) defaults() {
+	if o.Window <= 0 {
+		o.Window = 10
+	}
+	if o.PVIWindow <= 0 {
+		o.PVIWindow = 30
+	}
+	if o.Step <= 0 {
+		o.Step = 5
+	}
+}
+
+// PlethBeat is one pulse of a pleth waveform, from its foot to the next
+// one.
+type PlethBeat struct {
+	Time      float64 `json:"time"`      // foot
+	PeakTime  float64 `json:"peak_time"` // 피크
+	Amplitude float64 `json:"amplitude"` // 피크 - foot
+	Rate      float64 `json:"rate"`      // 다음 foot까지의 간격으로 계산한 맥박수 (/min)
+	Segment   int     `json:"segment"`
+}
+
+// PlethBeats detects the pulses of a pleth WAVE track with DetectPulses.
+// As in ArterialBeats the last pulse of a segment is not a beat, and beats
+// with a foot-to-foot interval outside 0.2-3 s or a non-positive amplitude
+// are dropped.
+func PlethBeats(trk *vital.Track, opts PulseOptions) ([]PlethBeat, error) {
+	var beats []PlethBeat
+	segment := 0
+	err := trk.WaveSegments(func(seg vital.WaveSegment) error {
+		pulses, err := DetectPulses(seg.Values, seg.SRate, opts)
+		if err != nil {
+			return err
+		}
+		for k := 0; k+1 < len(pulses); k++ {
+			p := pulses[k]
+			interval := float64(pulses[k+1].Onset-p.Onset) / seg.SRate
+			amp := seg.Values[p.Peak] - seg.Values[p.Onset]
+			if interval < minRR || interval > maxRR || amp <= 0 {
+				continue
+			}
+			beats = append(beats, PlethBeat{Time: seg.Time(p.Onset), PeakTime: seg.Time(p.Peak),
+				Amplitude: amp, Rate: 60 / interval, Segment: segment})
+		}
+		segment++
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("track %q: %w", trk.Name, err)
+	}
+	return beats, nil
+}
+
+// PVI returns the pleth variability index (%) of pulse amplitudes over
+// sliding windows: 100 (Amax - Amin) / Amax, valued at the end of each
+// window. Windows with fewer than 10 beats are skipped.
+func PVI(beats []PlethBeat, window, step float64) Series {
+	var amp Series
+	for _, b := range beats {
+		amp.Add(b.Time, b.Amplitude)
+	}
+	return amp.Windowed(window, step, 10, func(vals []float64) float64 {
+		lo, hi := vals[0], vals[0]
+		for _, v := range vals {
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+		return 100 * (hi - lo) / hi
+	})
+}
+
+// PPGResult summarizes AnalyzePPG.
+type PPGResult struct {
+	Track     string   `json:"track"`
+	Beats     int      `json:"beats"`
+	Rate      float64  `json:"rate"`      // 박동별 맥박수의 중앙값
+	Amplitude float64  `json:"amplitude"` // 박동별 진폭의 중앙값
+	PVI       float64  `json:"pvi"`       // 창별 PVI의 중앙값
+	Tracks    []string `json:"tracks"`    // 추가한 트랙
+}
+
+// AnalyzePPG detects the pulses of the pleth WAVE track trackName and adds
+// windowed NUMERIC tracks to vf: "<name>_PR" with the median pulse rate
+// (/min) and "<name>_AMP" with the median pulse amplitude (in the track's
+// unit, a perfusion measure) over opts.Window, and "<name>_PVI" (%) over
+// opts.PVIWindow. Windows with fewer than three beats are skipped.
+func AnalyzePPG(vf *vital.VitalFile, trackName string, opts PPGOptions) (*PPGResult, error) {
+	opts.defaults()
+	trk, ok := vf.Trks[trackName]
+	if !ok {
+		return nil, fmt.Errorf("track %q not found", trackName)
+	}
+	beats, err := PlethBeats(trk, opts.Pulse)
+	if err != nil {
+		return nil, err
+	}
+	var rate, amp Series
+	for _, b := range beats {
+		rate.Add(b.Time, b.Rate)
+		amp.Add(b.Time, b.Amplitude)
+	}
+	pr := rate.Windowed(opts.Window, opts.Step, 3, median)
+	pa := amp.Windowed(opts.Window, opts.Step, 3, median)
+	pvi := PVI(beats, opts.PVIWindow, opts.Step)
+
+	res := &PPGResult{Track: trackName, Beats: len(beats), Rate: median(rate.Values), Amplitude: median(amp.Values),
+		PVI: median(pvi.Values), Tracks: []string{}}
+	base := shortName(trk)
+	for _, out := range []struct {
+		suffix, unit string
+		s            Series
+	}{
+		{"PR", "/min", pr}, {"AMP", trk.Unit, pa}, {"PVI", "%", pvi},
+	} {
+		t := out.s.Track(trk, base+"_"+out.suffix, out.unit)
+		if err := vf.AddTrack(t); err != nil {
+			return nil, err
+		}
+		res.Tracks = append(res.Tracks, t.Name)
+	}
+	return res, nil
+}
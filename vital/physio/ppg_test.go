@@ -0,0 +1,78 @@
+package physio
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// amplitude is the synthetic pleth pulse amplitude at t seconds: 1 varying
+// by ±0.15 with a 4 s breathing cycle (PVI 100·0.3/1.15).
+func amplitude(t float64) float64 {
+	return 1 + 0.15*math.Sin(2*math.Pi*t/4)
+}
+
+// pleth returns a synthetic pleth with a baseline of 2, rising over 0.2 s
+// from each beat and decaying until the next.
+func pleth() func(t float64) float64 {
+	var beats []float64
+	for _, seg := range beatTimes() {
+		beats = append(beats, seg...)
+	}
+	rng := rand.New(rand.NewSource(3))
+	return func(t float64) float64 {
+		v := 2 + 0.005*rng.NormFloat64()
+		for _, c := range beats {
+			if phase := t - c; phase >= 0 && phase < rr(c) {
+				if phase < 0.2 {
+					v += amplitude(c) * (1 - math.Cos(math.Pi*phase/0.2)) / 2
+				} else {
+					v += amplitude(c) * math.Exp(-(phase-0.2)/0.3)
+				}
+			}
+		}
+		return v
+	}
+}
+
+func TestAnalyzePPG(t *testing.T) {
+	vf := read(t, recording(map[string]func(float64) float64{"PLETH": pleth()}, 0.001))
+	beats, err := PlethBeats(vf.Trks["Bx50/PLETH"], PulseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := beatTimes()
+	if n := len(want[0]) + len(want[1]) - 2; len(beats) != n {
+		t.Errorf("%d beats, want %d", len(beats), n)
+	}
+	for i, b := range beats {
+		c := b.Time - start
+		if i == 0 || b.Segment != beats[i-1].Segment {
+			// 구간 시작은 잡음뿐인 평평한 바닥이라 foot이 모호함
+			continue
+		}
+		if math.Abs(b.PeakTime-b.Time-0.2) > 0.03 {
+			t.Errorf("beat at %.2f s: peak %.3f s after the foot", c, b.PeakTime-b.Time)
+		}
+		if math.Abs(b.Amplitude-amplitude(c)) > 0.05 || math.Abs(b.Rate-60/rr(c)) > 1.5 {
+			t.Errorf("beat at %.2f s: amplitude %.3f, rate %.1f", c, b.Amplitude, b.Rate)
+		}
+	}
+
+	res, err := AnalyzePPG(vf, "Bx50/PLETH", PPGOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.PVI-100*0.3/1.15) > 4 || math.Abs(res.Rate-75) > 3 || math.Abs(res.Amplitude-1) > 0.1 {
+		t.Errorf("result %+v", res)
+	}
+	pr := vf.Trks["Bx50/PLETH_PR"]
+	if pr == nil || pr.Unit != "/min" || len(pr.Recs) == 0 || vf.Trks["Bx50/PLETH_AMP"].Unit != "mV" {
+		t.Fatalf("tracks %v", res.Tracks)
+	}
+	for _, rec := range pr.Recs {
+		if tm := rec.Dt - start; tm > 30+5 && tm < 40 {
+			t.Errorf("pulse rate window at %.1f s inside the gap", tm)
+		}
+	}
+}
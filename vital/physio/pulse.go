@@ -0,0 +1,122 @@
+package physio
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mdsung/vitaldb_processor/vital/dsp"
+)
+
+// Pulse detector defaults.
+const (
+	defaultPulseRefractory = 0.25  // 초 (240 /min)
+	ssfWindow              = 0.128 // slope sum 창 (초)
+	pulseLowpass           = 16    // Hz
+)
+
+// PulseOptions configures DetectPulses. Zero values select the defaults.
+type PulseOptions struct {
+	Refractory float64 // 최소 박동 간격 (초, 기본 0.25)
+}
+
+// Pulse is a beat of a pressure or pleth waveform: the sample indices of
+// its foot (onset) and of its systolic peak.
+type Pulse struct {
+	Onset int
+	Peak  int
+}
+
+// DetectPulses finds the pulses of an arterial pressure or pleth waveform
+// sampled at fs Hz with the slope sum function of Zong et al. (2003): the
+// rising slopes of the 16 Hz low-passed signal are summed over 128 ms and
+// each rise above an adaptive threshold (0.6 of the recent peak slope sum,
+// initially three times its mean over the first 10 s) is a pulse. The
+// onset is the minimum of x just before the rise (the last sample within 1%
+// of the pulse height of it) and the peak the maximum of x up to the next
+// onset (at most 0.5 s later).
+func DetectPulses(x []float64, fs float64, opts PulseOptions) ([]Pulse, error) {
+	if opts.Refractory <= 0 {
+		opts.Refractory = defaultPulseRefractory
+	}
+	if !(fs > 0) {
+		return nil, fmt.Errorf("invalid sample rate %g", fs)
+	}
+	n := len(x)
+	if n < int(fs) {
+		return nil, nil
+	}
+	y := x
+	if fs > 2*pulseLowpass {
+		lp, err := dsp.ButterLowpass(2, pulseLowpass, fs)
+		if err != nil {
+			return nil, err
+		}
+		y = lp.FiltFilt(x)
+	}
+
+	// slope sum: 창 안의 양의 기울기 합
+	w := int(math.Round(ssfWindow * fs))
+	ssf := make([]float64, n)
+	var acc float64
+	for i := 1; i < n; i++ {
+		acc += math.Max(0, y[i]-y[i-1])
+		if i-w >= 1 {
+			acc -= math.Max(0, y[i-w]-y[i-w-1])
+		}
+		ssf[i] = acc
+	}
+	level := 3 * mean(ssf[:min(n, int(10*fs))]) / 0.6
+	refractory := int(opts.Refractory * fs)
+	search := int(0.15 * fs)
+
+	var rises []int // 문턱값을 넘은 시점
+	var onsets []int
+	quiet := int(3 * fs)
+	for i, last := 1, -refractory; i < n; i++ {
+		if i-last > quiet {
+			// 3초 동안 박동이 없으면 (큰 잡음 뒤 등) 문턱값을 낮춤
+			level /= 2
+			last = i - refractory
+		}
+		thr := 0.6 * level
+		if ssf[i] <= thr || ssf[i-1] > thr || i-last < refractory {
+			continue
+		}
+		// 150 ms 안의 slope sum 최대값으로 수준 갱신, 1% 지점까지 거슬러 올라가 onset 후보
+		top := i
+		for j := i; j < min(n, i+search); j++ {
+			if ssf[j] > ssf[top] {
+				top = j
+			}
+		}
+		level = 0.75*level + 0.25*ssf[top]
+		foot := i
+		for foot > max(0, i-search) && ssf[foot-1] > 0.01*ssf[top] {
+			foot--
+		}
+		onsets = append(onsets, argMaxSigned(x, foot-int(0.1*fs), i+1, false))
+		rises = append(rises, i)
+		last = i
+	}
+
+	pulses := make([]Pulse, 0, len(rises))
+	for k, r := range rises {
+		end := r + int(0.5*fs)
+		if k+1 < len(rises) && onsets[k+1] < end {
+			end = onsets[k+1]
+		}
+		if end <= r {
+			end = r + 1
+		}
+		p := Pulse{Onset: onsets[k], Peak: argMaxSigned(x, r, end, true)}
+		// 바닥이 평평하면 상승 직전의 지점을 onset으로
+		floor := x[p.Onset] + 0.01*(x[p.Peak]-x[p.Onset])
+		for i := p.Onset + 1; i < p.Peak; i++ {
+			if x[i] <= floor {
+				p.Onset = i
+			}
+		}
+		pulses = append(pulses, p)
+	}
+	return pulses, nil
+}
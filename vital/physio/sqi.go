@@ -0,0 +1,367 @@
+package physio
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+	"github.com/mdsung/vitaldb_processor/vital/dsp"
+)
+
+// Artifact flags, combined as a bit mask in QualityWindow.Flags and the
+// "<name>_MASK" track.
+const (
+	ArtifactFlat        = 1 << iota // 같은 값이 FlatTime 이상 이어짐
+	ArtifactClip                    // Mindisp/Maxdisp 표시 범위 끝에서 잘림
+	ArtifactSaturation              // 저장 형식의 최소/최대값 (ADC 포화)
+	ArtifactCautery                 // 전기소작기 등 고주파 잡음
+	ArtifactSpike                   // 움직임 등으로 진폭이 갑자기 커짐
+	ArtifactImplausible             // NUMERIC 값이 생리적 범위 밖
+)
+
+var artifactNames = []string{"flat", "clip", "saturation", "cautery", "spike", "implausible"}
+
+// ArtifactNames returns the names of the artifacts set in flags.
+func ArtifactNames(flags int) []string {
+	var names []string
+	for i, name := range artifactNames {
+		if flags&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Limit is the plausible range of a NUMERIC track: values above Low and at
+// most High.
+type Limit struct {
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+// DefaultLimits are the plausible ranges of common NUMERIC tracks, by name
+// without device. A limit applies to tracks of that name and to those
+// ending in "_" and the name (Solar8000/PLETH_HR, ECG_II_HR).
+var DefaultLimits = map[string]Limit{
+	"HR":   {0, 300},
+	"PR":   {0, 300},
+	"SPO2": {0, 100},
+	"SBP":  {0, 300},
+	"DBP":  {0, 300},
+	"MBP":  {0, 300},
+	"MAP":  {0, 300},
+	"BT":   {25, 45},
+	"TEMP": {25, 45},
+}
+
+// SQIOptions configures the signal quality analysis. Zero values select
+// the defaults.
+type SQIOptions struct {
+	Window       float64 // 초, 기본 2
+	FlatTime     float64 // 같은 값이 이 시간 이상 이어지면 flat (초, 기본 1)
+	ClipFraction float64 // 창에서 표시 범위 끝/포화 샘플 비율이 이보다 크면 표시 (기본 0.01)
+	// CauteryFreq is the frequency (Hz) above which power counts as
+	// electrocautery noise (default 40 for ECG and 15 for ART and pleth
+	// tracks, by name; other tracks are not checked).
+	CauteryFreq  float64
+	CauteryRatio float64          // 창의 고주파 파워 비율이 이보다 크면 cautery (기본 0.5)
+	SpikeFactor  float64          // 창의 범위가 구간 창 범위 중앙값의 이 배수보다 크면 spike (기본 3)
+	Limits       map[string]Limit // NUMERIC 범위 (nil = DefaultLimits)
+}
+
+func (o *SQIOptions) defaults() {
+	if o.Window <= 0 {
+		o.Window = 2
+	}
+	if o.FlatTime <= 0 {
+		o.FlatTime = 1
+	}
+	if o.ClipFraction <= 0 {
+		o.ClipFraction = 0.01
+	}
+	if o.CauteryRatio <= 0 {
+		o.CauteryRatio = 0.5
+	}
+	if o.SpikeFactor <= 0 {
+		o.SpikeFactor = 3
+	}
+	if o.Limits == nil {
+		o.Limits = DefaultLimits
+	}
+}
+
+// QualityWindow is the signal quality of a window of a WAVE track, or of a
+// single NUMERIC record (Start == End).
+type QualityWindow struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	SQI   float64 `json:"sqi"`   // 0-1
+	Flags int     `json:"flags"` // Artifact 비트
+}
+
+// ArtifactMask is the quality of a track in time order.
+type ArtifactMask []QualityWindow
+
+// Flagged reports whether t falls in a window (or on a record) with an
+// artifact.
+func (m ArtifactMask) Flagged(t float64) bool {
+	i := sort.Search(len(m), func(i int) bool { return m[i].End >= t })
+	for ; i < len(m) && m[i].Start <= t; i++ {
+		if m[i].Flags != 0 && (t < m[i].End || m[i].Start == m[i].End) {
+			return true
+		}
+	}
+	return false
+}
+
+// PlausibleRange returns the limit of a NUMERIC track from limits (see
+// DefaultLimits) and whether it has one.
+func PlausibleRange(trk *vital.Track, limits map[string]Limit) (Limit, bool) {
+	if trk.Type != 2 {
+		return Limit{}, false
+	}
+	name := strings.ToUpper(shortName(trk))
+	if l, ok := limits[name]; ok {
+		return l, true
+	}
+	if i := strings.LastIndex(name, "_"); i >= 0 {
+		l, ok := limits[name[i+1:]]
+		return l, ok
+	}
+	return Limit{}, false
+}
+
+// NumericQuality checks each value of a NUMERIC track against its
+// plausible range and returns one window per record: SQI 1, or 0 with
+// ArtifactImplausible.
+func NumericQuality(trk *vital.Track, limit Limit) (ArtifactMask, error) {
+	s, err := NumericSeries(trk)
+	if err != nil {
+		return nil, err
+	}
+	mask := make(ArtifactMask, s.Len())
+	for i, t := range s.Times {
+		mask[i] = QualityWindow{Start: t, End: t, SQI: 1}
+		if v := s.Values[i]; !(v > limit.Low && v <= limit.High) {
+			mask[i].SQI, mask[i].Flags = 0, ArtifactImplausible
+		}
+	}
+	return mask, nil
+}
+
+// WaveQuality splits each segment of a WAVE track into windows of
+// opts.Window seconds and flags flatlines, clipping (values held at or
+// beyond the display range Mindisp/Maxdisp for three samples or more),
+// saturation (likewise at the limits of the stored fmt),
+// high-frequency (electrocautery) noise and amplitude spikes. A window's
+// SQI is 0 if it is flagged and otherwise the share of its power below the
+// cautery frequency (1 if that is not checked).
+func WaveQuality(trk *vital.Track, opts SQIOptions) (ArtifactMask, error) {
+	opts.defaults()
+	cautery := opts.CauteryFreq
+	if cautery <= 0 {
+		switch name := strings.ToUpper(shortName(trk)); {
+		case strings.HasPrefix(name, "ECG"):
+			cautery = 40
+		case strings.HasPrefix(name, "ART"), strings.HasPrefix(name, "ABP"),
+			strings.HasPrefix(name, "PLETH"), strings.HasPrefix(name, "PPG"):
+			cautery = 15
+		}
+	}
+	satLo, satHi, saturable := formatLimits(trk)
+	clip := trk.Maxdisp > trk.Mindisp
+
+	var mask ArtifactMask
+	err := trk.WaveSegments(func(seg vital.WaveSegment) error {
+		x := seg.Values
+		n := len(x)
+		// 같은 값이 FlatTime 이상 이어진 샘플(flat)과 3개 이상 이어진 샘플(pinned)
+		flat, pinned := make([]bool, n), make([]bool, n)
+		minRun := max(3, int(opts.FlatTime*seg.SRate))
+		for i := 0; i < n; {
+			j := i + 1
+			for j < n && x[j] == x[i] {
+				j++
+			}
+			for k := i; k < j && j-i >= 3; k++ {
+				pinned[k] = true
+				flat[k] = j-i >= minRun
+			}
+			i = j
+		}
+		var hp dsp.SOS
+		if cautery > 0 && cautery < seg.SRate/2 {
+			var err error
+			if hp, err = dsp.ButterHighpass(4, cautery, seg.SRate); err != nil {
+				return err
+			}
+		}
+
+		w := max(1, int(opts.Window*seg.SRate))
+		first := len(mask)
+		var ranges []float64
+		for from := 0; from < n; from += w {
+			to := min(from+w, n)
+			q := QualityWindow{Start: seg.Time(from), End: seg.Time(to), SQI: 1}
+			lo, hi := x[from], x[from]
+			clipped, saturated := 0, 0
+			for i := from; i < to; i++ {
+				v := x[i]
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+				if flat[i] {
+					q.Flags |= ArtifactFlat
+				}
+				if clip && pinned[i] && (v >= float64(trk.Maxdisp) || v <= float64(trk.Mindisp)) {
+					clipped++
+				}
+				if saturable && pinned[i] && (v <= satLo || v >= satHi) {
+					saturated++
+				}
+			}
+			limit := opts.ClipFraction * float64(to-from)
+			if float64(clipped) > limit {
+				q.Flags |= ArtifactClip
+			}
+			if float64(saturated) > limit {
+				q.Flags |= ArtifactSaturation
+			}
+			if hp != nil {
+				// 창마다 따로 필터링해 이웃 창의 급격한 변화가 새어 들지 않게 함
+				m := mean(x[from:to])
+				var total, high float64
+				for i, v := range hp.FiltFilt(x[from:to]) {
+					total += (x[from+i] - m) * (x[from+i] - m)
+					high += v * v
+				}
+				if total > 0 {
+					q.SQI = math.Max(0, 1-high/total)
+				}
+				if q.SQI < 1-opts.CauteryRatio {
+					q.Flags |= ArtifactCautery
+				}
+			}
+			ranges = append(ranges, hi-lo)
+			mask = append(mask, q)
+		}
+		// 구간의 창 범위 중앙값보다 훨씬 큰 창은 spike
+		if typical := median(ranges); len(ranges) >= 3 && typical > 0 {
+			for i, r := range ranges {
+				if r > opts.SpikeFactor*typical {
+					mask[first+i].Flags |= ArtifactSpike
+				}
+			}
+		}
+		for i := first; i < len(mask); i++ {
+			if mask[i].Flags != 0 {
+				mask[i].SQI = 0
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("track %q: %w", trk.Name, err)
+	}
+	return mask, nil
+}
+
+// formatLimits returns the calibrated values of the smallest and largest
+// raw sample of an integer fmt, within half a gain step.
+func formatLimits(trk *vital.Track) (lo, hi float64, ok bool) {
+	var rawLo, rawHi float64
+	switch trk.Fmt {
+	case 3:
+		rawLo, rawHi = math.MinInt8, math.MaxInt8
+	case 4:
+		rawLo, rawHi = 0, math.MaxUint8
+	case 5:
+		rawLo, rawHi = math.MinInt16, math.MaxInt16
+	case 6:
+		rawLo, rawHi = 0, math.MaxUint16
+	case 7:
+		rawLo, rawHi = math.MinInt32, math.MaxInt32
+	case 8:
+		rawLo, rawHi = 0, math.MaxUint32
+	default:
+		return 0, 0, false
+	}
+	lo, hi = trk.Calibrate(rawLo+0.5), trk.Calibrate(rawHi-0.5)
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	return lo, hi, true
+}
+
+// TrackQuality returns the artifact mask of a WAVE track, or of a NUMERIC
+// track with a plausible range in opts.Limits. It returns nil for other
+// tracks.
+func TrackQuality(trk *vital.Track, opts SQIOptions) (ArtifactMask, error) {
+	opts.defaults()
+	switch trk.Type {
+	case 1:
+		return WaveQuality(trk, opts)
+	case 2:
+		if limit, ok := PlausibleRange(trk, opts.Limits); ok {
+			return NumericQuality(trk, limit)
+		}
+	}
+	return nil, nil
+}
+
+// SQIResult summarizes AnalyzeSQI.
+type SQIResult struct {
+	Track       string         `json:"track"`
+	Windows     int            `json:"windows"` // NUMERIC은 레코드 수
+	Flagged     int            `json:"flagged"`
+	FlaggedTime float64        `json:"flagged_time"` // 초 (WAVE)
+	MeanSQI     float64        `json:"mean_sqi"`
+	Artifacts   map[string]int `json:"artifacts"` // 종류별 표시된 창 수
+	Tracks      []string       `json:"tracks"`    // 추가한 트랙
+}
+
+// AnalyzeSQI computes the artifact mask of trackName (see TrackQuality)
+// and adds a "<name>_MASK" NUMERIC track to vf with the artifact flags of
+// each window (at its start) or NUMERIC record, and for WAVE tracks a
+// "<name>_SQI" track with each window's SQI.
+func AnalyzeSQI(vf *vital.VitalFile, trackName string, opts SQIOptions) (*SQIResult, error) {
+	trk, ok := vf.Trks[trackName]
+	if !ok {
+		return nil, fmt.Errorf("track %q not found", trackName)
+	}
+	mask, err := TrackQuality(trk, opts)
+	if err != nil {
+		return nil, err
+	}
+	if mask == nil {
+		return nil, fmt.Errorf("track %q: no quality checks for this track (WAVE, or NUMERIC with a plausible range)", trackName)
+	}
+
+	res := &SQIResult{Track: trackName, Windows: len(mask), Artifacts: map[string]int{}, Tracks: []string{}}
+	var sqi, flags Series
+	for _, q := range mask {
+		sqi.Add(q.Start, q.SQI)
+		flags.Add(q.Start, float64(q.Flags))
+		if q.Flags != 0 {
+			res.Flagged++
+			res.FlaggedTime += q.End - q.Start
+		}
+		for _, name := range ArtifactNames(q.Flags) {
+			res.Artifacts[name]++
+		}
+	}
+	res.MeanSQI = mean(sqi.Values)
+
+	base := shortName(trk)
+	outputs := []*vital.Track{flags.Track(trk, base+"_MASK", "")}
+	if trk.Type == 1 {
+		outputs = append(outputs, sqi.Track(trk, base+"_SQI", ""))
+	}
+	for _, t := range outputs {
+		if err := vf.AddTrack(t); err != nil {
+			return nil, err
+		}
+		res.Tracks = append(res.Tracks, t.Name)
+	}
+	return res, nil
+}
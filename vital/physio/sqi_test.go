@@ -0,0 +1,77 @@
+package physio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mdsung/vitaldb_processor/vital"
+)
+
+func TestWaveQuality(t *testing.T) {
+	clean := ecg()
+	artifacts := func(t float64) float64 {
+		v := clean(t)
+		switch {
+		case t >= 10 && t < 12:
+			return 0.5
+		case t >= 20 && t < 22:
+			return v + math.Sin(2*math.Pi*100*t)
+		case t >= 44 && t < 46:
+			return 10 * v
+		case t >= 50 && t < 52:
+			return 32.767 // int16 최대값
+		}
+		return v
+	}
+	vf := read(t, recording(map[string]func(float64) float64{"ECG_II": artifacts}, 0.001))
+	mask, err := WaveQuality(vf.Trks["Bx50/ECG_II"], SQIOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mask) != 25 {
+		t.Fatalf("%d windows", len(mask))
+	}
+	want := map[float64]int{10: ArtifactFlat, 20: ArtifactCautery, 44: ArtifactSpike, 50: ArtifactSaturation | ArtifactFlat}
+	for _, q := range mask {
+		w := want[q.Start-start]
+		if q.Flags&w != w || (w == 0) != (q.Flags == 0) {
+			t.Errorf("window at %.0f s: flags %v, want %v", q.Start-start, ArtifactNames(q.Flags), ArtifactNames(w))
+		}
+		if w == 0 && q.SQI < 0.9 || w != 0 && q.SQI != 0 {
+			t.Errorf("window at %.0f s: SQI %.2f", q.Start-start, q.SQI)
+		}
+	}
+	if !mask.Flagged(start+11) || mask.Flagged(start+13) || mask.Flagged(start+35) {
+		t.Error("Flagged")
+	}
+
+	res, err := AnalyzeSQI(vf, "Bx50/ECG_II", SQIOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Flagged != 4 || res.FlaggedTime != 8 || res.Artifacts["flat"] != 2 || len(res.Tracks) != 2 {
+		t.Errorf("result %+v", res)
+	}
+	if m := vf.Trks["Bx50/ECG_II_MASK"]; m == nil || len(m.Recs) != 25 || m.Recs[5].Val != float32(ArtifactFlat) {
+		t.Errorf("mask track %+v", m)
+	}
+}
+
+func TestNumericQuality(t *testing.T) {
+	trk := &vital.Track{Name: "Bx50/PLETH_HR", DName: "Bx50", Type: 2, Fmt: 1, Gain: 1}
+	for i, v := range []float32{80, 0, 81, 350, 82} {
+		trk.Recs = append(trk.Recs, vital.Rec{Dt: start + float64(i), Val: v})
+	}
+	mask, err := TrackQuality(trk, SQIOptions{})
+	if err != nil || len(mask) != 5 {
+		t.Fatalf("%d records, %v", len(mask), err)
+	}
+	if mask.Flagged(start) || !mask.Flagged(start+1) || !mask.Flagged(start+3) || mask.Flagged(start+3.5) {
+		t.Errorf("mask %+v", mask)
+	}
+
+	trk.Name = "Bx50/VENT_MODE"
+	if mask, _ := TrackQuality(trk, SQIOptions{}); mask != nil {
+		t.Error("track without a plausible range was checked")
+	}
+}
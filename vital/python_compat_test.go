@@ -2,16 +2,19 @@ package vital
 
 import (
 	"encoding/json"
+	"fmt"
 	"math"
 	"os"
+	"sort"
+	"strings"
 	"testing"
 )
 
 // GoldenData represents the structure of JSON golden files generated by Python VitalDB
 type GoldenData struct {
-	FileInfo GoldenFileInfo            `json:"file_info"`
-	Devices  map[string]GoldenDevice   `json:"devices"`
-	Tracks   map[string]GoldenTrack    `json:"tracks"`
+	FileInfo GoldenFileInfo          `json:"file_info"`
+	Devices  map[string]GoldenDevice `json:"devices"`
+	Tracks   map[string]GoldenTrack  `json:"tracks"`
 }
 
 type GoldenFileInfo struct {
@@ -28,20 +31,20 @@ type GoldenDevice struct {
 }
 
 type GoldenTrack struct {
-	Name         string              `json:"name"`
-	Type         uint8               `json:"type"`
-	Fmt          uint8               `json:"fmt"`
-	Unit         string              `json:"unit"`
-	SampleRate   float32             `json:"sample_rate"`
-	Gain         float64             `json:"gain"`
-	Offset       float64             `json:"offset"`
-	MinDisplay   float32             `json:"min_display"`
-	MaxDisplay   float32             `json:"max_display"`
-	Color        uint32              `json:"color"`
-	MonitorType  uint8               `json:"monitor_type"`
-	DeviceName   string              `json:"device_name,omitempty"`
-	RecordsCount int                 `json:"records_count"`
-	Records      []GoldenRecord      `json:"records"`
+	Name         string         `json:"name"`
+	Type         uint8          `json:"type"`
+	Fmt          uint8          `json:"fmt"`
+	Unit         string         `json:"unit"`
+	SampleRate   float32        `json:"sample_rate"`
+	Gain         float64        `json:"gain"`
+	Offset       float64        `json:"offset"`
+	MinDisplay   float32        `json:"min_display"`
+	MaxDisplay   float32        `json:"max_display"`
+	Color        uint32         `json:"color"`
+	MonitorType  uint8          `json:"monitor_type"`
+	DeviceName   string         `json:"device_name,omitempty"`
+	RecordsCount int            `json:"records_count"`
+	Records      []GoldenRecord `json:"records"`
 }
 
 type GoldenRecord struct {
@@ -51,353 +54,269 @@ type GoldenRecord struct {
 
 // TestPythonCompatibility tests that Go parser produces identical results to Python VitalDB
 // This is the CRITICAL test that validates the Go implementation against the golden standard.
+// The recorded files are not distributed; see TestGoldenFixtures for the hermetic variant.
 func TestPythonCompatibility(t *testing.T) {
-	testCases := []struct {
-		name       string
-		vitalFile  string
-		goldenFile string
-	}{
-		{
-			name:       "MICUA01_240724_180000",
-			vitalFile:  "../data_sample/MICUA01_240724_180000.vital",
-			goldenFile: "testdata/golden/MICUA01_240724_180000.json",
-		},
-		{
-			name:       "MICUA01_240724_180622",
-			vitalFile:  "../data_sample/MICUA01_240724_180622.vital",
-			goldenFile: "testdata/golden/MICUA01_240724_180622.json",
-		},
-		{
-			name:       "MICUA01_240724_181539",
-			vitalFile:  "../data_sample/MICUA01_240724_181539.vital",
-			goldenFile: "testdata/golden/MICUA01_240724_181539.json",
-		},
-		{
-			name:       "MICUA01_240724_190000",
-			vitalFile:  "../data_sample/MICUA01_240724_190000.vital",
-			goldenFile: "testdata/golden/MICUA01_240724_190000.json",
-		},
-		{
-			name:       "MICUB06_240322_230000",
-			vitalFile:  "../data_sample/MICUB06_240322_230000.vital",
-			goldenFile: "testdata/golden/MICUB06_240322_230000.json",
-		},
-		{
-			name:       "MICUB08_240520_230000",
-			vitalFile:  "../data_sample/MICUB08_240520_230000.vital",
-			goldenFile: "testdata/golden/MICUB08_240520_230000.json",
-		},
+	testCases := []string{
+		"MICUA01_240724_180000",
+		"MICUA01_240724_180622",
+		"MICUA01_240724_181539",
+		"MICUA01_240724_190000",
+		"MICUB06_240322_230000",
+		"MICUB08_240520_230000",
 	}
 
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
+	for _, name := range testCases {
+		t.Run(name, func(t *testing.T) {
+			vitalFile := "../data_sample/" + name + ".vital"
+			goldenFile := "testdata/golden/" + name + ".json"
+
 			// Skip if vital file doesn't exist
-			if _, err := os.Stat(tc.vitalFile); os.IsNotExist(err) {
-				t.Skipf("Test data file not found: %s", tc.vitalFile)
+			if _, err := os.Stat(vitalFile); os.IsNotExist(err) {
+				t.Skipf("Test data file not found: %s", vitalFile)
 			}
 
 			// Skip if golden file doesn't exist
-			if _, err := os.Stat(tc.goldenFile); os.IsNotExist(err) {
-				t.Skipf("Golden file not found: %s (run test/generate_golden_files.py)", tc.goldenFile)
+			if _, err := os.Stat(goldenFile); os.IsNotExist(err) {
+				t.Skipf("Golden file not found: %s (run test/generate_golden_files.py)", goldenFile)
 			}
 
-			// Parse with Go
-			goResult, err := NewVitalFile(tc.vitalFile)
+			goResult, err := NewVitalFile(vitalFile)
 			if err != nil {
 				t.Fatalf("Go parser failed: %v", err)
 			}
-
-			// Load golden file
-			goldenBytes, err := os.ReadFile(tc.goldenFile)
-			if err != nil {
-				t.Fatalf("Failed to read golden file: %v", err)
-			}
-
-			var goldenResult GoldenData
-			if err := json.Unmarshal(goldenBytes, &goldenResult); err != nil {
-				t.Fatalf("Failed to unmarshal golden file: %v", err)
-			}
-
-			// Compare results
-			compareFileInfo(t, goResult, goldenResult.FileInfo)
-			compareDevices(t, goResult, goldenResult.Devices)
-			compareTracks(t, goResult, goldenResult.Tracks)
+			diffGolden(goResult, loadGolden(t, goldenFile), defaultTolerance).report(t)
 		})
 	}
 }
 
-func compareFileInfo(t *testing.T, goResult *VitalFile, golden GoldenFileInfo) {
+// loadGolden reads a golden JSON file.
+func loadGolden(t *testing.T, path string) GoldenData {
 	t.Helper()
+	goldenBytes, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	var golden GoldenData
+	if err := json.Unmarshal(goldenBytes, &golden); err != nil {
+		t.Fatalf("Failed to unmarshal golden file: %v", err)
+	}
+	return golden
+}
 
-	// Allow small floating point differences (1ms tolerance)
-	const timeTolerance = 0.001
+// goldenTolerance sets how far Go results may deviate from a golden before
+// a difference is reported.
+type goldenTolerance struct {
+	Time  float64 // 시간 허용 오차 (초)
+	Value float64 // 값 허용 오차 (1보다 큰 값은 상대 오차)
+}
 
-	if math.Abs(goResult.DtStart-golden.DtStart) > timeTolerance {
-		t.Errorf("DtStart mismatch: got %v, want %v", goResult.DtStart, golden.DtStart)
-	}
+// defaultTolerance allows 1ms on times and float32 rounding on values.
+var defaultTolerance = goldenTolerance{Time: 0.001, Value: 1e-6}
 
-	if math.Abs(goResult.DtEnd-golden.DtEnd) > timeTolerance {
-		t.Errorf("DtEnd mismatch: got %v, want %v", goResult.DtEnd, golden.DtEnd)
-	}
+// maxDiffsPerTrack limits the record differences listed for a single track;
+// the rest are only counted.
+const maxDiffsPerTrack = 10
 
-	if goResult.Dgmt != golden.GMTOffset {
-		t.Errorf("GMTOffset mismatch: got %v, want %v", goResult.Dgmt, golden.GMTOffset)
-	}
+// goldenDiff collects every difference between a parsed file and a golden,
+// grouped by track, so that one run shows the full extent of a regression.
+type goldenDiff struct {
+	file    []string
+	tracks  map[string][]string // 트랙별 차이 목록 (maxDiffsPerTrack까지)
+	dropped map[string]int      // 트랙별 생략된 차이 수
 }
 
-func compareDevices(t *testing.T, goResult *VitalFile, golden map[string]GoldenDevice) {
-	t.Helper()
+func (d *goldenDiff) addFile(format string, args ...any) {
+	d.file = append(d.file, fmt.Sprintf(format, args...))
+}
 
-	if len(goResult.Devs) != len(golden) {
-		t.Errorf("Device count mismatch: got %d, want %d", len(goResult.Devs), len(golden))
+func (d *goldenDiff) addTrack(track, format string, args ...any) {
+	if len(d.tracks[track]) >= maxDiffsPerTrack {
+		d.dropped[track]++
+		return
 	}
+	d.tracks[track] = append(d.tracks[track], fmt.Sprintf(format, args...))
+}
 
-	for devName, goldenDev := range golden {
-		goDev, ok := goResult.Devs[devName]
-		if !ok {
-			t.Errorf("Device %q not found in Go result", devName)
-			continue
-		}
+func (d *goldenDiff) empty() bool {
+	return len(d.file) == 0 && len(d.tracks) == 0
+}
 
-		if goDev.Name != goldenDev.Name {
-			t.Errorf("Device %q name mismatch: got %q, want %q", devName, goDev.Name, goldenDev.Name)
+// report fails t with one error for file-level differences and one per
+// track with differences, in track name order.
+func (d *goldenDiff) report(t *testing.T) {
+	t.Helper()
+	if len(d.file) > 0 {
+		t.Errorf("file differs from golden:\n\t%s", strings.Join(d.file, "\n\t"))
+	}
+	names := make([]string, 0, len(d.tracks))
+	for name := range d.tracks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		msg := strings.Join(d.tracks[name], "\n\t")
+		if n := d.dropped[name]; n > 0 {
+			msg += fmt.Sprintf("\n\t... and %d more", n)
 		}
+		t.Errorf("track %q differs from golden:\n\t%s", name, msg)
 	}
 }
 
-func compareTracks(t *testing.T, goResult *VitalFile, golden map[string]GoldenTrack) {
-	t.Helper()
+// diffGolden compares a parsed file with a golden: file info, devices,
+// track metadata, record counts and the golden's records sample by sample.
+func diffGolden(got *VitalFile, want GoldenData, tol goldenTolerance) *goldenDiff {
+	d := &goldenDiff{tracks: make(map[string][]string), dropped: make(map[string]int)}
 
-	if len(goResult.Trks) != len(golden) {
-		t.Errorf("Track count mismatch: got %d, want %d", len(goResult.Trks), len(golden))
-		// List missing tracks
-		for trackName := range golden {
-			if _, ok := goResult.Trks[trackName]; !ok {
-				t.Errorf("  Missing track in Go result: %q", trackName)
-			}
-		}
-		for trackName := range goResult.Trks {
-			if _, ok := golden[trackName]; !ok {
-				t.Errorf("  Extra track in Go result: %q", trackName)
-			}
-		}
+	if math.Abs(got.DtStart-want.FileInfo.DtStart) > tol.Time {
+		d.addFile("DtStart: got %v, want %v", got.DtStart, want.FileInfo.DtStart)
+	}
+	if math.Abs(got.DtEnd-want.FileInfo.DtEnd) > tol.Time {
+		d.addFile("DtEnd: got %v, want %v", got.DtEnd, want.FileInfo.DtEnd)
+	}
+	if got.Dgmt != want.FileInfo.GMTOffset {
+		d.addFile("GMTOffset: got %v, want %v", got.Dgmt, want.FileInfo.GMTOffset)
 	}
 
-	for trackName, goldenTrack := range golden {
-		goTrack, ok := goResult.Trks[trackName]
+	for devName, goldenDev := range want.Devices {
+		goDev, ok := got.Devs[devName]
 		if !ok {
-			continue // Already reported above
+			d.addFile("device %q missing", devName)
+		} else if goDev.Name != goldenDev.Name {
+			d.addFile("device %q name: got %q, want %q", devName, goDev.Name, goldenDev.Name)
 		}
-
-		// Compare track metadata
-		if goTrack.Name != goldenTrack.Name {
-			t.Errorf("Track %q name mismatch: got %q, want %q", trackName, goTrack.Name, goldenTrack.Name)
-		}
-
-		if goTrack.Type != goldenTrack.Type {
-			t.Errorf("Track %q type mismatch: got %d, want %d", trackName, goTrack.Type, goldenTrack.Type)
+	}
+	for devName := range got.Devs {
+		if _, ok := want.Devices[devName]; !ok {
+			d.addFile("extra device %q", devName)
 		}
+	}
 
-		if goTrack.Fmt != goldenTrack.Fmt {
-			t.Errorf("Track %q fmt mismatch: got %d, want %d", trackName, goTrack.Fmt, goldenTrack.Fmt)
+	for trackName := range got.Trks {
+		if _, ok := want.Tracks[trackName]; !ok {
+			d.addTrack(trackName, "extra track")
 		}
-
-		if goTrack.Unit != goldenTrack.Unit {
-			t.Errorf("Track %q unit mismatch: got %q, want %q", trackName, goTrack.Unit, goldenTrack.Unit)
+	}
+	for trackName, goldenTrack := range want.Tracks {
+		goTrack, ok := got.Trks[trackName]
+		if !ok {
+			d.addTrack(trackName, "missing track")
+			continue
 		}
+		diffTrack(d, trackName, goTrack, goldenTrack, tol)
+	}
+	return d
+}
 
-		if goTrack.SRate != goldenTrack.SampleRate {
-			t.Errorf("Track %q sample rate mismatch: got %v, want %v", trackName, goTrack.SRate, goldenTrack.SampleRate)
+func diffTrack(d *goldenDiff, name string, got *Track, want GoldenTrack, tol goldenTolerance) {
+	meta := []struct {
+		field     string
+		got, want any
+	}{
+		{"name", got.Name, want.Name},
+		{"type", got.Type, want.Type},
+		{"fmt", got.Fmt, want.Fmt},
+		{"unit", got.Unit, want.Unit},
+		{"sample rate", got.SRate, want.SampleRate},
+		{"gain", got.Gain, want.Gain},
+		{"offset", got.Offset, want.Offset},
+		{"record count", got.NumRecs(), want.RecordsCount},
+	}
+	for _, m := range meta {
+		if m.got != m.want {
+			d.addTrack(name, "%s: got %v, want %v", m.field, m.got, m.want)
 		}
+	}
 
-		if goTrack.Gain != goldenTrack.Gain {
-			t.Errorf("Track %q gain mismatch: got %v, want %v", trackName, goTrack.Gain, goldenTrack.Gain)
+	// 골든 파일에는 앞쪽 일부 레코드만 저장되어 있으므로 그 범위만 비교
+	for i, goldenRec := range want.Records {
+		if i >= got.NumRecs() {
+			break
 		}
-
-		if goTrack.Offset != goldenTrack.Offset {
-			t.Errorf("Track %q offset mismatch: got %v, want %v", trackName, goTrack.Offset, goldenTrack.Offset)
+		rec, err := got.Rec(i)
+		if err != nil {
+			d.addTrack(name, "record %d: %v", i, err)
+			return
 		}
-
-		// Compare record count
-		goRecCount := len(goTrack.Recs)
-		if goRecCount != goldenTrack.RecordsCount {
-			t.Errorf("Track %q record count mismatch: got %d, want %d", trackName, goRecCount, goldenTrack.RecordsCount)
+		if math.Abs(rec.Dt-goldenRec.Dt) > tol.Time {
+			d.addTrack(name, "record %d time: got %v, want %v", i, rec.Dt, goldenRec.Dt)
 		}
-
-		// Compare sample records (first 100 only, as golden file has limited records)
-		compareRecords(t, trackName, &goTrack, goldenTrack)
-	}
-}
-
-func compareRecords(t *testing.T, trackName string, goTrack *Track, goldenTrack GoldenTrack) {
-	t.Helper()
-
-	// Only compare records that are in the golden file (first 100)
-	compareCount := len(goldenTrack.Records)
-	if compareCount > len(goTrack.Recs) {
-		compareCount = len(goTrack.Recs)
-	}
-
-	const timeTolerance = 0.001 // 1ms tolerance
-
-	for i := 0; i < compareCount; i++ {
-		goldenRec := goldenTrack.Records[i]
-		goRec := goTrack.Recs[i]
-
-		// Compare timestamp
-		if math.Abs(goRec.Dt-goldenRec.Dt) > timeTolerance {
-			t.Errorf("Track %q record %d timestamp mismatch: got %v, want %v",
-				trackName, i, goRec.Dt, goldenRec.Dt)
-			break // Don't spam too many errors
+		if msg := diffValue(rec.Val, goldenRec.Val, tol); msg != "" {
+			d.addTrack(name, "record %d %s", i, msg)
 		}
-
-		// Compare value (type-dependent)
-		compareRecordValue(t, trackName, i, goRec.Val, goldenRec.Val)
 	}
 }
 
-func compareRecordValue(t *testing.T, trackName string, recIndex int, goVal, goldenVal interface{}) {
-	t.Helper()
-
-	const floatTolerance = 1e-6
-
-	// Handle nil values
-	if goldenVal == nil {
-		if goVal != nil {
-			t.Errorf("Track %q record %d value mismatch: got %v, want nil", trackName, recIndex, goVal)
+// diffValue describes how a record value differs from its golden JSON value,
+// or returns "" if they match within tolerance.
+func diffValue(got, want any, tol goldenTolerance) string {
+	switch w := want.(type) {
+	case nil:
+		if got != nil {
+			return fmt.Sprintf("value: got %v, want nil", got)
 		}
-		return
-	}
-
-	// Handle arrays
-	goldenArr, goldenIsArr := goldenVal.([]interface{})
-	if goldenIsArr {
-		// Go value should be a numeric array
-		switch goArr := goVal.(type) {
-		case []float32:
-			compareFloat32Array(t, trackName, recIndex, goArr, goldenArr)
-		case []float64:
-			compareFloat64Array(t, trackName, recIndex, goArr, goldenArr)
-		case []int8:
-			compareInt8Array(t, trackName, recIndex, goArr, goldenArr)
-		case []uint8:
-			compareUint8Array(t, trackName, recIndex, goArr, goldenArr)
-		case []int16:
-			compareInt16Array(t, trackName, recIndex, goArr, goldenArr)
-		case []uint16:
-			compareUint16Array(t, trackName, recIndex, goArr, goldenArr)
-		case []int32:
-			compareInt32Array(t, trackName, recIndex, goArr, goldenArr)
-		case []uint32:
-			compareUint32Array(t, trackName, recIndex, goArr, goldenArr)
-		default:
-			t.Errorf("Track %q record %d: unexpected Go array type %T", trackName, recIndex, goVal)
+	case string:
+		if s, ok := got.(string); !ok || s != w {
+			return fmt.Sprintf("value: got %#v, want %q", got, w)
 		}
-		return
-	}
-
-	// Handle single numeric values
-	goldenNum, goldenIsNum := goldenVal.(float64)
-	if goldenIsNum {
-		goNum, ok := toFloat64(goVal)
+	case float64:
+		g, ok := toFloat64(got)
 		if !ok {
-			t.Errorf("Track %q record %d: cannot convert Go value %v to float64", trackName, recIndex, goVal)
-			return
+			return fmt.Sprintf("value: got %T, want a number", got)
 		}
-		if math.Abs(goNum-goldenNum) > floatTolerance {
-			t.Errorf("Track %q record %d value mismatch: got %v, want %v", trackName, recIndex, goNum, goldenNum)
+		if !withinTolerance(g, w, tol.Value) {
+			return fmt.Sprintf("value: got %v, want %v", g, w)
 		}
-		return
-	}
-
-	// Handle strings
-	goldenStr, goldenIsStr := goldenVal.(string)
-	if goldenIsStr {
-		goStr, ok := goVal.(string)
+	case []any, []float64:
+		samples, ok := goldenSamples(want)
 		if !ok {
-			t.Errorf("Track %q record %d: expected string, got %T", trackName, recIndex, goVal)
-			return
-		}
-		if goStr != goldenStr {
-			t.Errorf("Track %q record %d string mismatch: got %q, want %q", trackName, recIndex, goStr, goldenStr)
+			return "golden array holds non-numeric values"
 		}
-		return
-	}
-
-	t.Errorf("Track %q record %d: unsupported golden value type %T", trackName, recIndex, goldenVal)
-}
-
-func compareFloat32Array(t *testing.T, trackName string, recIndex int, goArr []float32, goldenArr []interface{}) {
-	t.Helper()
-
-	if len(goArr) != len(goldenArr) {
-		t.Errorf("Track %q record %d array length mismatch: got %d, want %d",
-			trackName, recIndex, len(goArr), len(goldenArr))
-		return
-	}
-
-	const floatTolerance = 1e-4 // Relaxed tolerance for float32
-
-	for i := 0; i < len(goArr) && i < 10; i++ { // Check first 10 elements
-		goldenVal, ok := goldenArr[i].(float64)
+		g, ok := samplesAsFloat64(got)
 		if !ok {
-			t.Errorf("Track %q record %d element %d: cannot convert golden value to float64", trackName, recIndex, i)
-			continue
+			return fmt.Sprintf("value: got %T, want an array", got)
+		}
+		if len(g) != len(samples) {
+			return fmt.Sprintf("array length: got %d, want %d", len(g), len(samples))
+		}
+		first, mismatches := -1, 0
+		for i := range samples {
+			if !withinTolerance(g[i], samples[i], tol.Value) {
+				if first < 0 {
+					first = i
+				}
+				mismatches++
+			}
 		}
-		if math.Abs(float64(goArr[i])-goldenVal) > floatTolerance {
-			t.Errorf("Track %q record %d element %d mismatch: got %v, want %v",
-				trackName, recIndex, i, goArr[i], goldenVal)
-			break
+		if mismatches > 0 {
+			return fmt.Sprintf("%d of %d samples differ, first at %d: got %v, want %v",
+				mismatches, len(samples), first, g[first], samples[first])
 		}
+	default:
+		return fmt.Sprintf("unsupported golden value type %T", want)
 	}
+	return ""
 }
 
-func compareFloat64Array(t *testing.T, trackName string, recIndex int, goArr []float64, goldenArr []interface{}) {
-	t.Helper()
-
-	if len(goArr) != len(goldenArr) {
-		t.Errorf("Track %q record %d array length mismatch: got %d, want %d",
-			trackName, recIndex, len(goArr), len(goldenArr))
-		return
+// goldenSamples returns a golden WAVE value, decoded from JSON ([]any) or
+// built in memory ([]float64), as float64 samples.
+func goldenSamples(want any) ([]float64, bool) {
+	if w, ok := want.([]float64); ok {
+		return w, true
 	}
-
-	const floatTolerance = 1e-6
-
-	for i := 0; i < len(goArr) && i < 10; i++ { // Check first 10 elements
-		goldenVal, ok := goldenArr[i].(float64)
+	arr := want.([]any)
+	out := make([]float64, len(arr))
+	for i, v := range arr {
+		f, ok := v.(float64)
 		if !ok {
-			t.Errorf("Track %q record %d element %d: cannot convert golden value to float64", trackName, recIndex, i)
-			continue
-		}
-		if math.Abs(goArr[i]-goldenVal) > floatTolerance {
-			t.Errorf("Track %q record %d element %d mismatch: got %v, want %v",
-				trackName, recIndex, i, goArr[i], goldenVal)
-			break
+			return nil, false
 		}
+		out[i] = f
 	}
+	return out, true
 }
 
-func compareInt16Array(t *testing.T, trackName string, recIndex int, goArr []int16, goldenArr []interface{}) {
-	t.Helper()
-
-	if len(goArr) != len(goldenArr) {
-		t.Errorf("Track %q record %d array length mismatch: got %d, want %d",
-			trackName, recIndex, len(goArr), len(goldenArr))
-		return
-	}
-
-	for i := 0; i < len(goArr) && i < 10; i++ { // Check first 10 elements
-		goldenVal, ok := goldenArr[i].(float64)
-		if !ok {
-			t.Errorf("Track %q record %d element %d: cannot convert golden value to number", trackName, recIndex, i)
-			continue
-		}
-		if int16(goldenVal) != goArr[i] {
-			t.Errorf("Track %q record %d element %d mismatch: got %v, want %v",
-				trackName, recIndex, i, goArr[i], int16(goldenVal))
-			break
-		}
-	}
+func withinTolerance(got, want, tol float64) bool {
+	return math.Abs(got-want) <= tol*math.Max(1, math.Abs(want))
 }
 
 func toFloat64(val interface{}) (float64, bool) {
@@ -408,6 +327,10 @@ func toFloat64(val interface{}) (float64, bool) {
 		return float64(v), true
 	case int:
 		return float64(v), true
+	case int8:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
 	case int16:
 		return float64(v), true
 	case int32:
@@ -427,117 +350,42 @@ func toFloat64(val interface{}) (float64, bool) {
 	}
 }
 
-func compareInt8Array(t *testing.T, trackName string, recIndex int, goArr []int8, goldenArr []interface{}) {
-	t.Helper()
-
-	if len(goArr) != len(goldenArr) {
-		t.Errorf("Track %q record %d array length mismatch: got %d, want %d",
-			trackName, recIndex, len(goArr), len(goldenArr))
-		return
-	}
-
-	for i := 0; i < len(goArr) && i < 10; i++ {
-		goldenVal, ok := goldenArr[i].(float64)
-		if !ok {
-			t.Errorf("Track %q record %d element %d: cannot convert golden value to number", trackName, recIndex, i)
-			continue
-		}
-		if int8(goldenVal) != goArr[i] {
-			t.Errorf("Track %q record %d element %d mismatch: got %v, want %v",
-				trackName, recIndex, i, goArr[i], int8(goldenVal))
-			break
-		}
-	}
-}
-
-func compareUint8Array(t *testing.T, trackName string, recIndex int, goArr []uint8, goldenArr []interface{}) {
-	t.Helper()
-
-	if len(goArr) != len(goldenArr) {
-		t.Errorf("Track %q record %d array length mismatch: got %d, want %d",
-			trackName, recIndex, len(goArr), len(goldenArr))
-		return
-	}
-
-	for i := 0; i < len(goArr) && i < 10; i++ {
-		goldenVal, ok := goldenArr[i].(float64)
-		if !ok {
-			t.Errorf("Track %q record %d element %d: cannot convert golden value to number", trackName, recIndex, i)
-			continue
+// samplesAsFloat64 widens a WAVE record value to float64.
+func samplesAsFloat64(val any) ([]float64, bool) {
+	out := make([]float64, 0, sampleCount(val))
+	switch v := val.(type) {
+	case []float32:
+		for _, x := range v {
+			out = append(out, float64(x))
 		}
-		if uint8(goldenVal) != goArr[i] {
-			t.Errorf("Track %q record %d element %d mismatch: got %v, want %v",
-				trackName, recIndex, i, goArr[i], uint8(goldenVal))
-			break
+	case []float64:
+		out = append(out, v...)
+	case []int8:
+		for _, x := range v {
+			out = append(out, float64(x))
 		}
-	}
-}
-
-func compareUint16Array(t *testing.T, trackName string, recIndex int, goArr []uint16, goldenArr []interface{}) {
-	t.Helper()
-
-	if len(goArr) != len(goldenArr) {
-		t.Errorf("Track %q record %d array length mismatch: got %d, want %d",
-			trackName, recIndex, len(goArr), len(goldenArr))
-		return
-	}
-
-	for i := 0; i < len(goArr) && i < 10; i++ {
-		goldenVal, ok := goldenArr[i].(float64)
-		if !ok {
-			t.Errorf("Track %q record %d element %d: cannot convert golden value to number", trackName, recIndex, i)
-			continue
-		}
-		if uint16(goldenVal) != goArr[i] {
-			t.Errorf("Track %q record %d element %d mismatch: got %v, want %v",
-				trackName, recIndex, i, goArr[i], uint16(goldenVal))
-			break
+	case []uint8:
+		for _, x := range v {
+			out = append(out, float64(x))
 		}
-	}
-}
-
-func compareInt32Array(t *testing.T, trackName string, recIndex int, goArr []int32, goldenArr []interface{}) {
-	t.Helper()
-
-	if len(goArr) != len(goldenArr) {
-		t.Errorf("Track %q record %d array length mismatch: got %d, want %d",
-			trackName, recIndex, len(goArr), len(goldenArr))
-		return
-	}
-
-	for i := 0; i < len(goArr) && i < 10; i++ {
-		goldenVal, ok := goldenArr[i].(float64)
-		if !ok {
-			t.Errorf("Track %q record %d element %d: cannot convert golden value to number", trackName, recIndex, i)
-			continue
+	case []int16:
+		for _, x := range v {
+			out = append(out, float64(x))
 		}
-		if int32(goldenVal) != goArr[i] {
-			t.Errorf("Track %q record %d element %d mismatch: got %v, want %v",
-				trackName, recIndex, i, goArr[i], int32(goldenVal))
-			break
+	case []uint16:
+		for _, x := range v {
+			out = append(out, float64(x))
 		}
-	}
-}
-
-func compareUint32Array(t *testing.T, trackName string, recIndex int, goArr []uint32, goldenArr []interface{}) {
-	t.Helper()
-
-	if len(goArr) != len(goldenArr) {
-		t.Errorf("Track %q record %d array length mismatch: got %d, want %d",
-			trackName, recIndex, len(goArr), len(goldenArr))
-		return
-	}
-
-	for i := 0; i < len(goArr) && i < 10; i++ {
-		goldenVal, ok := goldenArr[i].(float64)
-		if !ok {
-			t.Errorf("Track %q record %d element %d: cannot convert golden value to number", trackName, recIndex, i)
-			continue
+	case []int32:
+		for _, x := range v {
+			out = append(out, float64(x))
 		}
-		if uint32(goldenVal) != goArr[i] {
-			t.Errorf("Track %q record %d element %d mismatch: got %v, want %v",
-				trackName, recIndex, i, goArr[i], uint32(goldenVal))
-			break
+	case []uint32:
+		for _, x := range v {
+			out = append(out, float64(x))
 		}
+	default:
+		return nil, false
 	}
+	return out, true
 }
@@ -35,168 +35,84 @@ func parseRec(pkt []byte, vf *VitalFile) {
 	}
 
 	// tid를 사용하여 트랙 찾기 (Python의 tid_dtnames와 동일)
-	trackName, exists := vf.TrkIDs[trkid]
-	if !exists {
+	track := vf.trackByID(trkid)
+	if track == nil {
 		return
 	}
 
-	track, exists := vf.Trks[trackName]
-	if !exists {
-		return
-	}
-
-	// 데이터 타입별 파싱
+	// 데이터 타입별 파싱 - 트랙은 포인터로 저장되어 있어 복사 없이 갱신됨
 	switch track.Type {
 	case 1: // WAVE 타입
-		parseWaveData(pkt, pos, dt, trkid, &track, vf)
+		parseWaveData(pkt, pos, dt, track, vf)
 	case 2: // NUMERIC 타입
-		parseNumericData(pkt, pos, dt, &track)
+		parseNumericData(pkt, pos, dt, track)
 	case 5: // STRING 타입
-		parseStringData(pkt, pos, dt, &track)
+		parseStringData(pkt, pos, dt, track)
+	}
+}
+
+// trackByID resolves a track ID from a REC packet to its track.
+func (vf *VitalFile) trackByID(tid uint16) *Track {
+	if trk, ok := vf.tids[tid]; ok {
+		return trk
+	}
+	// tids는 parseTrkInfo가 채우므로, 직접 구성된 VitalFile은 이름으로 조회
+	name, ok := vf.TrkIDs[tid]
+	if !ok {
+		return nil
 	}
+	return vf.Trks[name]
+}
 
-	// 업데이트된 트랙을 다시 저장
-	vf.Trks[trackName] = track
+// sampleSize returns the byte width of a sample in the given fmt code, or 0
+// if the code is unknown.
+func sampleSize(fmtcode uint8) int {
+	switch fmtcode {
+	case 1: // float32
+		return 4
+	case 2: // float64
+		return 8
+	case 3, 4: // int8, uint8
+		return 1
+	case 5, 6: // int16, uint16
+		return 2
+	case 7, 8: // int32, uint32
+		return 4
+	default:
+		return 0
+	}
 }
 
 // parseWaveData parses WAVE type data from REC packet
-func parseWaveData(pkt []byte, pos int, dt float64, trkid uint16, track *Track, vf *VitalFile) {
+func parseWaveData(pkt []byte, pos int, dt float64, track *Track, vf *VitalFile) {
 	if pos+4 > len(pkt) {
 		return
 	}
-	nsamples := binary.LittleEndian.Uint32(pkt[pos : pos+4])
+	count := binary.LittleEndian.Uint32(pkt[pos : pos+4])
 	pos += 4
 
 	// 포맷별 샘플 크기 계산
-	var sampleSize int
-	switch track.Fmt {
-	case 1: // float32
-		sampleSize = 4
-	case 2: // float64
-		sampleSize = 8
-	case 3: // int8
-		sampleSize = 1
-	case 4: // uint8
-		sampleSize = 1
-	case 5: // int16
-		sampleSize = 2
-	case 6: // uint16
-		sampleSize = 2
-	case 7: // int32
-		sampleSize = 4
-	case 8: // uint32
-		sampleSize = 4
-	default:
+	size := sampleSize(track.Fmt)
+	if size == 0 {
 		return
 	}
-
-	totalBytes := int(nsamples) * sampleSize
-	if pos+totalBytes > len(pkt) {
+	// 샘플 수가 패킷에 남은 바이트를 넘으면 무시 (손상된 nsamples로 인한 대용량 할당 방지)
+	if uint64(count) > uint64((len(pkt)-pos)/size) {
 		return
 	}
+	nsamples := int(count)
+	data := pkt[pos : pos+nsamples*size]
 
-	// fmt에 따라 적절한 타입의 샘플 배열 생성
-	var samples any
-
-	switch track.Fmt {
-	case 1: // float32 - 원본 타입 유지
-		float32Samples := make([]float32, nsamples)
-		for i := 0; i < int(nsamples); i++ {
-			samplePos := pos + i*sampleSize
-			if samplePos+sampleSize > len(pkt) {
-				break
-			}
-			sample := bytesToFloat32(pkt[samplePos : samplePos+4])
-			float32Samples[i] = sample
-		}
-		samples = float32Samples
-
-	case 2: // float64 - 원본 타입 유지
-		float64Samples := make([]float64, nsamples)
-		for i := 0; i < int(nsamples); i++ {
-			samplePos := pos + i*sampleSize
-			if samplePos+sampleSize > len(pkt) {
-				break
-			}
-			sample := bytesToFloat64(pkt[samplePos : samplePos+8])
-			float64Samples[i] = sample
-		}
-		samples = float64Samples
-
-	case 5: // int16 - Python VitalDB와 호환성을 위해 원본 타입 유지
-		int16Samples := make([]int16, nsamples)
-		for i := 0; i < int(nsamples); i++ {
-			samplePos := pos + i*sampleSize
-			if samplePos+sampleSize > len(pkt) {
-				break
-			}
-			int16Samples[i] = int16(binary.LittleEndian.Uint16(pkt[samplePos : samplePos+2]))
-		}
-		samples = int16Samples
-
-	default: // 기타 정수 타입들 - Python VitalDB 호환성을 위해 원본 타입 유지
-		// Python VitalDB는 gain/offset을 적용하지 않고 raw 값을 저장함
-		// 사용자가 필요시 track.Gain과 track.Offset을 사용하여 변환 가능
-		switch track.Fmt {
-		case 3: // int8
-			int8Samples := make([]int8, nsamples)
-			for i := 0; i < int(nsamples); i++ {
-				samplePos := pos + i*sampleSize
-				if samplePos+sampleSize > len(pkt) {
-					break
-				}
-				int8Samples[i] = int8(pkt[samplePos])
-			}
-			samples = int8Samples
-
-		case 4: // uint8
-			uint8Samples := make([]uint8, nsamples)
-			for i := 0; i < int(nsamples); i++ {
-				samplePos := pos + i*sampleSize
-				if samplePos+sampleSize > len(pkt) {
-					break
-				}
-				uint8Samples[i] = pkt[samplePos]
-			}
-			samples = uint8Samples
-
-		case 6: // uint16
-			uint16Samples := make([]uint16, nsamples)
-			for i := 0; i < int(nsamples); i++ {
-				samplePos := pos + i*sampleSize
-				if samplePos+sampleSize > len(pkt) {
-					break
-				}
-				uint16Samples[i] = binary.LittleEndian.Uint16(pkt[samplePos : samplePos+2])
-			}
-			samples = uint16Samples
-
-		case 7: // int32
-			int32Samples := make([]int32, nsamples)
-			for i := 0; i < int(nsamples); i++ {
-				samplePos := pos + i*sampleSize
-				if samplePos+sampleSize > len(pkt) {
-					break
-				}
-				int32Samples[i] = int32(binary.LittleEndian.Uint32(pkt[samplePos : samplePos+4]))
-			}
-			samples = int32Samples
-
-		case 8: // uint32
-			uint32Samples := make([]uint32, nsamples)
-			for i := 0; i < int(nsamples); i++ {
-				samplePos := pos + i*sampleSize
-				if samplePos+sampleSize > len(pkt) {
-					break
-				}
-				uint32Samples[i] = binary.LittleEndian.Uint32(pkt[samplePos : samplePos+4])
-			}
-			samples = uint32Samples
+	if track.spill != nil {
+		// 디스크로 내보낸 트랙은 디코딩 없이 원본 바이트를 그대로 기록
+		vf.spiller.write(track, dt, data)
+	} else {
+		track.Recs = append(track.Recs, Rec{Dt: dt, Val: decodeSamples(track.Fmt, data, nsamples)})
+		if vf.spiller != nil {
+			vf.spiller.add(track, int64(len(data)))
 		}
 	}
 
-	track.Recs = append(track.Recs, Rec{Dt: dt, Val: samples})
-
 	// 웨이브 타입의 경우 샘플률을 고려하여 dtend 업데이트 (Python과 동일)
 	if track.SRate > 0 {
 		recDtend := dt + float64(nsamples)/float64(track.SRate)
@@ -272,9 +188,65 @@ func parseStringData(pkt []byte, pos int, dt float64, track *Track) {
 	}
 	strlen := binary.LittleEndian.Uint32(pkt[pos : pos+4])
 	pos += 4
-	if pos+int(strlen) > len(pkt) {
+	if uint64(strlen) > uint64(len(pkt)-pos) {
 		return
 	}
 	val := string(pkt[pos : pos+int(strlen)])
 	track.Recs = append(track.Recs, Rec{Dt: dt, Val: val})
 }
+
+// decodeSamples converts n little-endian samples of the given fmt code into
+// a slice of the matching Go type.
+func decodeSamples(fmtcode uint8, data []byte, n int) any {
+	// Python VitalDB와 호환성을 위해 원본 타입 유지 (gain/offset 미적용)
+	// 사용자가 필요시 track.Gain과 track.Offset을 사용하여 변환 가능
+	switch fmtcode {
+	case 1: // float32
+		v := make([]float32, n)
+		for i := range v {
+			v[i] = bytesToFloat32(data[i*4:])
+		}
+		return v
+	case 2: // float64
+		v := make([]float64, n)
+		for i := range v {
+			v[i] = bytesToFloat64(data[i*8:])
+		}
+		return v
+	case 3: // int8
+		v := make([]int8, n)
+		for i := range v {
+			v[i] = int8(data[i])
+		}
+		return v
+	case 4: // uint8
+		v := make([]uint8, n)
+		copy(v, data)
+		return v
+	case 5: // int16
+		v := make([]int16, n)
+		for i := range v {
+			v[i] = int16(binary.LittleEndian.Uint16(data[i*2:]))
+		}
+		return v
+	case 6: // uint16
+		v := make([]uint16, n)
+		for i := range v {
+			v[i] = binary.LittleEndian.Uint16(data[i*2:])
+		}
+		return v
+	case 7: // int32
+		v := make([]int32, n)
+		for i := range v {
+			v[i] = int32(binary.LittleEndian.Uint32(data[i*4:]))
+		}
+		return v
+	case 8: // uint32
+		v := make([]uint32, n)
+		for i := range v {
+			v[i] = binary.LittleEndian.Uint32(data[i*4:])
+		}
+		return v
+	}
+	return nil
+}
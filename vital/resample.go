@@ -0,0 +1,313 @@
+package vital
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/mdsung/vitaldb_processor/vital/dsp"
+)
+
+// Resampling filters.
+const (
+	FilterSinc   = "sinc"   // Kaiser 창을 씌운 sinc 저역통과 (polyphase)
+	FilterLinear = "linear" // 선형 보간, 앨리어싱 방지 없음
+)
+
+// Resampling defaults.
+const (
+	defaultResampleTaps   = 16
+	defaultResampleCutoff = 0.9
+	maxResampleFactor     = 1000 // 정수가 아닌 비율을 근사할 때 분모 한도
+)
+
+// ResampleOptions configures Track.Resample. Zero values select the
+// defaults.
+type ResampleOptions struct {
+	Rate   float64 // 목표 샘플링 레이트 (Hz)
+	Filter string  // FilterSinc (기본) 또는 FilterLinear
+	// Taps is the number of zero crossings of the sinc filter on each side
+	// (default 16); longer filters have a sharper transition band.
+	Taps int
+	// Cutoff is the passband edge as a fraction of the lower of the two
+	// Nyquist frequencies (default 0.9).
+	Cutoff float64
+	// Beta is the Kaiser window parameter (default dsp.DefaultKaiserBeta);
+	// larger values trade transition width for stopband attenuation.
+	Beta float64
+	// Name is the name of the track added by VitalFile.AddResampled
+	// (default: the track's name with a "_<Rate>Hz" suffix).
+	Name string
+}
+
+// Resample returns a copy of a WAVE track at opts.Rate. The ratio of the
+// rates is taken as L/M in lowest terms (approximated with M up to 1000 for
+// non-integer rates, in which case SRate of the copy is the exact rate
+// used). Each contiguous segment (see Segments) is resampled on its own,
+// extending its edge samples, so gaps are preserved; within a segment the
+// output chunks follow the input chunks, timed from the segment's start.
+//
+// Values are filtered raw and stored in the track's fmt, rounded and
+// clamped for integer formats, so Gain and Offset still apply. The copy
+// holds its records in memory.
+func (t *Track) Resample(opts ResampleOptions) (*Track, error) {
+	if t.Type != 1 || t.SRate <= 0 {
+		return nil, fmt.Errorf("track %q: not a WAVE track", t.Name)
+	}
+	if !(opts.Rate > 0) {
+		return nil, fmt.Errorf("track %q: invalid target rate %g", t.Name, opts.Rate)
+	}
+	r, err := newResampler(float64(t.SRate), opts)
+	if err != nil {
+		return nil, fmt.Errorf("track %q: %w", t.Name, err)
+	}
+
+	out := t.copyMeta()
+	out.SRate = float32(r.outRate)
+	var (
+		sb    = newSegmenter(t, SegmentOptions{})
+		start float64
+		bound []int // 세그먼트 안 청크 시작 위치 (입력 샘플)
+		xs    []float64
+	)
+	flush := func() {
+		if len(xs) == 0 {
+			return
+		}
+		ys := r.apply(xs)
+		bound = append(bound, len(xs))
+		for i := 0; i+1 < len(bound); i++ {
+			from, to := r.outIndex(bound[i]), r.outIndex(bound[i+1])
+			if to > len(ys) {
+				to = len(ys)
+			}
+			if from >= to {
+				continue
+			}
+			vals := append([]float64(nil), ys[from:to]...)
+			out.Recs = append(out.Recs, Rec{Dt: start + float64(from)/r.outRate, Val: storeSamples(t.Fmt, vals)})
+		}
+		xs, bound = xs[:0], bound[:0]
+	}
+	err = t.ForEachRec(func(rec Rec) error {
+		vals, ok := samplesFloat64(rec.Val)
+		if !ok || len(vals) == 0 {
+			return nil
+		}
+		n := len(sb.segs)
+		sb.add(rec.Dt, t.recEnd(&rec))
+		if len(sb.segs) != n {
+			flush()
+			start = rec.Dt
+		}
+		bound = append(bound, len(xs))
+		xs = append(xs, vals...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("track %q: %w", t.Name, err)
+	}
+	flush()
+	return out, nil
+}
+
+// Decimate returns a copy of a WAVE track at SRate/factor, low-pass
+// filtered as configured by opts (whose Rate is ignored).
+func (t *Track) Decimate(factor int, opts ResampleOptions) (*Track, error) {
+	if factor < 1 {
+		return nil, fmt.Errorf("track %q: invalid decimation factor %d", t.Name, factor)
+	}
+	opts.Rate = float64(t.SRate) / float64(factor)
+	return t.Resample(opts)
+}
+
+// AddResampled resamples the track trackName and adds the result to vf as a
+// new track of the same device, named opts.Name or "<name>_<rate>Hz".
+func (vf *VitalFile) AddResampled(trackName string, opts ResampleOptions) (*Track, error) {
+	trk, ok := vf.Trks[trackName]
+	if !ok {
+		return nil, fmt.Errorf("track %q not found", trackName)
+	}
+	out, err := trk.Resample(opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Name == "" {
+		opts.Name = trackName + "_" + strconv.FormatFloat(opts.Rate, 'f', -1, 64) + "Hz"
+	} else if trk.DName != "" {
+		opts.Name = trk.DName + "/" + opts.Name
+	}
+	out.Name = opts.Name
+	if err := vf.AddTrack(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// resampler converts a block of samples by the rational factor up/down.
+type resampler struct {
+	up, down int
+	outRate  float64
+	linear   bool
+	phases   []phase // 출력 위치의 위상 (k*down mod up)마다 탭
+}
+
+// phase holds the filter taps applied to input samples n0-first,
+// n0-first-1, ... for one output phase.
+type phase struct {
+	first int
+	taps  []float64
+}
+
+func newResampler(inRate float64, opts ResampleOptions) (*resampler, error) {
+	up, down, err := rateRatio(inRate, opts.Rate)
+	if err != nil {
+		return nil, err
+	}
+	r := &resampler{up: up, down: down, outRate: inRate * float64(up) / float64(down)}
+	switch opts.Filter {
+	case "", FilterSinc:
+	case FilterLinear:
+		r.linear = true
+		return r, nil
+	default:
+		return nil, fmt.Errorf("unknown resampling filter %q", opts.Filter)
+	}
+
+	taps, cutoff, beta := opts.Taps, opts.Cutoff, opts.Beta
+	if taps <= 0 {
+		taps = defaultResampleTaps
+	}
+	if cutoff <= 0 || cutoff > 1 {
+		cutoff = defaultResampleCutoff
+	}
+	if beta <= 0 {
+		beta = dsp.DefaultKaiserBeta
+	}
+	// 필터는 up배 올린 샘플 간격 기준, 차단 주파수는 낮은 쪽 나이퀴스트의 cutoff배
+	wider := up
+	if down > wider {
+		wider = down
+	}
+	half := taps * wider
+	h := dsp.WindowedSinc(0.5*cutoff/float64(wider), half, beta)
+	r.phases = make([]phase, up)
+	for p := range r.phases {
+		// d = p + j*up 가 [-half, half] 안인 j
+		first := int(math.Ceil(float64(-half-p) / float64(up)))
+		last := int(math.Floor(float64(half-p) / float64(up)))
+		ph := phase{first: first}
+		var sum float64
+		for j := first; j <= last; j++ {
+			tap := h[p+j*up+half]
+			ph.taps = append(ph.taps, tap)
+			sum += tap
+		}
+		// 위상마다 DC 이득 1
+		for i := range ph.taps {
+			ph.taps[i] /= sum
+		}
+		r.phases[p] = ph
+	}
+	return r, nil
+}
+
+// outIndex returns the first output sample at or after input sample n.
+func (r *resampler) outIndex(n int) int {
+	return (n*r.up + r.down - 1) / r.down
+}
+
+// apply resamples xs, extending its first and last samples beyond the
+// edges.
+func (r *resampler) apply(xs []float64) []float64 {
+	at := func(n int) float64 {
+		switch {
+		case n < 0:
+			return xs[0]
+		case n >= len(xs):
+			return xs[len(xs)-1]
+		}
+		return xs[n]
+	}
+	ys := make([]float64, r.outIndex(len(xs)))
+	for k := range ys {
+		pos := k * r.down
+		n0, p := pos/r.up, pos%r.up
+		if r.linear {
+			frac := float64(p) / float64(r.up)
+			ys[k] = at(n0)*(1-frac) + at(n0+1)*frac
+			continue
+		}
+		ph := r.phases[p]
+		var y float64
+		for i, h := range ph.taps {
+			y += h * at(n0-(ph.first+i))
+		}
+		ys[k] = y
+	}
+	return ys
+}
+
+// rateRatio returns to/from as a fraction up/down in lowest terms. Rates
+// that are not integers are approximated by continued fractions with down
+// at most maxResampleFactor; a ratio below 1/maxResampleFactor has no such
+// approximation and is an error.
+func rateRatio(from, to float64) (up, down int, err error) {
+	if from == math.Trunc(from) && to == math.Trunc(to) && from < math.MaxInt32 && to < math.MaxInt32 {
+		a, b := int(to), int(from)
+		g := gcd(a, b)
+		return a / g, b / g, nil
+	}
+	x := to / from
+	// 연분수 수렴값 h/k
+	h0, h1, k0, k1 := 0, 1, 1, 0
+	for {
+		a := math.Floor(x)
+		h2, k2 := int(a)*h1+h0, int(a)*k1+k0
+		if k2 > maxResampleFactor {
+			break
+		}
+		h0, h1, k0, k1 = h1, h2, k1, k2
+		if x-a < 1e-12 {
+			break
+		}
+		x = 1 / (x - a)
+	}
+	if h1 == 0 {
+		return 0, 0, fmt.Errorf("rate ratio %g/%g cannot be approximated with a factor up to %d", to, from, maxResampleFactor)
+	}
+	return h1, k1, nil
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// storeSamples returns vals in the slice type of fmtcode, rounded and
+// clamped to the range of integer formats.
+func storeSamples(fmtcode uint8, vals []float64) any {
+	var lo, hi float64
+	switch fmtcode {
+	case 3:
+		lo, hi = math.MinInt8, math.MaxInt8
+	case 4:
+		lo, hi = 0, math.MaxUint8
+	case 5:
+		lo, hi = math.MinInt16, math.MaxInt16
+	case 6:
+		lo, hi = 0, math.MaxUint16
+	case 7:
+		lo, hi = math.MinInt32, math.MaxInt32
+	case 8:
+		lo, hi = 0, math.MaxUint32
+	default:
+		return convertSamples(fmtcode, vals)
+	}
+	for i, v := range vals {
+		vals[i] = math.Max(lo, math.Min(hi, math.Round(v)))
+	}
+	return convertSamples(fmtcode, vals)
+}
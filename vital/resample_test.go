@@ -0,0 +1,112 @@
+package vital
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mdsung/vitaldb_processor/vital/vitaltest"
+)
+
+// sineRecording returns a file with a float32 WAVE track of sines at the
+// given frequencies, sampled at 500 Hz in 1 s chunks: 0-4 s and, after a
+// gap, 10-12 s.
+func sineRecording(freqs ...float64) *vitaltest.Builder {
+	b := vitaltest.New(syntheticStart)
+	b.Device(1, "Bx50", "Bx50", "COM1")
+	b.Track(vitaltest.Track{ID: 1, Type: vitaltest.TypeWave, Fmt: vitaltest.FmtFloat32, Name: "ECG_II", SRate: 500, DevID: 1})
+	for _, sec := range []int{0, 1, 2, 3, 10, 11} {
+		chunk := make([]float32, 500)
+		for i := range chunk {
+			tm := float64(sec) + float64(i)/500
+			for _, f := range freqs {
+				chunk[i] += float32(math.Sin(2 * math.Pi * f * tm))
+			}
+		}
+		b.Wave(1, syntheticStart+float64(sec), chunk)
+	}
+	return b
+}
+
+// maxError returns the largest difference between the samples of trk at
+// least margin seconds from a segment edge and fn.
+func maxError(trk *Track, margin float64, fn func(tm float64) float64) float64 {
+	var worst float64
+	for _, rec := range trk.Recs {
+		for i, v := range rec.Val.([]float32) {
+			tm := rec.Dt - syntheticStart + float64(i)/float64(trk.SRate)
+			if tm < margin || (tm > 4-margin && tm < 10+margin) || tm > 12-margin {
+				continue
+			}
+			worst = math.Max(worst, math.Abs(float64(v)-fn(tm)))
+		}
+	}
+	return worst
+}
+
+func TestResample(t *testing.T) {
+	vf := readVital(t, sineRecording(5, 200))
+	ecg := vf.Trks["Bx50/ECG_II"]
+	slow := func(tm float64) float64 { return math.Sin(2 * math.Pi * 5 * tm) }
+
+	for _, rate := range []float64{250, 300} {
+		out, err := ecg.Resample(ResampleOptions{Rate: rate})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.SRate != float32(rate) || len(out.Recs) != 6 {
+			t.Fatalf("%g Hz: SRate %g, %d chunks", rate, out.SRate, len(out.Recs))
+		}
+		// 200 Hz 성분은 새 나이퀴스트 주파수보다 높아 제거되고 5 Hz만 남음
+		if err := maxError(out, 0.2, slow); err > 0.01 {
+			t.Errorf("%g Hz: max error %g", rate, err)
+		}
+		segs, _ := out.Segments(SegmentOptions{})
+		if len(segs) != 2 || !near(segs[1].Start, syntheticStart+10) || !near(segs[1].End, syntheticStart+12) {
+			t.Errorf("%g Hz: segments %+v", rate, segs)
+		}
+	}
+
+	// 선형 보간은 앨리어싱을 막지 않음
+	lin, err := ecg.Decimate(2, ResampleOptions{Filter: FilterLinear})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := maxError(lin, 0.2, slow); err < 0.5 {
+		t.Errorf("linear decimation removed the 200 Hz component (max error %g)", err)
+	}
+	if _, err := ecg.Resample(ResampleOptions{Rate: 100, Filter: "cubic"}); err == nil {
+		t.Error("unknown filter should fail")
+	}
+}
+
+func TestAddResampled(t *testing.T) {
+	vf := readVital(t, syntheticRecording(2))
+	trk, err := vf.AddResampled("Bx50/ECG_II", ResampleOptions{Rate: 125})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trk.Name != "Bx50/ECG_II_125Hz" || trk.Fmt != vf.Trks["Bx50/ECG_II"].Fmt || trk.NumRecs() != 2 {
+		t.Errorf("added %q fmt %d with %d chunks", trk.Name, trk.Fmt, trk.NumRecs())
+	}
+	if n := len(trk.Recs[0].Val.([]int16)); n != 125 {
+		t.Errorf("first chunk has %d samples", n)
+	}
+	if _, err := vf.AddResampled("Bx50/ECG_II", ResampleOptions{Rate: 125}); err == nil {
+		t.Error("adding the same track twice should fail")
+	}
+	if _, err := vf.AddResampled("Bx50/ECG_II", ResampleOptions{Rate: 100, Name: "ECG_100"}); err != nil || vf.Trks["Bx50/ECG_100"] == nil {
+		t.Errorf("named track: %v", err)
+	}
+	diffGolden(roundTrip(t, vf), goldenFromVitalFile(vf), goldenTolerance{}).report(t)
+
+	if up, down, err := rateRatio(99.5, 100); err != nil || up != 200 || down != 199 {
+		t.Errorf("rateRatio(99.5, 100) = %d/%d, %v", up, down, err)
+	}
+	// 1/1000보다 작은 정수가 아닌 비율은 근사할 수 없음
+	if up, down, err := rateRatio(500, 0.3); err == nil {
+		t.Errorf("rateRatio(500, 0.3) = %d/%d", up, down)
+	}
+	if _, err := vf.Trks["Bx50/ECG_II"].Resample(ResampleOptions{Rate: 0.3}); err == nil {
+		t.Error("resampling 500 Hz to 0.3 Hz should fail")
+	}
+}
@@ -0,0 +1,139 @@
+package vital
+
+import (
+	"math"
+	"sort"
+)
+
+// defaultIntervalFactor is the multiple of the expected interval after
+// which NUMERIC and STRING records start a new segment.
+const defaultIntervalFactor = 2
+
+// Segment is a stretch of a track recorded without interruption, covering
+// [Start, End). Gap is the time since the end of the previous segment: 0
+// for the first segment, negative if the segment overlaps the previous one.
+type Segment struct {
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Records int     `json:"records"`
+	Gap     float64 `json:"gap"`
+}
+
+// Duration returns End - Start.
+func (s Segment) Duration() float64 {
+	return s.End - s.Start
+}
+
+// SegmentOptions configures Track.Segments. Zero values select the
+// defaults.
+type SegmentOptions struct {
+	// Tolerance is the largest difference between a WAVE chunk's start and
+	// the previous chunk's end that is still contiguous (default: half a
+	// sample period).
+	Tolerance float64
+	// Interval is the expected interval of NUMERIC and STRING records
+	// (default: the median interval of the track). Each record covers one
+	// interval, and a record more than IntervalFactor intervals after the
+	// previous one starts a new segment (default 2).
+	Interval       float64
+	IntervalFactor float64
+}
+
+// Segments splits the track into contiguous segments. A WAVE chunk
+// continues the segment if its Dt is within the tolerance of the previous
+// chunk's end (Dt + samples/SRate); NUMERIC and STRING records continue it
+// if they follow the previous record within IntervalFactor expected
+// intervals. Records are taken in stored order.
+func (t *Track) Segments(opts SegmentOptions) ([]Segment, error) {
+	sb := newSegmenter(t, opts)
+	err := t.ForEachRec(func(rec Rec) error {
+		sb.add(rec.Dt, t.recEnd(&rec))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sb.finish(), nil
+}
+
+// segmenter builds segments from record times. WAVE chunks are segmented as
+// they arrive; instantaneous records are kept until finish, since the
+// default interval depends on all of them.
+type segmenter struct {
+	wave      bool
+	opts      SegmentOptions
+	segs      []Segment
+	times     []float64 // 순간 레코드 시각
+	prevEnd   float64
+	hasRecord bool
+}
+
+func newSegmenter(t *Track, opts SegmentOptions) *segmenter {
+	sb := &segmenter{wave: t.Type == 1 && t.SRate > 0, opts: opts}
+	if sb.wave && sb.opts.Tolerance <= 0 {
+		sb.opts.Tolerance = 0.5 / float64(t.SRate)
+	}
+	if sb.opts.IntervalFactor <= 0 {
+		sb.opts.IntervalFactor = defaultIntervalFactor
+	}
+	return sb
+}
+
+func (sb *segmenter) add(dt, end float64) {
+	if !sb.wave {
+		sb.times = append(sb.times, dt)
+		return
+	}
+	if sb.hasRecord && math.Abs(dt-sb.prevEnd) <= sb.opts.Tolerance {
+		cur := &sb.segs[len(sb.segs)-1]
+		cur.End = math.Max(cur.End, end)
+		cur.Records++
+	} else {
+		sb.start(dt, end)
+	}
+	sb.prevEnd = end
+	sb.hasRecord = true
+}
+
+// start opens a new segment at dt.
+func (sb *segmenter) start(dt, end float64) {
+	seg := Segment{Start: dt, End: end, Records: 1}
+	if n := len(sb.segs); n > 0 {
+		seg.Gap = dt - sb.segs[n-1].End
+	}
+	sb.segs = append(sb.segs, seg)
+}
+
+func (sb *segmenter) finish() []Segment {
+	if sb.wave || len(sb.times) == 0 {
+		return sb.segs
+	}
+	interval := sb.opts.Interval
+	if interval <= 0 {
+		interval = medianInterval(sb.times)
+	}
+	limit := sb.opts.IntervalFactor * interval
+	for i, dt := range sb.times {
+		if i > 0 && dt-sb.times[i-1] >= 0 && dt-sb.times[i-1] <= limit {
+			cur := &sb.segs[len(sb.segs)-1]
+			cur.End = math.Max(cur.End, dt+interval)
+			cur.Records++
+			continue
+		}
+		sb.start(dt, dt+interval)
+	}
+	return sb.segs
+}
+
+// medianInterval returns the median of the positive intervals between
+// consecutive times, or 0 if there are none.
+func medianInterval(times []float64) float64 {
+	var intervals []float64
+	for i := 1; i < len(times); i++ {
+		if iv := times[i] - times[i-1]; iv > 0 {
+			intervals = append(intervals, iv)
+		}
+	}
+	sort.Float64s(intervals)
+	return percentile(intervals, 50)
+}
@@ -0,0 +1,71 @@
+package vital
+
+import (
+	"testing"
+
+	"github.com/mdsung/vitaldb_processor/vital/vitaltest"
+)
+
+func TestSegments(t *testing.T) {
+	b := vitaltest.New(syntheticStart)
+	b.Device(1, "Bx50", "Bx50", "COM1")
+	b.Track(vitaltest.Track{ID: 1, Type: vitaltest.TypeWave, Fmt: vitaltest.FmtInt16, Name: "ECG_II", SRate: 100, DevID: 1})
+	b.Track(vitaltest.Track{ID: 2, Type: vitaltest.TypeNumeric, Fmt: vitaltest.FmtFloat32, Name: "HR", DevID: 1})
+	chunk := make([]int16, 100) // 1초
+	// 0-2초 연속 (두 번째 청크는 반 샘플 주기 이내의 지터), 5초에 재연결, 5.5초에 겹침
+	for _, dt := range []float64{0, 1.004, 5, 5.5} {
+		b.Wave(1, syntheticStart+dt, chunk)
+	}
+	// 2초 간격 HR, 10초 공백
+	for _, dt := range []float64{0, 2, 4, 6, 16, 18} {
+		b.Numeric(2, syntheticStart+dt, float32(60))
+	}
+	vf := readVital(t, b)
+
+	ecg, err := vf.Trks["Bx50/ECG_II"].Segments(SegmentOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Segment{
+		{Start: 0, End: 2.004, Records: 2},
+		{Start: 5, End: 6, Records: 1, Gap: 2.996},
+		{Start: 5.5, End: 6.5, Records: 1, Gap: -0.5},
+	}
+	checkSegments(t, "ECG", ecg, want)
+
+	// 허용 오차를 0.001초로 줄이면 지터도 끊김
+	if ecg, _ := vf.Trks["Bx50/ECG_II"].Segments(SegmentOptions{Tolerance: 0.001}); len(ecg) != 4 {
+		t.Errorf("ECG with 1 ms tolerance: %d segments", len(ecg))
+	}
+
+	hr, err := vf.Trks["Bx50/HR"].Segments(SegmentOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSegments(t, "HR", hr, []Segment{
+		{Start: 0, End: 8, Records: 4},
+		{Start: 16, End: 20, Records: 2, Gap: 8},
+	})
+	if hr, _ := vf.Trks["Bx50/HR"].Segments(SegmentOptions{Interval: 5, IntervalFactor: 2.5}); len(hr) != 1 {
+		t.Errorf("HR with 5 s interval: %+v", hr)
+	}
+}
+
+func checkSegments(t *testing.T, name string, got, want []Segment) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: %d segments %+v, want %d", name, len(got), got, len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		g.Start -= syntheticStart
+		g.End -= syntheticStart
+		if g.Records != w.Records || !near(g.Start, w.Start) || !near(g.End, w.End) || !near(g.Gap, w.Gap) {
+			t.Errorf("%s segment %d = %+v, want %+v", name, i, g, w)
+		}
+	}
+}
+
+func near(a, b float64) bool {
+	return a-b < 1e-6 && b-a < 1e-6
+}
@@ -0,0 +1,282 @@
+package vital
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// LoadOptions controls how NewVitalFileWithOptions holds sample data.
+type LoadOptions struct {
+	// MemoryBudget caps the bytes of WAVE samples kept in memory while
+	// loading (0 = unlimited). When the budget is exceeded, the track with
+	// the most in-memory samples is spilled to a temporary file in SpillDir
+	// and its later records are written there directly.
+	MemoryBudget int64
+	SpillDir     string // 임시 파일 위치 ("" = os.TempDir())
+}
+
+// spiller enforces a LoadOptions.MemoryBudget during loading.
+type spiller struct {
+	budget int64
+	dir    string
+	used   int64            // 메모리에 있는 WAVE 샘플 바이트 합계
+	mem    map[*Track]int64 // 트랙별 메모리 사용량
+	err    error            // 첫 번째 디스크 오류 (파서는 에러를 반환하지 않음)
+}
+
+func newSpiller(opts LoadOptions) *spiller {
+	return &spiller{budget: opts.MemoryBudget, dir: opts.SpillDir, mem: make(map[*Track]int64)}
+}
+
+// add accounts for n bytes of samples appended to trk in memory and spills
+// the largest tracks until the budget holds again.
+func (s *spiller) add(trk *Track, n int64) {
+	s.used += n
+	s.mem[trk] += n
+	for s.used > s.budget && s.err == nil {
+		var victim *Track
+		for t, m := range s.mem {
+			if victim == nil || m > s.mem[victim] {
+				victim = t
+			}
+		}
+		if victim == nil {
+			return
+		}
+		s.used -= s.mem[victim]
+		delete(s.mem, victim)
+		s.err = victim.spillTo(s.dir)
+	}
+}
+
+// discard forgets trk, whose name a later TRKINFO packet redefined, and
+// removes its spill file: the records of a replaced track are dropped, and
+// Close only reaches the tracks still in VitalFile.Trks.
+func (s *spiller) discard(trk *Track) {
+	s.used -= s.mem[trk]
+	delete(s.mem, trk)
+	if trk.spill != nil {
+		if err := trk.spill.remove(); err != nil && s.err == nil {
+			s.err = err
+		}
+		trk.spill = nil
+	}
+}
+
+// write appends a raw WAVE chunk to a spilled track.
+func (s *spiller) write(trk *Track, dt float64, data []byte) {
+	if s.err == nil {
+		s.err = trk.spill.append(dt, data)
+	}
+}
+
+// trackSpill stores the records of a spilled WAVE track column-wise: record
+// times and sample offsets stay in memory while the samples themselves are
+// appended to a temporary file in the track's raw fmt encoding.
+type trackSpill struct {
+	f    *os.File
+	w    *bufio.Writer
+	size int       // 샘플당 바이트 수
+	dts  []float64 // 레코드 시간 컬럼
+	offs []int64   // 레코드 시작 샘플 오프셋 컬럼
+	n    int64     // 기록된 총 샘플 수
+}
+
+func (sp *trackSpill) append(dt float64, data []byte) error {
+	sp.dts = append(sp.dts, dt)
+	sp.offs = append(sp.offs, sp.n)
+	sp.n += int64(len(data) / sp.size)
+	if _, err := sp.w.Write(data); err != nil {
+		return fmt.Errorf("failed to write spill file: %w", err)
+	}
+	return nil
+}
+
+// span returns the first sample and sample count of record i.
+func (sp *trackSpill) span(i int) (int64, int) {
+	end := sp.n
+	if i+1 < len(sp.offs) {
+		end = sp.offs[i+1]
+	}
+	return sp.offs[i], int(end - sp.offs[i])
+}
+
+func (sp *trackSpill) flush() error {
+	if sp.w.Buffered() == 0 {
+		return nil
+	}
+	if err := sp.w.Flush(); err != nil {
+		return fmt.Errorf("failed to write spill file: %w", err)
+	}
+	return nil
+}
+
+// remove closes and deletes the spill file.
+func (sp *trackSpill) remove() error {
+	name := sp.f.Name()
+	return errors.Join(sp.f.Close(), os.Remove(name))
+}
+
+// spillTo moves the in-memory records of a WAVE track to a new spill file
+// in dir.
+func (t *Track) spillTo(dir string) error {
+	size := sampleSize(t.Fmt)
+	if size == 0 {
+		return fmt.Errorf("cannot spill track %q with fmt %d", t.Name, t.Fmt)
+	}
+	f, err := os.CreateTemp(dir, "vital-spill-*.col")
+	if err != nil {
+		return fmt.Errorf("failed to create spill file: %w", err)
+	}
+	sp := &trackSpill{f: f, w: bufio.NewWriterSize(f, 64*1024), size: size}
+	for _, rec := range t.Recs {
+		if err := sp.append(rec.Dt, encodeSamples(rec.Val)); err != nil {
+			f.Close()
+			os.Remove(f.Name())
+			return err
+		}
+	}
+	t.spill = sp
+	t.Recs = nil
+	return nil
+}
+
+// Spilled reports whether the track's records live in a spill file rather
+// than in Recs.
+func (t *Track) Spilled() bool {
+	return t.spill != nil
+}
+
+// NumRecs returns the number of records of the track, whether in memory or
+// spilled.
+func (t *Track) NumRecs() int {
+	if t.spill != nil {
+		return len(t.spill.dts)
+	}
+	return len(t.Recs)
+}
+
+// Rec returns record i of the track, reading it back from the spill file if
+// the track was spilled.
+func (t *Track) Rec(i int) (Rec, error) {
+	if t.spill == nil {
+		return t.Recs[i], nil
+	}
+	sp := t.spill
+	if err := sp.flush(); err != nil {
+		return Rec{}, err
+	}
+	first, n := sp.span(i)
+	data := make([]byte, n*sp.size)
+	if _, err := sp.f.ReadAt(data, first*int64(sp.size)); err != nil {
+		return Rec{}, fmt.Errorf("failed to read spill file: %w", err)
+	}
+	return Rec{Dt: sp.dts[i], Val: decodeSamples(t.Fmt, data, n)}, nil
+}
+
+// ForEachRec calls fn for every record of the track in order, streaming
+// spilled records from disk. Iteration stops at the first error returned
+// by fn, which is returned to the caller.
+func (t *Track) ForEachRec(fn func(Rec) error) error {
+	if t.spill == nil {
+		for _, rec := range t.Recs {
+			if err := fn(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	sp := t.spill
+	if err := sp.flush(); err != nil {
+		return err
+	}
+	r := bufio.NewReaderSize(io.NewSectionReader(sp.f, 0, sp.n*int64(sp.size)), 256*1024)
+	var data []byte
+	for i := range sp.dts {
+		_, n := sp.span(i)
+		if cap(data) < n*sp.size {
+			data = make([]byte, n*sp.size)
+		}
+		data = data[:n*sp.size]
+		if _, err := io.ReadFull(r, data); err != nil {
+			return fmt.Errorf("failed to read spill file: %w", err)
+		}
+		if err := fn(Rec{Dt: sp.dts[i], Val: decodeSamples(t.Fmt, data, n)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close removes the spill files of the file's tracks. It is a no-op for
+// files loaded without a memory budget.
+func (vf *VitalFile) Close() error {
+	var errs []error
+	for _, trk := range vf.Trks {
+		if trk.spill == nil {
+			continue
+		}
+		errs = append(errs, trk.spill.remove())
+		trk.spill = nil
+	}
+	return errors.Join(errs...)
+}
+
+// encodeSamples returns the little-endian encoding of a WAVE chunk, the
+// inverse of decodeSamples.
+func encodeSamples(val any) []byte {
+	le := binary.LittleEndian
+	switch v := val.(type) {
+	case []float32:
+		b := make([]byte, 4*len(v))
+		for i, x := range v {
+			le.PutUint32(b[i*4:], math.Float32bits(x))
+		}
+		return b
+	case []float64:
+		b := make([]byte, 8*len(v))
+		for i, x := range v {
+			le.PutUint64(b[i*8:], math.Float64bits(x))
+		}
+		return b
+	case []int8:
+		b := make([]byte, len(v))
+		for i, x := range v {
+			b[i] = byte(x)
+		}
+		return b
+	case []uint8:
+		return append([]byte(nil), v...)
+	case []int16:
+		b := make([]byte, 2*len(v))
+		for i, x := range v {
+			le.PutUint16(b[i*2:], uint16(x))
+		}
+		return b
+	case []uint16:
+		b := make([]byte, 2*len(v))
+		for i, x := range v {
+			le.PutUint16(b[i*2:], x)
+		}
+		return b
+	case []int32:
+		b := make([]byte, 4*len(v))
+		for i, x := range v {
+			le.PutUint32(b[i*4:], uint32(x))
+		}
+		return b
+	case []uint32:
+		b := make([]byte, 4*len(v))
+		for i, x := range v {
+			le.PutUint32(b[i*4:], x)
+		}
+		return b
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package vital
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/mdsung/vitaldb_processor/vital/vitaltest"
+)
+
+func TestMemoryBudgetSpillsWaveTracks(t *testing.T) {
+	path := writeSyntheticVital(t, 120)
+	full, err := NewVitalFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	vf, err := NewVitalFileWithOptions(path, LoadOptions{MemoryBudget: 16 << 10, SpillDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecg := vf.Trks["Bx50/ECG_II"]
+	if !ecg.Spilled() || ecg.Recs != nil {
+		t.Fatal("wave track exceeding the budget was not spilled")
+	}
+	if vf.Trks["Bx50/HR"].Spilled() {
+		t.Error("numeric track should stay in memory")
+	}
+
+	want := full.Trks["Bx50/ECG_II"].Recs
+	if ecg.NumRecs() != len(want) {
+		t.Fatalf("NumRecs: got %d, want %d", ecg.NumRecs(), len(want))
+	}
+	i := 0
+	err = ecg.ForEachRec(func(rec Rec) error {
+		if !reflect.DeepEqual(rec, want[i]) {
+			t.Fatalf("record %d differs after paging back", i)
+		}
+		i++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := ecg.Rec(77)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rec, want[77]) {
+		t.Error("random access to a spilled record differs")
+	}
+
+	if err := vf.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Close left %d spill files behind", len(entries))
+	}
+}
+
+// TestRedefinedTrackSpillRemoved checks that the spill file of a track
+// replaced by a later TRKINFO packet is removed rather than leaked.
+func TestRedefinedTrackSpillRemoved(t *testing.T) {
+	b := vitaltest.New(syntheticStart)
+	b.Device(1, "Bx50", "Bx50", "COM1")
+	ecg := vitaltest.Track{ID: 1, Type: vitaltest.TypeWave, Fmt: vitaltest.FmtInt16, Name: "ECG_II", SRate: 500, DevID: 1}
+	b.Track(ecg)
+	chunk := make([]int16, 500)
+	for s := 0; s < 10; s++ {
+		b.Wave(1, syntheticStart+float64(s), chunk)
+	}
+	ecg.ID = 2
+	b.Track(ecg) // 같은 이름으로 재정의
+	b.Wave(2, syntheticStart+10, chunk)
+
+	dir := t.TempDir()
+	vf, err := NewVitalFileWithOptions(writeVital(t, b), LoadOptions{MemoryBudget: 1024, SpillDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("%d spill files left after redefining the spilled track", len(entries))
+	}
+	if n := vf.Trks["Bx50/ECG_II"].NumRecs(); n != 1 {
+		t.Errorf("redefined track has %d records", n)
+	}
+	if err := vf.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEncodeSamplesRoundTrip(t *testing.T) {
+	vals := map[uint8]any{
+		1: []float32{1.5, -2.25},
+		2: []float64{3.125, -4},
+		3: []int8{-5, 6},
+		4: []uint8{7, 250},
+		5: []int16{-300, 301},
+		6: []uint16{65000, 2},
+		7: []int32{-70000, 70001},
+		8: []uint32{4000000000, 9},
+	}
+	for fmtcode, val := range vals {
+		got := decodeSamples(fmtcode, encodeSamples(val), 2)
+		if !reflect.DeepEqual(got, val) {
+			t.Errorf("fmt %d: got %v, want %v", fmtcode, got, val)
+		}
+	}
+}
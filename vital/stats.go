@@ -0,0 +1,205 @@
+package vital
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// StatsPercentiles are the percentiles reported in TrackStats.Percentiles,
+// keyed "p1", "p5", ... "p99".
+var StatsPercentiles = []float64{1, 5, 25, 50, 75, 95, 99}
+
+// statsReservoirSize is the number of values percentiles are computed from
+// exactly; longer tracks are estimated from a uniform sample of this size.
+const statsReservoirSize = 100000
+
+// TrackStats describes the records of a track. Values are calibrated (see
+// Track.Calibrate). Fields that need values, such as Min or Percentiles,
+// are zero when the track has none.
+type TrackStats struct {
+	Records int `json:"records"`
+	Values  int `json:"values"`  // 유한한 값 수 (WAVE는 샘플 수)
+	Invalid int `json:"invalid"` // NaN, Inf
+
+	Start    float64 `json:"start"`    // 첫 레코드 시각
+	End      float64 `json:"end"`      // 마지막 레코드가 끝나는 시각
+	Coverage float64 `json:"coverage"` // End-Start에서 gap을 뺀 초
+	Gaps     int     `json:"gaps"`
+	GapTime  float64 `json:"gap_time"`
+
+	Min         float64            `json:"min"`
+	Max         float64            `json:"max"`
+	Mean        float64            `json:"mean"`
+	Std         float64            `json:"std"` // 모표준편차
+	Percentiles map[string]float64 `json:"percentiles,omitempty"`
+	Exact       bool               `json:"exact"` // 백분위수를 모든 값으로 계산
+
+	DeclaredRate  float64 `json:"declared_rate"`
+	EffectiveRate float64 `json:"effective_rate"`
+	OutOfRange    float64 `json:"out_of_range"` // Mindisp/Maxdisp 밖의 값 비율 (표시 범위가 없으면 0)
+}
+
+// Stats computes descriptive statistics of the track's records.
+//
+// Gaps are the pauses between the segments found by Segments with default
+// options. The effective rate is the number of values per second of
+// coverage for WAVE tracks and records per second for others, to compare
+// with the declared SRate.
+func (t *Track) Stats() (TrackStats, error) {
+	s := TrackStats{DeclaredRate: float64(t.SRate)}
+	wave := t.Type == 1 && t.SRate > 0
+	hasRange := t.Maxdisp > t.Mindisp
+
+	var (
+		mean, m2 float64 // Welford
+		outside  int
+		sb       = newSegmenter(t, SegmentOptions{})
+		res      = reservoir{state: 0x9e3779b97f4a7c15}
+	)
+	add := func(raw float64) {
+		v := t.Calibrate(raw)
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			s.Invalid++
+			return
+		}
+		s.Values++
+		if s.Values == 1 || v < s.Min {
+			s.Min = v
+		}
+		if s.Values == 1 || v > s.Max {
+			s.Max = v
+		}
+		d := v - mean
+		mean += d / float64(s.Values)
+		m2 += d * (v - mean)
+		if hasRange && (v < float64(t.Mindisp) || v > float64(t.Maxdisp)) {
+			outside++
+		}
+		res.add(v)
+	}
+
+	err := t.ForEachRec(func(rec Rec) error {
+		end := t.recEnd(&rec)
+		if s.Records == 0 {
+			s.Start, s.End = rec.Dt, end
+		} else {
+			s.Start = math.Min(s.Start, rec.Dt)
+			s.End = math.Max(s.End, end)
+		}
+		sb.add(rec.Dt, end)
+		s.Records++
+		forEachSample(rec.Val, add)
+		return nil
+	})
+	if err != nil {
+		return s, err
+	}
+
+	for _, seg := range sb.finish() {
+		if seg.Gap > 0 {
+			s.Gaps++
+			s.GapTime += seg.Gap
+		}
+	}
+	span := s.End - s.Start
+	s.Coverage = math.Max(0, span-s.GapTime)
+
+	switch {
+	case wave && s.Coverage > 0:
+		s.EffectiveRate = float64(s.Values+s.Invalid) / s.Coverage
+	case !wave && span > 0:
+		s.EffectiveRate = float64(s.Records-1) / span
+	}
+	if s.Values > 0 {
+		s.Mean = mean
+		s.Std = math.Sqrt(m2 / float64(s.Values))
+		s.Exact = res.seen <= len(res.vals)
+		sort.Float64s(res.vals)
+		s.Percentiles = make(map[string]float64, len(StatsPercentiles))
+		for _, p := range StatsPercentiles {
+			s.Percentiles[PercentileKey(p)] = percentile(res.vals, p)
+		}
+		if hasRange {
+			s.OutOfRange = float64(outside) / float64(s.Values)
+		}
+	}
+	return s, nil
+}
+
+// PercentileKey returns the TrackStats.Percentiles key of percentile p.
+func PercentileKey(p float64) string {
+	return "p" + strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// percentile returns the p-th percentile of sorted values, interpolating
+// linearly between the closest ranks (as numpy.percentile does by default).
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := p / 100 * float64(len(sorted)-1)
+	i := int(pos)
+	if i >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	frac := pos - float64(i)
+	return sorted[i] + frac*(sorted[i+1]-sorted[i])
+}
+
+// reservoir keeps a uniform sample of up to statsReservoirSize values. The
+// sample is deterministic, so repeated runs report the same percentiles.
+type reservoir struct {
+	vals  []float64
+	seen  int
+	state uint64 // xorshift64*
+}
+
+func (r *reservoir) add(v float64) {
+	r.seen++
+	if len(r.vals) < statsReservoirSize {
+		r.vals = append(r.vals, v)
+		return
+	}
+	r.state ^= r.state >> 12
+	r.state ^= r.state << 25
+	r.state ^= r.state >> 27
+	if j := (r.state * 2685821657736338717) % uint64(r.seen); j < statsReservoirSize {
+		r.vals[j] = v
+	}
+}
+
+// forEachSample calls fn with every numeric value of a record: the samples
+// of a WAVE chunk or the value of a NUMERIC record. STRING values are
+// skipped.
+func forEachSample(val any, fn func(float64)) {
+	switch v := val.(type) {
+	case []float32:
+		eachSample(v, fn)
+	case []float64:
+		eachSample(v, fn)
+	case []int8:
+		eachSample(v, fn)
+	case []uint8:
+		eachSample(v, fn)
+	case []int16:
+		eachSample(v, fn)
+	case []uint16:
+		eachSample(v, fn)
+	case []int32:
+		eachSample(v, fn)
+	case []uint32:
+		eachSample(v, fn)
+	default:
+		rec := Rec{Val: val}
+		if x, ok := rec.GetNumericValue(); ok {
+			fn(x)
+		}
+	}
+}
+
+func eachSample[T float32 | float64 | int8 | uint8 | int16 | uint16 | int32 | uint32](v []T, fn func(float64)) {
+	for _, x := range v {
+		fn(float64(x))
+	}
+}
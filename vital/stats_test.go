@@ -0,0 +1,84 @@
+package vital
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/mdsung/vitaldb_processor/vital/vitaltest"
+)
+
+func TestTrackStats(t *testing.T) {
+	b := vitaltest.New(syntheticStart)
+	b.Device(1, "Bx50", "Bx50", "COM1")
+	b.Track(vitaltest.Track{ID: 1, Type: vitaltest.TypeWave, Fmt: vitaltest.FmtInt16,
+		Name: "ECG_II", Unit: "mV", Mindisp: -1, Maxdisp: 1, SRate: 100, Gain: 0.01, DevID: 1})
+	b.Track(vitaltest.Track{ID: 2, Type: vitaltest.TypeNumeric, Fmt: vitaltest.FmtFloat32,
+		Name: "HR", Unit: "/min", Maxdisp: 200, DevID: 1})
+	// 1초 청크 두 개 사이에 1초 공백, 값은 -150..149 (x0.01 = -1.5..1.49)
+	samples := make([]int16, 100)
+	for i := range samples {
+		samples[i] = int16(i - 50)
+	}
+	b.Wave(1, syntheticStart, samples)
+	for i := range samples {
+		samples[i] = int16(3 * (i - 50))
+	}
+	b.Wave(1, syntheticStart+2, samples)
+	// 1초 간격 HR, 10초에서 20초 사이 공백, NaN 하나
+	for _, s := range []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 20, 21} {
+		b.Numeric(2, syntheticStart+s, float32(60+s))
+	}
+	b.Numeric(2, syntheticStart+22, float32(math.NaN()))
+	vf := readVital(t, b)
+
+	ecg, err := vf.Trks["Bx50/ECG_II"].Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ecg.Records != 2 || ecg.Values != 200 || ecg.Gaps != 1 || ecg.GapTime != 1 || ecg.Coverage != 2 {
+		t.Errorf("ECG counts = %+v", ecg)
+	}
+	if ecg.Min != -1.5 || math.Abs(ecg.Max-1.47) > 1e-9 || ecg.EffectiveRate != 100 || !ecg.Exact {
+		t.Errorf("ECG values = %+v", ecg)
+	}
+	// |x| > 1: 두 번째 청크의 -1.50..-1.02 (17개), 1.02..1.47 (16개)
+	if want := 33.0 / 200; math.Abs(ecg.OutOfRange-want) > 1e-9 {
+		t.Errorf("out of range = %v, want %v", ecg.OutOfRange, want)
+	}
+
+	hr, err := vf.Trks["Bx50/HR"].Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hr.Records != 14 || hr.Values != 13 || hr.Invalid != 1 || hr.Gaps != 1 || hr.GapTime != 9 {
+		t.Errorf("HR counts = %+v", hr)
+	}
+	if hr.Coverage != 13 || math.Abs(hr.EffectiveRate-13.0/22) > 1e-9 {
+		t.Errorf("HR coverage %v, rate %v", hr.Coverage, hr.EffectiveRate)
+	}
+	if hr.Min != 60 || hr.Max != 81 || hr.Percentiles["p50"] != 66 || hr.OutOfRange != 0 {
+		t.Errorf("HR values = %+v", hr)
+	}
+	if math.Abs(hr.Mean-(60*13+0+1+2+3+4+5+6+7+8+9+10+20+21)/13.0) > 1e-9 {
+		t.Errorf("HR mean = %v", hr.Mean)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	vals := []float64{1, 2, 3, 4}
+	for p, want := range map[float64]float64{0: 1, 25: 1.75, 50: 2.5, 100: 4} {
+		if got := percentile(vals, p); got != want {
+			t.Errorf("p%v = %v, want %v", p, got, want)
+		}
+	}
+
+	r := reservoir{state: 1}
+	for i := 0; i < 3*statsReservoirSize; i++ {
+		r.add(float64(i))
+	}
+	sort.Float64s(r.vals)
+	if med := percentile(r.vals, 50); math.Abs(med-1.5*statsReservoirSize) > 0.02*statsReservoirSize {
+		t.Errorf("reservoir median = %v", med)
+	}
+}
@@ -0,0 +1,299 @@
+package vital
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// defaultMaxDrift is the relative difference between a device's true
+// sample rate and Track.SRate that TimingOptions accepts as clock drift.
+const defaultMaxDrift = 0.01
+
+// TimingOptions configures Track.Timing and CorrectTiming. Zero values
+// select the defaults.
+type TimingOptions struct {
+	// Tolerance is the largest difference between a chunk's Dt and the end
+	// of the previous chunk that is always contiguous (default: half a
+	// sample period).
+	Tolerance float64
+	// MaxDrift is the largest relative rate error attributed to clock drift
+	// (default 0.01): a chunk continues the segment if its Dt is within
+	// MaxDrift times the previous chunk's duration of that chunk's end.
+	MaxDrift float64
+	// EffectiveRate makes CorrectTiming set SRate to the track's effective
+	// rate instead of keeping the declared rate for the samples of a chunk.
+	EffectiveRate bool
+}
+
+// SegmentTiming is a segment of a WAVE track with the sample rate estimated
+// from the time stamps of its chunks.
+type SegmentTiming struct {
+	Segment
+	Samples       int     `json:"samples"`
+	EffectiveRate float64 `json:"effective_rate"` // 청크 Dt의 최소제곱 추정 (청크가 하나면 SRate)
+	Drift         float64 `json:"drift"`          // EffectiveRate/SRate - 1
+
+	origin float64 // 최소제곱 직선의 첫 샘플 시각 (CorrectTiming이 사용)
+}
+
+// TimingCorrection is a change made by CorrectTiming to the samples of a
+// chunk.
+type TimingCorrection struct {
+	Action  string  `json:"action"` // "drop": 중복 청크 삭제, "trim": 앞 구간과 겹치는 샘플 삭제
+	Dt      float64 `json:"dt"`     // 원본 청크 시각
+	Samples int     `json:"samples"`
+}
+
+// TimingReport describes the timing of a WAVE track's chunks and, when
+// returned by CorrectTiming, the corrections applied.
+type TimingReport struct {
+	Track         string          `json:"track"`
+	SRate         float64         `json:"srate"`
+	EffectiveRate float64         `json:"effective_rate"` // 전체 샘플 수 / 세그먼트 시간
+	Segments      []SegmentTiming `json:"segments"`
+	Overlaps      int             `json:"overlaps"`     // 앞 세그먼트와 겹치는 세그먼트 수
+	OverlapTime   float64         `json:"overlap_time"` // 겹친 시간 합 (초)
+	Duplicates    int             `json:"duplicates"`   // 이미 기록된 구간 안에 있는 청크 수
+
+	Corrections []TimingCorrection `json:"corrections,omitempty"`
+	Retimed     int                `json:"retimed"`   // Dt가 바뀐 청크 수
+	MaxShift    float64            `json:"max_shift"` // Dt 변경량의 최댓값 (초)
+}
+
+// chunk is a WAVE record as seen by the timing analysis.
+type chunk struct {
+	dt  float64
+	n   int
+	val any // CorrectTiming만 사용
+	dup bool
+	seg int // Segments 인덱스
+}
+
+// Timing analyses the time stamps of a WAVE track's chunks, taken in time
+// order. Consecutive chunks belong to the same segment if they are
+// contiguous within the tolerance or the drift allowance; the effective
+// rate of each segment is estimated from its chunks. A chunk that lies
+// within the time already covered by its segment is a duplicate; a chunk
+// that starts before the previous chunk's end by more than the allowance
+// starts an overlapping segment.
+func (t *Track) Timing(opts TimingOptions) (*TimingReport, error) {
+	r, _, err := t.timing(opts, false)
+	return r, err
+}
+
+// CorrectTiming returns a copy of t on a corrected timeline and a report of
+// the corrections. Duplicate chunks are dropped. Within each segment, each
+// chunk starts where the least-squares fit of the segment's time stamps
+// puts its first sample, at the segment's EffectiveRate, so that jitter is
+// removed while clock drift does not build up over a long segment; samples
+// that overlap an earlier segment are trimmed. The samples of a chunk stay
+// 1/SRate apart; with opts.EffectiveRate the copy's SRate becomes the
+// track's effective rate, which makes the chunks of a drifting segment
+// contiguous. The copy holds its records in memory and shares sample slices
+// with t.
+func (t *Track) CorrectTiming(opts TimingOptions) (*Track, *TimingReport, error) {
+	r, chunks, err := t.timing(opts, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := t.copyMeta()
+	if opts.EffectiveRate && r.EffectiveRate > 0 {
+		out.SRate = float32(r.EffectiveRate)
+	}
+	rate := float64(out.SRate)
+	tol := timingTolerance(opts, t)
+	covered := math.Inf(-1) // 앞 세그먼트들에 놓은 마지막 샘플 다음 시각
+	cursor := math.Inf(-1)  // 현재 세그먼트에 놓은 마지막 샘플 다음 시각
+	lastSeg, pos := -1, 0   // pos: 세그먼트 안에서 앞선 샘플 수
+	for _, c := range chunks {
+		if c.dup {
+			r.Corrections = append(r.Corrections, TimingCorrection{Action: "drop", Dt: c.dt, Samples: c.n})
+			continue
+		}
+		if c.seg != lastSeg {
+			covered, lastSeg, pos = math.Max(covered, cursor), c.seg, 0
+		}
+		seg := &r.Segments[c.seg]
+		dt := seg.origin + float64(pos)/seg.EffectiveRate
+		pos += c.n
+		skip := 0
+		if dt < covered-tol {
+			skip = samplesBefore(dt, covered, out.SRate)
+			if skip >= c.n {
+				r.Corrections = append(r.Corrections, TimingCorrection{Action: "trim", Dt: c.dt, Samples: c.n})
+				continue
+			}
+			r.Corrections = append(r.Corrections, TimingCorrection{Action: "trim", Dt: c.dt, Samples: skip})
+			dt += float64(skip) / rate
+		}
+		// 첫 남은 샘플의 원래 시각과 비교
+		if shift := math.Abs(dt - (c.dt + float64(skip)/float64(t.SRate))); shift > 1e-9 {
+			r.Retimed++
+			r.MaxShift = math.Max(r.MaxShift, shift)
+		}
+		out.Recs = append(out.Recs, Rec{Dt: dt, Val: sliceSamples(c.val, skip, c.n)})
+		cursor = dt + float64(c.n-skip)/rate
+	}
+	return out, r, nil
+}
+
+// CorrectTiming returns a copy of vf with the WAVE tracks corrected by
+// Track.CorrectTiming, and their reports sorted by track name. Other tracks
+// are copied unchanged.
+func (vf *VitalFile) CorrectTiming(opts TimingOptions) (*VitalFile, []*TimingReport, error) {
+	out := vf.copyStructure()
+	var reports []*TimingReport
+	for name, trk := range vf.Trks {
+		if trk.Type != 1 || trk.SRate <= 0 {
+			dst := trk.copyMeta()
+			err := trk.ForEachRec(func(rec Rec) error {
+				dst.Recs = append(dst.Recs, rec)
+				return nil
+			})
+			if err != nil {
+				return nil, nil, fmt.Errorf("track %q: %w", name, err)
+			}
+			out.Trks[name] = dst
+			continue
+		}
+		dst, r, err := trk.CorrectTiming(opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		r.Track = name
+		out.Trks[name] = dst
+		reports = append(reports, r)
+	}
+	out.linkTracks()
+	out.recomputeTimeRange()
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Track < reports[j].Track })
+	return out, reports, nil
+}
+
+// timing collects the chunks of t in time order and segments them. With
+// keep, the chunks hold their samples.
+func (t *Track) timing(opts TimingOptions, keep bool) (*TimingReport, []chunk, error) {
+	if t.Type != 1 || t.SRate <= 0 {
+		return nil, nil, fmt.Errorf("track %q: not a WAVE track", t.Name)
+	}
+	var chunks []chunk
+	err := t.ForEachRec(func(rec Rec) error {
+		c := chunk{dt: rec.Dt, n: sampleCount(rec.Val)}
+		if keep {
+			c.val = rec.Val
+		}
+		if c.n > 0 {
+			chunks = append(chunks, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("track %q: %w", t.Name, err)
+	}
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].dt < chunks[j].dt })
+
+	r := &TimingReport{Track: t.Name, SRate: float64(t.SRate), Segments: []SegmentTiming{}}
+	srate := float64(t.SRate)
+	tol := timingTolerance(opts, t)
+	maxDrift := opts.MaxDrift
+	if maxDrift <= 0 {
+		maxDrift = defaultMaxDrift
+	}
+
+	var prev *chunk
+	for i := range chunks {
+		c := &chunks[i]
+		end := c.dt + float64(c.n)/srate
+		if prev != nil {
+			seg := &r.Segments[len(r.Segments)-1]
+			prevEnd := prev.dt + float64(prev.n)/srate
+			limit := math.Max(tol, maxDrift*float64(prev.n)/srate)
+			diff := c.dt - prevEnd
+			switch {
+			case math.Abs(diff) <= limit:
+				c.seg = len(r.Segments) - 1
+				seg.End = math.Max(seg.End, end)
+				seg.Records++
+				seg.Samples += c.n
+				prev = c
+				continue
+			case diff < 0 && end <= seg.End+tol:
+				c.dup = true
+				r.Duplicates++
+				continue
+			case diff < 0:
+				r.Overlaps++
+				r.OverlapTime += seg.End - c.dt
+			}
+		}
+		seg := SegmentTiming{Segment: Segment{Start: c.dt, End: end, Records: 1}, Samples: c.n}
+		if n := len(r.Segments); n > 0 {
+			seg.Gap = c.dt - r.Segments[n-1].End
+		}
+		c.seg = len(r.Segments)
+		r.Segments = append(r.Segments, seg)
+		prev = c
+	}
+
+	var samples, duration float64
+	for i := range r.Segments {
+		seg := &r.Segments[i]
+		seg.EffectiveRate, seg.origin = fitRate(chunks, i, srate)
+		seg.Drift = seg.EffectiveRate/srate - 1
+		samples += float64(seg.Samples)
+		duration += float64(seg.Samples) / seg.EffectiveRate
+	}
+	if duration > 0 {
+		r.EffectiveRate = samples / duration
+	}
+	return r, chunks, nil
+}
+
+// timingTolerance returns opts.Tolerance or half a sample period of t.
+func timingTolerance(opts TimingOptions, t *Track) float64 {
+	if opts.Tolerance > 0 {
+		return opts.Tolerance
+	}
+	return 0.5 / float64(t.SRate)
+}
+
+// fitRate estimates the sample rate of segment seg by fitting the chunks'
+// Dt against the number of samples before them (least squares), and
+// returns it with the fitted time of the segment's first sample. Segments
+// with a single chunk keep srate and their Dt.
+func fitRate(chunks []chunk, seg int, srate float64) (rate, origin float64) {
+	var xs, ys []float64
+	pos, t0 := 0.0, math.NaN()
+	for _, c := range chunks {
+		if c.dup || c.seg != seg {
+			continue
+		}
+		if math.IsNaN(t0) {
+			t0 = c.dt
+		}
+		xs = append(xs, pos)
+		ys = append(ys, c.dt-t0) // 큰 Unix 시각에서의 정밀도 손실 방지
+		pos += float64(c.n)
+	}
+	if len(xs) < 2 {
+		return srate, t0
+	}
+	var mx, my float64
+	for i := range xs {
+		mx += xs[i]
+		my += ys[i]
+	}
+	mx /= float64(len(xs))
+	my /= float64(len(xs))
+	var sxy, sxx float64
+	for i := range xs {
+		sxy += (xs[i] - mx) * (ys[i] - my)
+		sxx += (xs[i] - mx) * (xs[i] - mx)
+	}
+	if sxy <= 0 || sxx == 0 {
+		return srate, t0
+	}
+	return sxx / sxy, t0 + my - mx*sxy/sxx
+}
@@ -0,0 +1,123 @@
+package vital
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mdsung/vitaldb_processor/vital/vitaltest"
+)
+
+func TestTiming(t *testing.T) {
+	b := vitaltest.New(syntheticStart)
+	b.Device(1, "Bx50", "Bx50", "COM1")
+	b.Track(vitaltest.Track{ID: 1, Type: vitaltest.TypeWave, Fmt: vitaltest.FmtInt16, Name: "ECG_II", SRate: 100, DevID: 1})
+	b.Track(vitaltest.Track{ID: 2, Type: vitaltest.TypeNumeric, Fmt: vitaltest.FmtFloat32, Name: "HR", DevID: 1})
+	chunk := make([]int16, 100)
+	for i := range chunk {
+		chunk[i] = int16(i)
+	}
+	// 0-4초: 실제 샘플링 레이트가 100/1.008 Hz (청크마다 8 ms 밀림), 2.016초 청크는 중복 전송
+	// 10-12초: 정상, 11.5초: 앞 세그먼트와 0.5초 겹침
+	for _, dt := range []float64{0, 1.008, 2.016, 2.016, 3.024, 10, 11, 11.5} {
+		b.Wave(1, syntheticStart+dt, chunk)
+	}
+	b.Numeric(2, syntheticStart, float32(60))
+	vf := readVital(t, b)
+	ecg := vf.Trks["Bx50/ECG_II"]
+
+	r, err := ecg.Timing(TimingOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Segments) != 3 || r.Duplicates != 1 || r.Overlaps != 1 || !near(r.OverlapTime, 0.5) {
+		t.Fatalf("report = %+v", r)
+	}
+	if seg := r.Segments[0]; seg.Records != 4 || seg.Samples != 400 || !near(seg.EffectiveRate, 100/1.008) {
+		t.Errorf("drifting segment = %+v", seg)
+	}
+	if seg := r.Segments[1]; seg.EffectiveRate != 100 || seg.Drift != 0 || !near(seg.Gap, 10-4.024) {
+		t.Errorf("second segment = %+v", seg)
+	}
+
+	fixed, r, err := ecg.CorrectTiming(TimingOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 드리프트는 기록된 시각을 따르고(누적 보정 없음), 겹친 청크는 앞 세그먼트 끝으로
+	wantDt := []float64{0, 1.008, 2.016, 3.024, 10, 11, 12}
+	if len(fixed.Recs) != len(wantDt) {
+		t.Fatalf("%d corrected chunks", len(fixed.Recs))
+	}
+	for i, rec := range fixed.Recs {
+		if !near(rec.Dt-syntheticStart, wantDt[i]) {
+			t.Errorf("chunk %d at %f, want %f", i, rec.Dt-syntheticStart, wantDt[i])
+		}
+	}
+	// 겹친 청크는 앞 50 샘플을 잘라냄
+	if last := fixed.Recs[6].Val.([]int16); len(last) != 50 || last[0] != 50 {
+		t.Errorf("trimmed chunk: %d samples from %d", len(last), last[0])
+	}
+	want := []TimingCorrection{{"drop", syntheticStart + 2.016, 100}, {"trim", syntheticStart + 11.5, 50}}
+	if len(r.Corrections) != 2 || r.Corrections[0] != want[0] || r.Corrections[1] != want[1] {
+		t.Errorf("corrections = %+v", r.Corrections)
+	}
+	if r.Retimed != 0 || r.MaxShift > 1e-6 {
+		t.Errorf("retimed %d chunks, max shift %f", r.Retimed, r.MaxShift)
+	}
+
+	// 파일 단위 보정: 다른 트랙은 그대로
+	out, reports, err := vf.CorrectTiming(TimingOptions{EffectiveRate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || reports[0].Track != "Bx50/ECG_II" || out.Trks["Bx50/HR"].NumRecs() != 1 {
+		t.Fatalf("reports %+v", reports)
+	}
+	if srate := float64(out.Trks["Bx50/ECG_II"].SRate); math.Abs(srate-reports[0].EffectiveRate) > 1e-3 || srate >= 100 {
+		t.Errorf("corrected SRate %f", srate)
+	}
+	diffGolden(roundTrip(t, out), goldenFromVitalFile(out), goldenTolerance{}).report(t)
+
+	if _, err := vf.Trks["Bx50/HR"].Timing(TimingOptions{}); err == nil {
+		t.Error("Timing of a NUMERIC track should fail")
+	}
+}
+
+// TestCorrectTimingDrift checks that a long drifting segment is laid out on
+// its fitted rate: the shift stays within the jitter instead of adding up
+// to the drift of the whole hour.
+func TestCorrectTimingDrift(t *testing.T) {
+	samples := make([]int16, 500)
+	trk := &Track{Name: "Bx50/ECG_II", Type: 1, Fmt: 5, Gain: 1, SRate: 500}
+	for i := 0; i < 3600; i++ {
+		jitter := 0.0005 * math.Sin(float64(i)) // ±0.5 ms
+		trk.Recs = append(trk.Recs, Rec{Dt: syntheticStart + 1.004*float64(i) + jitter, Val: samples})
+	}
+
+	fixed, r, err := trk.CorrectTiming(TimingOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Segments) != 1 || math.Abs(r.Segments[0].EffectiveRate-500/1.004) > 0.01 {
+		t.Fatalf("segments %+v", r.Segments)
+	}
+	if r.Retimed == 0 || r.MaxShift > 0.001 {
+		t.Errorf("retimed %d chunks, max shift %f", r.Retimed, r.MaxShift)
+	}
+	if last := fixed.Recs[3599].Dt - syntheticStart; math.Abs(last-1.004*3599) > 0.001 {
+		t.Errorf("last chunk at %f, recorded at %f", last, 1.004*3599)
+	}
+
+	// 실제 레이트로 바꾸면 청크가 빈틈없이 이어짐
+	fixed, _, err = trk.CorrectTiming(TimingOptions{EffectiveRate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	period := 1 / float64(fixed.SRate)
+	for i := 1; i < len(fixed.Recs); i++ {
+		end := fixed.Recs[i-1].Dt + 500*period
+		if gap := fixed.Recs[i].Dt - end; math.Abs(gap) > period/2 {
+			t.Fatalf("chunk %d starts %f s after the previous chunk's end", i, gap)
+		}
+	}
+}
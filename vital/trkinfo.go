@@ -24,7 +24,7 @@ func parseTrkInfo(pkt []byte, vf *VitalFile) {
 	}
 	unit, n := unpackStr(pkt, pos)
 	pos += n
-	if pos+20 > len(pkt) {
+	if pos+36 > len(pkt) { // mindisp, maxdisp, col, srate, gain, offset
 		return
 	}
 	mindisp := bytesToFloat32(pkt[pos : pos+4])
@@ -64,7 +64,7 @@ func parseTrkInfo(pkt []byte, vf *VitalFile) {
 	// tid와 트랙 이름 매핑 저장 (Python의 tid_dtnames와 동일)
 	vf.TrkIDs[tid] = fullTrackName
 
-	trk := Track{
+	trk := &Track{
 		Name:    fullTrackName, // Python VitalDB 호환성: "Device/TrackName" 형식
 		Type:    trktype,
 		Fmt:     fmtcode,
@@ -79,6 +79,22 @@ func parseTrkInfo(pkt []byte, vf *VitalFile) {
 		DName:   deviceName,
 		Recs:    []Rec{},
 	}
+	if vf.tids == nil {
+		vf.tids = make(map[uint16]*Track)
+	}
+	// 같은 이름의 트랙이 재정의되면 이전 tid도 새 트랙을 가리키도록 갱신
+	if prev, exists := vf.Trks[fullTrackName]; exists {
+		for id, t := range vf.tids {
+			if t == prev {
+				vf.tids[id] = trk
+			}
+		}
+		// 이전 트랙은 더 이상 Trks에 없으므로 Close가 spill 파일을 찾을 수 없음
+		if vf.spiller != nil {
+			vf.spiller.discard(prev)
+		}
+	}
 	vf.Trks[fullTrackName] = trk
+	vf.tids[tid] = trk
 	vf.Order = append(vf.Order, fullTrackName)
 }
@@ -27,7 +27,9 @@ type Track struct {
 	Col     uint32
 	Montype uint8
 	DName   string
-	Recs    []Rec
+	Recs    []Rec // 디스크로 내보낸 트랙은 nil - NumRecs/Rec/ForEachRec 사용
+
+	spill *trackSpill // MemoryBudget 초과 시 샘플을 보관하는 임시 파일
 }
 
 // Rec represents a single data record within a track
@@ -39,11 +41,14 @@ type Rec struct {
 // VitalFile represents the complete structure of a VitalDB file
 type VitalFile struct {
 	Devs    map[string]Device
-	Trks    map[string]Track
+	Trks    map[string]*Track
 	DtStart float64
 	DtEnd   float64
 	Dgmt    int16
 	Order   []string
 	DevIDs  map[uint32]string // did -> device name 매핑
 	TrkIDs  map[uint16]string // tid -> track name 매핑
+
+	tids    map[uint16]*Track // tid -> track, REC 패킷 조회용
+	spiller *spiller          // LoadOptions.MemoryBudget 적용 (nil = 무제한)
 }
@@ -1,18 +1,139 @@
 package vital
 
 import (
+	"bytes"
+	"compress/gzip"
+	"reflect"
 	"testing"
+
+	vt "github.com/mdsung/vitaldb_processor/vital/vitaltest"
 )
 
-// Unit tests will be added here in Task #2 using mock data.
-// This file will contain tests that:
-// - Use no external file dependencies
-// - Run in < 1 second
-// - Test individual functions/parsers with mock data
-
-// Placeholder test to ensure the file compiles
-func TestPlaceholder(t *testing.T) {
-	// This test will be replaced with actual unit tests in Task #2
-	// when the mock data generation system is implemented
-	t.Skip("Unit tests will be implemented in Task #2 with mock data")
+// TestParseAllFormats checks that WAVE and NUMERIC records of every fmt code
+// keep their original Go type, as Python VitalDB does.
+func TestParseAllFormats(t *testing.T) {
+	cases := []struct {
+		fmtcode uint8
+		wave    any
+		num     any
+	}{
+		{vt.FmtFloat32, []float32{1.5, -2.5}, float32(36.6)},
+		{vt.FmtFloat64, []float64{0.125, 3}, float64(98.5)},
+		{vt.FmtInt8, []int8{-128, 127}, int8(-3)},
+		{vt.FmtUint8, []uint8{0, 255}, uint8(200)},
+		{vt.FmtInt16, []int16{-32768, 1000}, int16(-150)},
+		{vt.FmtUint16, []uint16{65535, 7}, uint16(40000)},
+		{vt.FmtInt32, []int32{-100000, 5}, int32(-70000)},
+		{vt.FmtUint32, []uint32{4000000000, 1}, uint32(3000000000)},
+	}
+	for _, tc := range cases {
+		b := vt.New(syntheticStart)
+		b.Track(vt.Track{ID: 1, Type: vt.TypeWave, Fmt: tc.fmtcode, Name: "W", Unit: "mV", SRate: 100})
+		b.Track(vt.Track{ID: 2, Type: vt.TypeNumeric, Fmt: tc.fmtcode, Name: "N", Unit: "mV"})
+		b.Wave(1, syntheticStart, tc.wave)
+		b.Numeric(2, syntheticStart+1, tc.num)
+		vf := readVital(t, b)
+
+		if got := vf.Trks["W"].Recs; len(got) != 1 || !reflect.DeepEqual(got[0].Val, tc.wave) {
+			t.Errorf("fmt %d wave: got %#v, want %#v", tc.fmtcode, got, tc.wave)
+		}
+		if got := vf.Trks["N"].Recs; len(got) != 1 || !reflect.DeepEqual(got[0].Val, tc.num) {
+			t.Errorf("fmt %d numeric: got %#v, want %#v", tc.fmtcode, got, tc.num)
+		}
+	}
+}
+
+func TestParseDevicesTracksAndStrings(t *testing.T) {
+	b := vt.New(syntheticStart)
+	b.Dgmt = 540
+	b.Device(7, "Intellivue", "MX800", "COM3")
+	b.Track(vt.Track{ID: 3, Type: vt.TypeString, Fmt: vt.FmtFloat32, Name: "EVENT", DevID: 7})
+	b.Track(vt.Track{ID: 4, Type: vt.TypeNumeric, Fmt: vt.FmtFloat32, Name: "HR", Unit: "/min",
+		Mindisp: 0, Maxdisp: 200, Col: 0xff00ff00, Gain: 1, Montype: 3, DevID: 99})
+	b.String(3, syntheticStart+5, "Intubation")
+	vf := readVital(t, b)
+
+	if vf.Dgmt != 540 {
+		t.Errorf("Dgmt: got %d, want 540", vf.Dgmt)
+	}
+	if dev := vf.Devs["MX800"]; dev != (Device{Name: "MX800", TypeName: "Intellivue", Port: "COM3"}) {
+		t.Errorf("device: got %+v", dev)
+	}
+	ev, ok := vf.Trks["MX800/EVENT"]
+	if !ok || ev.DName != "MX800" {
+		t.Fatalf("track on a known device should be named Device/Name, got %v", vf.Order)
+	}
+	if len(ev.Recs) != 1 || ev.Recs[0].Val != "Intubation" || ev.Recs[0].Dt != syntheticStart+5 {
+		t.Errorf("string record: got %+v", ev.Recs)
+	}
+	hr, ok := vf.Trks["HR"]
+	if !ok || hr.DName != "" {
+		t.Fatal("track on an unknown device should keep its bare name")
+	}
+	if hr.Maxdisp != 200 || hr.Col != 0xff00ff00 || hr.Montype != 3 || hr.Unit != "/min" {
+		t.Errorf("track metadata: got %+v", hr)
+	}
+}
+
+func TestTrackOrderCommand(t *testing.T) {
+	b := vt.New(syntheticStart)
+	for i, name := range []string{"A", "B", "C"} {
+		b.Track(vt.Track{ID: uint16(i + 1), Type: vt.TypeNumeric, Fmt: vt.FmtFloat32, Name: name, Unit: "/min"})
+	}
+	b.TrackOrder(3, 1, 2)
+	vf := readVital(t, b)
+
+	if want := []string{"C", "A", "B"}; !reflect.DeepEqual(vf.Order, want) {
+		t.Errorf("Order: got %v, want %v", vf.Order, want)
+	}
+}
+
+// TestMalformedPacketsAreSkipped checks that damaged packets are ignored
+// without affecting the packets around them.
+func TestMalformedPacketsAreSkipped(t *testing.T) {
+	b := vt.New(syntheticStart)
+	b.Track(vt.Track{ID: 1, Type: vt.TypeWave, Fmt: vt.FmtInt16, Name: "W", Unit: "mV", SRate: 100})
+	b.Raw(vt.PacketDevInfo, []byte{1, 2})
+	b.Raw(vt.PacketTrkInfo, make([]byte, 10))
+	b.Raw(vt.PacketRec, []byte{0, 0, 1})
+	b.Raw(vt.PacketCmd, []byte{1})
+	b.Raw(42, []byte("unknown packet type"))
+	b.Wave(9, syntheticStart, []int16{1, 2})                                               // unknown track
+	b.Raw(vt.PacketRec, vt.RecPacket(1, syntheticStart, []byte{255, 255, 255, 255, 1, 0})) // nsamples beyond packet
+	b.Wave(1, syntheticStart+1, []int16{3, 4})
+	b.Truncated(vt.PacketRec, 1000, vt.RecPacket(1, syntheticStart+2, nil))
+	vf := readVital(t, b)
+
+	recs := vf.Trks["W"].Recs
+	if len(recs) != 1 || !reflect.DeepEqual(recs[0].Val, []int16{3, 4}) {
+		t.Errorf("got records %+v, want only the valid chunk", recs)
+	}
+	if len(vf.Devs) != 0 || len(vf.Trks) != 1 {
+		t.Errorf("malformed metadata packets were not ignored: %d devices, %d tracks", len(vf.Devs), len(vf.Trks))
+	}
+}
+
+func TestTimeRangeFromRecords(t *testing.T) {
+	b := vt.New(0)
+	b.Track(vt.Track{ID: 1, Type: vt.TypeWave, Fmt: vt.FmtFloat32, Name: "W", Unit: "mV", SRate: 10})
+	b.Wave(1, 1000, make([]float32, 10))
+	b.Wave(1, 990, make([]float32, 5))
+	vf := readVital(t, b)
+
+	if vf.DtStart != 990 || vf.DtEnd != 1001 {
+		t.Errorf("got [%v, %v], want [990, 1001]", vf.DtStart, vf.DtEnd)
+	}
+}
+
+func TestReadVitalFileRejectsOtherData(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte("NOTAVITALFILE"))
+	zw.Close()
+	if _, err := ReadVitalFile(&buf); err == nil {
+		t.Error("expected an error for a gzip stream without the VITA magic")
+	}
+	if _, err := ReadVitalFile(bytes.NewReader([]byte("plain"))); err == nil {
+		t.Error("expected an error for data that is not gzip")
+	}
 }
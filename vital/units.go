@@ -0,0 +1,224 @@
+package vital
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Unit conversion errors.
+var (
+	ErrUnknownUnit       = errors.New("unknown unit")
+	ErrIncompatibleUnits = errors.New("incompatible units")
+)
+
+// Unit is a unit of measure known to a UnitRegistry. A value v in the unit
+// is v*Scale + Offset in the base unit of its Dimension.
+type Unit struct {
+	Symbol    string
+	Dimension string
+	Scale     float64
+	Offset    float64
+}
+
+// UnitRegistry parses unit strings as written by monitors ("mmHg", "mm Hg",
+// "MMHG", "℃", "degC") into Units.
+type UnitRegistry struct {
+	units map[string]Unit // 정규화한 표기 -> 단위
+	fold  map[string]Unit // 소문자 표기 -> 단위 (대소문자만 다른 단위가 없을 때)
+	clash map[string]bool // 대소문자만 다른 단위가 있는 소문자 표기
+}
+
+// DefaultUnits holds the units of the signals found in .vital files:
+// pressure, temperature, gas concentration, volume, flow, voltage, rate and
+// time.
+var DefaultUnits = newDefaultUnits()
+
+// NewUnitRegistry returns an empty registry.
+func NewUnitRegistry() *UnitRegistry {
+	return &UnitRegistry{
+		units: make(map[string]Unit),
+		fold:  make(map[string]Unit),
+		clash: make(map[string]bool),
+	}
+}
+
+// Register adds u under its symbol and the given aliases.
+func (r *UnitRegistry) Register(u Unit, aliases ...string) {
+	for _, name := range append([]string{u.Symbol}, aliases...) {
+		key := normalizeUnit(name)
+		r.units[key] = u
+		lower := strings.ToLower(key)
+		if prev, ok := r.fold[lower]; ok && prev.Symbol != u.Symbol {
+			r.clash[lower] = true
+		}
+		r.fold[lower] = u
+	}
+}
+
+// Parse returns the unit written as s. Spacing, micro signs and, where it
+// is unambiguous, letter case are ignored.
+func (r *UnitRegistry) Parse(s string) (Unit, bool) {
+	key := normalizeUnit(s)
+	if u, ok := r.units[key]; ok {
+		return u, true
+	}
+	lower := strings.ToLower(key)
+	if u, ok := r.fold[lower]; ok && !r.clash[lower] {
+		return u, true
+	}
+	return Unit{}, false
+}
+
+// Conversion converts values from one unit to another of the same
+// dimension.
+type Conversion struct {
+	From, To Unit
+	scale    float64
+	offset   float64
+}
+
+// Conversion returns the conversion between two unit strings. It wraps
+// ErrUnknownUnit or ErrIncompatibleUnits.
+func (r *UnitRegistry) Conversion(from, to string) (Conversion, error) {
+	fu, ok := r.Parse(from)
+	if !ok {
+		return Conversion{}, fmt.Errorf("%w %q", ErrUnknownUnit, from)
+	}
+	tu, ok := r.Parse(to)
+	if !ok {
+		return Conversion{}, fmt.Errorf("%w %q", ErrUnknownUnit, to)
+	}
+	if fu.Dimension != tu.Dimension {
+		return Conversion{}, fmt.Errorf("%w: %s (%s) and %s (%s)", ErrIncompatibleUnits, fu.Symbol, fu.Dimension, tu.Symbol, tu.Dimension)
+	}
+	return Conversion{
+		From:   fu,
+		To:     tu,
+		scale:  fu.Scale / tu.Scale,
+		offset: (fu.Offset - tu.Offset) / tu.Scale,
+	}, nil
+}
+
+// Apply converts v.
+func (c Conversion) Apply(v float64) float64 {
+	return v*c.scale + c.offset
+}
+
+// Identity reports whether the conversion leaves values unchanged.
+func (c Conversion) Identity() bool {
+	return c.scale == 1 && c.offset == 0
+}
+
+// ConvertRec returns rec of trk in the target unit as float64 values (a
+// []float64 chunk for WAVE records). Integer samples are calibrated with
+// the track's gain and offset first. It returns false for records without
+// numeric values.
+func (c Conversion) ConvertRec(trk *Track, rec Rec) (Rec, bool) {
+	if v, ok := rec.GetNumericValue(); ok {
+		return Rec{Dt: rec.Dt, Val: c.Apply(trk.Calibrate(v))}, true
+	}
+	vals, ok := samplesFloat64(rec.Val)
+	if !ok {
+		return rec, false
+	}
+	for i, v := range vals {
+		vals[i] = c.Apply(trk.Calibrate(v))
+	}
+	return Rec{Dt: rec.Dt, Val: vals}, true
+}
+
+// ConvertUnit returns a copy of t with its values converted to unit to,
+// stored as float64 (fmt 2) with gain 1 and offset 0. The copy holds its
+// records in memory.
+func (t *Track) ConvertUnit(r *UnitRegistry, to string) (*Track, error) {
+	conv, err := r.Conversion(t.Unit, to)
+	if err != nil {
+		return nil, fmt.Errorf("track %q: %w", t.Name, err)
+	}
+	out := t.copyMeta()
+	out.Unit, out.Fmt, out.Gain, out.Offset = conv.To.Symbol, 2, 1, 0
+	// 표시 범위는 이미 물리 단위
+	out.Mindisp = float32(conv.Apply(float64(t.Mindisp)))
+	out.Maxdisp = float32(conv.Apply(float64(t.Maxdisp)))
+	err = t.ForEachRec(func(rec Rec) error {
+		if rec, ok := conv.ConvertRec(t, rec); ok {
+			out.Recs = append(out.Recs, rec)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("track %q: %w", t.Name, err)
+	}
+	return out, nil
+}
+
+// Calibrate returns the physical value of a raw sample: integer formats
+// (fmt 3-8) store v*Gain + Offset, floating point formats the value itself.
+// A zero gain is treated as uncalibrated.
+func (t *Track) Calibrate(v float64) float64 {
+	if t.Fmt >= 3 && t.Fmt <= 8 && t.Gain != 0 {
+		return v*t.Gain + t.Offset
+	}
+	return v
+}
+
+// normalizeUnit removes spacing and unifies the symbols monitors write in
+// several ways.
+func normalizeUnit(s string) string {
+	return strings.NewReplacer(
+		" ", "", "\t", "",
+		"µ", "u", "μ", "u",
+		"℃", "°C", "℉", "°F", "º", "°",
+	).Replace(s)
+}
+
+func newDefaultUnits() *UnitRegistry {
+	r := NewUnitRegistry()
+
+	// 압력 (기준: Pa)
+	r.Register(Unit{"mmHg", "pressure", 133.322387415, 0}, "mm Hg", "Torr")
+	r.Register(Unit{"kPa", "pressure", 1000, 0})
+	r.Register(Unit{"hPa", "pressure", 100, 0}, "mbar")
+	r.Register(Unit{"Pa", "pressure", 1, 0})
+	r.Register(Unit{"cmH2O", "pressure", 98.0665, 0}, "cm H2O", "cmH₂O")
+	r.Register(Unit{"bar", "pressure", 1e5, 0})
+	r.Register(Unit{"psi", "pressure", 6894.757293168, 0})
+
+	// 온도 (기준: K)
+	r.Register(Unit{"°C", "temperature", 1, 273.15}, "C", "degC", "Celsius")
+	r.Register(Unit{"°F", "temperature", 5.0 / 9, 273.15 - 32*5.0/9}, "F", "degF", "Fahrenheit")
+	r.Register(Unit{"K", "temperature", 1, 0}, "Kelvin")
+
+	// 가스 농도 (기준: 분율)
+	r.Register(Unit{"%", "concentration", 0.01, 0}, "vol%", "Vol%", "percent")
+	r.Register(Unit{"ppm", "concentration", 1e-6, 0})
+
+	// 부피 (기준: L)
+	r.Register(Unit{"L", "volume", 1, 0}, "l")
+	r.Register(Unit{"dL", "volume", 0.1, 0})
+	r.Register(Unit{"mL", "volume", 1e-3, 0}, "cc")
+	r.Register(Unit{"uL", "volume", 1e-6, 0})
+
+	// 유량 (기준: L/min)
+	r.Register(Unit{"L/min", "flow", 1, 0}, "lpm")
+	r.Register(Unit{"mL/min", "flow", 1e-3, 0})
+	r.Register(Unit{"mL/s", "flow", 0.06, 0})
+	r.Register(Unit{"L/s", "flow", 60, 0})
+
+	// 전압 (기준: V)
+	r.Register(Unit{"V", "voltage", 1, 0})
+	r.Register(Unit{"mV", "voltage", 1e-3, 0})
+	r.Register(Unit{"uV", "voltage", 1e-6, 0})
+
+	// 빈도 (기준: Hz)
+	r.Register(Unit{"/min", "rate", 1.0 / 60, 0}, "bpm", "beats/min", "breaths/min", "rpm", "1/min")
+	r.Register(Unit{"Hz", "rate", 1, 0}, "/s", "1/s")
+
+	// 시간 (기준: s)
+	r.Register(Unit{"s", "time", 1, 0}, "sec")
+	r.Register(Unit{"ms", "time", 1e-3, 0}, "msec")
+	r.Register(Unit{"min", "time", 60, 0})
+	r.Register(Unit{"h", "time", 3600, 0}, "hr")
+	return r
+}
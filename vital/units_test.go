@@ -0,0 +1,73 @@
+package vital
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestUnitConversion(t *testing.T) {
+	cases := []struct {
+		from, to string
+		in, want float64
+	}{
+		{"mmHg", "kPa", 760, 101.325},
+		{"kPa", "mm Hg", 101.325, 760},
+		{"cmH2O", "mbar", 10, 9.80665},
+		{"°C", "F", 37, 98.6},
+		{"degF", "℃", 212, 100},
+		{"K", "°C", 0, -273.15},
+		{"%", "ppm", 0.5, 5000},
+		{"vol%", "%", 2, 2},
+		{"mL", "L", 450, 0.45},
+		{"L/min", "mL/s", 6, 100},
+		{"µV", "mV", 1500, 1.5},
+		{"/min", "Hz", 72, 1.2},
+		{"MMHG", "mmHg", 120, 120},
+	}
+	for _, tc := range cases {
+		conv, err := DefaultUnits.Conversion(tc.from, tc.to)
+		if err != nil {
+			t.Errorf("%s -> %s: %v", tc.from, tc.to, err)
+			continue
+		}
+		if got := conv.Apply(tc.in); math.Abs(got-tc.want) > 1e-6*math.Max(1, math.Abs(tc.want)) {
+			t.Errorf("%v %s = %v %s, want %v", tc.in, tc.from, got, tc.to, tc.want)
+		}
+	}
+
+	if _, err := DefaultUnits.Conversion("mmHg", "°C"); !errors.Is(err, ErrIncompatibleUnits) {
+		t.Errorf("mmHg -> °C: %v", err)
+	}
+	if _, err := DefaultUnits.Conversion("furlong", "m"); !errors.Is(err, ErrUnknownUnit) {
+		t.Errorf("furlong: %v", err)
+	}
+	if u, ok := DefaultUnits.Parse(" mm Hg "); !ok || u.Symbol != "mmHg" {
+		t.Errorf("Parse(mm Hg) = %+v, %v", u, ok)
+	}
+}
+
+func TestTrackConvertUnit(t *testing.T) {
+	vf := readVital(t, syntheticRecording(2))
+	ecg := vf.Trks["Bx50/ECG_II"] // int16, gain 0.01 mV
+
+	uv, err := ecg.ConvertUnit(DefaultUnits, "uV")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := ecg.Recs[0].Val.([]int16)[125]
+	got := uv.Recs[0].Val.([]float64)[125]
+	if want := float64(raw) * 0.01 * 1000; math.Abs(got-want) > 1e-9 {
+		t.Errorf("sample = %v uV, want %v", got, want)
+	}
+	if uv.Unit != "uV" || uv.Fmt != 2 || uv.Gain != 1 || uv.Maxdisp != 100000 || len(uv.Recs) != 2 {
+		t.Errorf("converted track = %+v", uv)
+	}
+	// 변환한 트랙도 .vital로 쓸 수 있음
+	vf.Trks["Bx50/ECG_II"] = uv
+	diffGolden(roundTrip(t, vf), goldenFromVitalFile(vf), goldenTolerance{}).report(t)
+
+	if _, err := vf.Trks["Bx50/HR"].ConvertUnit(DefaultUnits, "mmHg"); !errors.Is(err, ErrIncompatibleUnits) {
+		t.Errorf("HR -> mmHg: %v", err)
+	}
+}
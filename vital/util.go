@@ -19,13 +19,13 @@ func unpackStr(b []byte, pos int) (string, int) {
 	if pos+4 > len(b) {
 		return "", 0
 	}
-	strlen := int(binary.LittleEndian.Uint32(b[pos : pos+4]))
+	strlen := binary.LittleEndian.Uint32(b[pos : pos+4])
 	pos += 4
-	if pos+strlen > len(b) {
+	if uint64(strlen) > uint64(len(b)-pos) {
 		return "", 4
 	}
-	val := string(b[pos : pos+strlen])
-	return val, 4 + strlen
+	val := string(b[pos : pos+int(strlen)])
+	return val, 4 + int(strlen)
 }
 
 // Type-safe helper functions for Rec.Val access
@@ -93,3 +93,82 @@ func (r *Rec) GetNumericValue() (float64, bool) {
 		return 0, false
 	}
 }
+
+// sampleCount returns the number of samples held by a record value: the
+// slice length for WAVE chunks and 1 for scalar values.
+func sampleCount(val any) int {
+	switch v := val.(type) {
+	case []float32:
+		return len(v)
+	case []float64:
+		return len(v)
+	case []int8:
+		return len(v)
+	case []uint8:
+		return len(v)
+	case []int16:
+		return len(v)
+	case []uint16:
+		return len(v)
+	case []int32:
+		return len(v)
+	case []uint32:
+		return len(v)
+	default:
+		return 1
+	}
+}
+
+// recEnd returns the time just after the last sample of a record. WAVE
+// chunks span len/SRate seconds; other records are instantaneous.
+func (t *Track) recEnd(r *Rec) float64 {
+	if t.Type == 1 && t.SRate > 0 {
+		return r.Dt + float64(sampleCount(r.Val))/float64(t.SRate)
+	}
+	return r.Dt
+}
+
+// recomputeTimeRange sets DtStart and DtEnd to the span of the records
+// currently held by the file's tracks.
+func (vf *VitalFile) recomputeTimeRange() {
+	vf.DtStart, vf.DtEnd = 0, 0
+	first := true
+	for _, trk := range vf.Trks {
+		for i := range trk.Recs {
+			rec := &trk.Recs[i]
+			end := trk.recEnd(rec)
+			if first || rec.Dt < vf.DtStart {
+				vf.DtStart = rec.Dt
+			}
+			if first || end > vf.DtEnd {
+				vf.DtEnd = end
+			}
+			first = false
+		}
+	}
+}
+
+// sliceSamples returns samples [from, to) of a WAVE chunk. The result shares
+// the chunk's backing array.
+func sliceSamples(val any, from, to int) any {
+	switch v := val.(type) {
+	case []float32:
+		return v[from:to]
+	case []float64:
+		return v[from:to]
+	case []int8:
+		return v[from:to]
+	case []uint8:
+		return v[from:to]
+	case []int16:
+		return v[from:to]
+	case []uint16:
+		return v[from:to]
+	case []int32:
+		return v[from:to]
+	case []uint32:
+		return v[from:to]
+	default:
+		return val
+	}
+}
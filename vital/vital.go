@@ -1,6 +1,7 @@
 package vital
 
 import (
+	"bufio"
 	"compress/gzip"
 	"encoding/binary"
 	"errors"
@@ -9,39 +10,112 @@ import (
 	"os"
 )
 
+// maxPacketLen is the largest packet payload the decoder accepts.
+const maxPacketLen = 100 * 1024 * 1024 // 100MB 제한
+
+// readBufferSize is the size of the buffered reader placed in front of the
+// gzip stream so that small packet reads do not hit the inflater directly.
+const readBufferSize = 256 * 1024
+
 func NewVitalFile(path string) (*VitalFile, error) {
+	return NewVitalFileWithOptions(path, LoadOptions{})
+}
+
+// NewVitalFileWithOptions loads a .vital file like NewVitalFile. With a
+// MemoryBudget, WAVE tracks beyond the budget are spilled to temporary files
+// and must be read through Track.NumRecs, Track.Rec or Track.ForEachRec;
+// call Close on the result to remove the files.
+func NewVitalFileWithOptions(path string, opts LoadOptions) (*VitalFile, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer f.Close()
+	return ReadVitalFileWithOptions(f, opts)
+}
+
+// ReadVitalFile loads a gzip-compressed .vital stream from r.
+func ReadVitalFile(r io.Reader) (*VitalFile, error) {
+	return ReadVitalFileWithOptions(r, LoadOptions{})
+}
 
-	gz, err := gzip.NewReader(f)
+// ReadVitalFileWithOptions loads a gzip-compressed .vital stream from r
+// with the given options; see NewVitalFileWithOptions.
+func ReadVitalFileWithOptions(r io.Reader, opts LoadOptions) (*VitalFile, error) {
+	gz, err := gzip.NewReader(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
 	}
 	defer gz.Close()
 
-	magic := make([]byte, 4)
-	if _, err := io.ReadFull(gz, magic); err != nil {
+	d := newDecoder(gz)
+	vf, err := d.readHeader()
+	if err != nil {
+		return nil, err
+	}
+	if opts.MemoryBudget > 0 {
+		vf.spiller = newSpiller(opts)
+	}
+
+	pktCount := 0
+	for {
+		pktType, pkt, err := d.next()
+		if err != nil {
+			// 파일 끝에서 불완전한 패킷은 무시 (Python VitalDB와 동일한 방식)
+			if err == io.EOF {
+				break
+			}
+			vf.Close()
+			return nil, fmt.Errorf("packet %d: %w", pktCount, err)
+		}
+		dispatchPacket(pktType, pkt, vf)
+		if vf.spiller != nil && vf.spiller.err != nil {
+			vf.Close()
+			return nil, vf.spiller.err
+		}
+		pktCount++
+	}
+	vf.spiller = nil
+	return vf, nil
+}
+
+// decoder reads the packet stream of a decompressed .vital file. The header
+// and payload buffers are reused across packets, so the slice returned by
+// next is only valid until the following call.
+type decoder struct {
+	r   *bufio.Reader
+	hdr [5]byte
+	buf []byte
+	off int64 // uncompressed offset of the next packet
+}
+
+func newDecoder(r io.Reader) *decoder {
+	return &decoder{r: bufio.NewReaderSize(r, readBufferSize)}
+}
+
+// readHeader reads the file header and returns an empty VitalFile carrying
+// the header fields.
+func (d *decoder) readHeader() (*VitalFile, error) {
+	// magic(4) + version(4) + headerlen(2)
+	var pre [10]byte
+	if _, err := io.ReadFull(d.r, pre[:4]); err != nil {
 		return nil, fmt.Errorf("failed to read magic: %w", err)
 	}
-	if string(magic) != "VITA" {
+	if string(pre[:4]) != "VITA" {
 		return nil, errors.New("not a vital file")
 	}
-
-	var version uint32
-	if err := binary.Read(gz, binary.LittleEndian, &version); err != nil {
+	if _, err := io.ReadFull(d.r, pre[4:8]); err != nil {
 		return nil, fmt.Errorf("failed to read version: %w", err)
 	}
-	var headerlen uint16
-	if err := binary.Read(gz, binary.LittleEndian, &headerlen); err != nil {
+	if _, err := io.ReadFull(d.r, pre[8:10]); err != nil {
 		return nil, fmt.Errorf("failed to read header length: %w", err)
 	}
+	headerlen := binary.LittleEndian.Uint16(pre[8:10])
 	header := make([]byte, headerlen)
-	if _, err := io.ReadFull(gz, header); err != nil {
+	if _, err := io.ReadFull(d.r, header); err != nil {
 		return nil, fmt.Errorf("failed to read header: %w", err)
 	}
+	d.off += int64(len(pre)) + int64(headerlen)
 
 	// 헤더 길이 체크 후 안전하게 파싱
 	var dgmt int16
@@ -57,56 +131,92 @@ func NewVitalFile(path string) (*VitalFile, error) {
 		dtend = bytesToFloat64(header[18:26])
 	}
 
-	vf := &VitalFile{
+	return &VitalFile{
 		Devs:    make(map[string]Device),
-		Trks:    make(map[string]Track),
+		Trks:    make(map[string]*Track),
 		DtStart: dtstart,
 		DtEnd:   dtend,
 		Dgmt:    dgmt,
 		Order:   []string{},
 		DevIDs:  make(map[uint32]string),
 		TrkIDs:  make(map[uint16]string),
-	}
+	}, nil
+}
 
-	pktCount := 0
-	for {
-		hdr := make([]byte, 5)
-		if _, err := io.ReadFull(gz, hdr); err != nil {
-			if err == io.EOF {
-				break
-			}
-			return nil, fmt.Errorf("failed to read packet header at packet %d: %w", pktCount, err)
+// next returns the type and payload of the next packet. It returns io.EOF
+// at the end of the stream, including when the last packet body is
+// truncated.
+func (d *decoder) next() (byte, []byte, error) {
+	if _, err := io.ReadFull(d.r, d.hdr[:]); err != nil {
+		if err == io.EOF {
+			return 0, nil, err
 		}
-		pktType := hdr[0]
-		pktLen := binary.LittleEndian.Uint32(hdr[1:5])
+		return 0, nil, fmt.Errorf("failed to read packet header: %w", err)
+	}
+	pktType := d.hdr[0]
+	pktLen := binary.LittleEndian.Uint32(d.hdr[1:5])
 
-		// 패킷 길이 검증
-		if pktLen > 100*1024*1024 { // 100MB 제한
-			return nil, fmt.Errorf("packet %d has invalid length: %d bytes", pktCount, pktLen)
-		}
+	// 패킷 길이 검증
+	if pktLen > maxPacketLen {
+		return 0, nil, fmt.Errorf("invalid packet length: %d bytes", pktLen)
+	}
 
-		pkt := make([]byte, pktLen)
-		if _, err := io.ReadFull(gz, pkt); err != nil {
-			// 파일 끝에서 불완전한 패킷은 무시 (Python VitalDB와 동일한 방식)
-			if err == io.EOF || err == io.ErrUnexpectedEOF {
-				break
-			}
-			return nil, fmt.Errorf("failed to read packet %d (type %d, length %d): %w", pktCount, pktType, pktLen, err)
+	pkt, err := d.readBody(int(pktLen))
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return 0, nil, io.EOF
 		}
+		return 0, nil, fmt.Errorf("failed to read packet (type %d, length %d): %w", pktType, pktLen, err)
+	}
+	d.off += int64(len(d.hdr)) + int64(pktLen)
+	return pktType, pkt, nil
+}
 
-		switch pktType {
-		case 9:
-			parseDevInfo(pkt, vf)
-		case 0:
-			parseTrkInfo(pkt, vf)
-		case 1:
-			parseRec(pkt, vf)
-		case 6:
-			parseCmd(pkt, vf)
-		default:
-			// skip
+// readBody reads an n-byte packet body into the reused buffer. A body larger
+// than the buffer is read in doubling chunks so that a corrupt length near
+// maxPacketLen only allocates as much as the stream actually holds.
+func (d *decoder) readBody(n int) ([]byte, error) {
+	if n <= cap(d.buf) {
+		pkt := d.buf[:n]
+		_, err := io.ReadFull(d.r, pkt)
+		return pkt, err
+	}
+	buf := d.buf[:0]
+	for len(buf) < n {
+		chunk := len(buf)
+		if chunk < readBufferSize {
+			chunk = readBufferSize
 		}
-		pktCount++
+		if chunk > n-len(buf) {
+			chunk = n - len(buf)
+		}
+		if len(buf)+chunk > cap(buf) {
+			grown := make([]byte, len(buf), len(buf)+chunk)
+			copy(grown, buf)
+			buf = grown
+		}
+		d.buf = buf
+		if _, err := io.ReadFull(d.r, buf[len(buf):len(buf)+chunk]); err != nil {
+			return nil, err
+		}
+		buf = buf[:len(buf)+chunk]
+	}
+	d.buf = buf
+	return buf, nil
+}
+
+// dispatchPacket hands a packet payload to the parser for its type.
+func dispatchPacket(pktType byte, pkt []byte, vf *VitalFile) {
+	switch pktType {
+	case 9:
+		parseDevInfo(pkt, vf)
+	case 0:
+		parseTrkInfo(pkt, vf)
+	case 1:
+		parseRec(pkt, vf)
+	case 6:
+		parseCmd(pkt, vf)
+	default:
+		// skip
 	}
-	return vf, nil
 }
@@ -0,0 +1,271 @@
+// Package vitaltest builds .vital byte streams programmatically for tests,
+// fuzzers and benchmarks.
+//
+// A Builder appends packets in the order its methods are called, so
+// fixtures can describe devices, tracks of every type and fmt code, WAVE,
+// NUMERIC and STRING records, CMD packets and deliberately malformed
+// packets without depending on recorded files:
+//
+//	b := vitaltest.New(1721811600)
+//	b.Device(1, "Bx50", "Bx50", "COM1")
+//	b.Track(vitaltest.Track{ID: 1, Type: vitaltest.TypeWave, Fmt: vitaltest.FmtInt16,
+//		Name: "ECG_II", Unit: "mV", SRate: 500, Gain: 0.01, DevID: 1})
+//	b.Wave(1, 1721811600, []int16{0, 12, 25})
+//	path := filepath.Join(t.TempDir(), "case.vital")
+//	if err := b.WriteFile(path); err != nil { ... }
+//
+// The package deliberately does not import vital so that the vital package's
+// own tests can use it.
+package vitaltest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+)
+
+// Packet types.
+const (
+	PacketTrkInfo byte = 0
+	PacketRec     byte = 1
+	PacketCmd     byte = 6
+	PacketDevInfo byte = 9
+)
+
+// Track types.
+const (
+	TypeWave    uint8 = 1
+	TypeNumeric uint8 = 2
+	TypeString  uint8 = 5
+)
+
+// Sample fmt codes.
+const (
+	FmtFloat32 uint8 = 1
+	FmtFloat64 uint8 = 2
+	FmtInt8    uint8 = 3
+	FmtUint8   uint8 = 4
+	FmtInt16   uint8 = 5
+	FmtUint16  uint8 = 6
+	FmtInt32   uint8 = 7
+	FmtUint32  uint8 = 8
+)
+
+// CmdTrackOrder is the CMD packet command that reorders tracks.
+const CmdTrackOrder uint32 = 1
+
+var le = binary.LittleEndian
+
+// Track describes a TRKINFO packet. The reader ignores TRKINFO packets
+// shorter than 51 bytes, so Name and Unit together need at least two bytes.
+type Track struct {
+	ID      uint16
+	Type    uint8
+	Fmt     uint8
+	Name    string
+	Unit    string
+	Mindisp float32
+	Maxdisp float32
+	Col     uint32
+	SRate   float32
+	Gain    float64
+	Offset  float64
+	Montype uint8
+	DevID   uint32
+}
+
+// Builder accumulates the header and packets of a .vital file.
+type Builder struct {
+	Version uint32
+	Dgmt    int16
+	DtStart float64
+	DtEnd   float64
+
+	pkts bytes.Buffer
+}
+
+// New returns a Builder whose header starts at dtstart.
+func New(dtstart float64) *Builder {
+	return &Builder{Version: 3, DtStart: dtstart}
+}
+
+// Device appends a DEVINFO packet.
+func (b *Builder) Device(did uint32, typeName, name, port string) *Builder {
+	return b.Raw(PacketDevInfo, DevInfoPacket(did, typeName, name, port))
+}
+
+// Track appends a TRKINFO packet.
+func (b *Builder) Track(t Track) *Builder {
+	return b.Raw(PacketTrkInfo, TrkInfoPacket(t))
+}
+
+// Wave appends a REC packet holding a WAVE chunk. The sample encoding
+// follows the Go type of samples ([]float32, []float64, []int8, []uint8,
+// []int16, []uint16, []int32 or []uint32), independent of the track's fmt.
+func (b *Builder) Wave(tid uint16, dt float64, samples any) *Builder {
+	return b.Raw(PacketRec, RecPacket(tid, dt, WavePayload(samples)))
+}
+
+// Numeric appends a REC packet holding a single value encoded according to
+// its Go type.
+func (b *Builder) Numeric(tid uint16, dt float64, val any) *Builder {
+	return b.Raw(PacketRec, RecPacket(tid, dt, encode(val)))
+}
+
+// String appends a REC packet holding a STRING value.
+func (b *Builder) String(tid uint16, dt float64, s string) *Builder {
+	return b.Raw(PacketRec, RecPacket(tid, dt, StringPayload(s)))
+}
+
+// TrackOrder appends a CMD packet ordering tracks by their 1-based
+// position in the order of definition.
+func (b *Builder) TrackOrder(positions ...uint16) *Builder {
+	return b.Raw(PacketCmd, CmdOrderPacket(positions...))
+}
+
+// Raw appends a packet with an arbitrary type and body, which need not be
+// well formed.
+func (b *Builder) Raw(typ byte, body []byte) *Builder {
+	b.pkts.WriteByte(typ)
+	binary.Write(&b.pkts, le, uint32(len(body)))
+	b.pkts.Write(body)
+	return b
+}
+
+// Truncated appends a packet header declaring length bytes followed by only
+// the given body, as left behind by a recorder that stopped mid-write. It
+// only makes sense as the last packet.
+func (b *Builder) Truncated(typ byte, length uint32, body []byte) *Builder {
+	b.pkts.WriteByte(typ)
+	binary.Write(&b.pkts, le, length)
+	b.pkts.Write(body)
+	return b
+}
+
+// Bytes returns the uncompressed stream: magic, version, header and packets.
+func (b *Builder) Bytes() []byte {
+	var out bytes.Buffer
+	header := make([]byte, 26)
+	le.PutUint16(header[0:], uint16(b.Dgmt))
+	le.PutUint64(header[10:], math.Float64bits(b.DtStart))
+	le.PutUint64(header[18:], math.Float64bits(b.DtEnd))
+
+	out.WriteString("VITA")
+	binary.Write(&out, le, b.Version)
+	binary.Write(&out, le, uint16(len(header)))
+	out.Write(header)
+	out.Write(b.pkts.Bytes())
+	return out.Bytes()
+}
+
+// Gzip returns the stream compressed as a .vital file is stored on disk.
+func (b *Builder) Gzip() []byte {
+	var out bytes.Buffer
+	zw := gzip.NewWriter(&out)
+	zw.Write(b.Bytes())
+	zw.Close()
+	return out.Bytes()
+}
+
+// WriteFile writes the compressed stream to path.
+func (b *Builder) WriteFile(path string) error {
+	return os.WriteFile(path, b.Gzip(), 0o644)
+}
+
+// DevInfoPacket returns the body of a DEVINFO packet.
+func DevInfoPacket(did uint32, typeName, name, port string) []byte {
+	var p bytes.Buffer
+	binary.Write(&p, le, did)
+	putStr(&p, typeName)
+	putStr(&p, name)
+	putStr(&p, port)
+	return p.Bytes()
+}
+
+// TrkInfoPacket returns the body of a TRKINFO packet.
+func TrkInfoPacket(t Track) []byte {
+	var p bytes.Buffer
+	binary.Write(&p, le, t.ID)
+	p.WriteByte(t.Type)
+	p.WriteByte(t.Fmt)
+	putStr(&p, t.Name)
+	putStr(&p, t.Unit)
+	binary.Write(&p, le, t.Mindisp)
+	binary.Write(&p, le, t.Maxdisp)
+	binary.Write(&p, le, t.Col)
+	binary.Write(&p, le, t.SRate)
+	binary.Write(&p, le, t.Gain)
+	binary.Write(&p, le, t.Offset)
+	p.WriteByte(t.Montype)
+	binary.Write(&p, le, t.DevID)
+	return p.Bytes()
+}
+
+// RecPacket returns the body of a REC packet: info length, time, track ID
+// and the type-specific payload.
+func RecPacket(tid uint16, dt float64, payload []byte) []byte {
+	var p bytes.Buffer
+	binary.Write(&p, le, uint16(10)) // infolen: dt(8) + tid(2)
+	binary.Write(&p, le, dt)
+	binary.Write(&p, le, tid)
+	p.Write(payload)
+	return p.Bytes()
+}
+
+// WavePayload returns the REC payload of a WAVE chunk: the sample count
+// followed by the encoded samples.
+func WavePayload(samples any) []byte {
+	data := encode(samples)
+	var p bytes.Buffer
+	binary.Write(&p, le, uint32(len(data)/elemSize(samples)))
+	p.Write(data)
+	return p.Bytes()
+}
+
+// StringPayload returns the REC payload of a STRING value.
+func StringPayload(s string) []byte {
+	var p bytes.Buffer
+	binary.Write(&p, le, uint32(0)) // reserved
+	putStr(&p, s)
+	return p.Bytes()
+}
+
+// CmdOrderPacket returns the body of a TRK_ORDER CMD packet.
+func CmdOrderPacket(positions ...uint16) []byte {
+	var p bytes.Buffer
+	binary.Write(&p, le, CmdTrackOrder)
+	binary.Write(&p, le, uint16(len(positions)))
+	binary.Write(&p, le, positions)
+	return p.Bytes()
+}
+
+func putStr(p *bytes.Buffer, s string) {
+	binary.Write(p, le, uint32(len(s)))
+	p.WriteString(s)
+}
+
+// encode returns the little-endian encoding of a fixed-size value or slice.
+func encode(v any) []byte {
+	var p bytes.Buffer
+	if err := binary.Write(&p, le, v); err != nil {
+		panic(fmt.Sprintf("vitaltest: cannot encode %T: %v", v, err))
+	}
+	return p.Bytes()
+}
+
+func elemSize(samples any) int {
+	switch samples.(type) {
+	case []int8, []uint8:
+		return 1
+	case []int16, []uint16:
+		return 2
+	case []float32, []int32, []uint32:
+		return 4
+	case []float64:
+		return 8
+	}
+	panic(fmt.Sprintf("vitaltest: unsupported sample type %T", samples))
+}
@@ -0,0 +1,378 @@
+package vital
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// fileVersion is the format version written by Write.
+const fileVersion = 3
+
+// Write encodes vf as a gzip-compressed .vital stream. Devices and tracks
+// get new IDs; tracks are defined in Order, followed by any tracks missing
+// from it, and records of all tracks are interleaved in time order. Values
+// are written in each track's fmt, so a file read back with ReadVitalFile
+// holds the same raw values.
+func (vf *VitalFile) Write(w io.Writer) error {
+	zw := gzip.NewWriter(w)
+	bw := bufio.NewWriterSize(zw, readBufferSize)
+	enc := &encoder{w: bw}
+
+	// magic, version, header: dgmt(2) + 예약(8) + dtstart(8) + dtend(8)
+	header := make([]byte, 26)
+	binary.LittleEndian.PutUint16(header[0:], uint16(vf.Dgmt))
+	binary.LittleEndian.PutUint64(header[10:], math.Float64bits(vf.DtStart))
+	binary.LittleEndian.PutUint64(header[18:], math.Float64bits(vf.DtEnd))
+	enc.raw([]byte("VITA"))
+	enc.u32(fileVersion)
+	enc.u16(uint16(len(header)))
+	enc.raw(header)
+
+	// 디바이스: 이름 순으로 did 1부터 부여 (트랙만 참조하는 디바이스 포함)
+	devs := make(map[string]Device, len(vf.Devs))
+	for name, dev := range vf.Devs {
+		devs[name] = dev
+	}
+	for _, trk := range vf.Trks {
+		if _, ok := devs[trk.DName]; trk.DName != "" && !ok {
+			devs[trk.DName] = Device{Name: trk.DName}
+		}
+	}
+	devNames := make([]string, 0, len(devs))
+	for name := range devs {
+		devNames = append(devNames, name)
+	}
+	sort.Strings(devNames)
+	dids := make(map[string]uint32, len(devNames))
+	for i, name := range devNames {
+		dev := devs[name]
+		dids[name] = uint32(i + 1)
+		var p bytes.Buffer
+		binary.Write(&p, binary.LittleEndian, dids[name])
+		putStr(&p, dev.TypeName)
+		putStr(&p, name)
+		putStr(&p, dev.Port)
+		enc.packet(9, p.Bytes())
+	}
+
+	trks := vf.writeOrder()
+	for i, trk := range trks {
+		enc.packet(0, trkInfoPacket(uint16(i+1), trk, dids[trk.DName]))
+	}
+
+	if err := writeRecs(enc, trks); err != nil {
+		return err
+	}
+	if enc.err != nil {
+		return enc.err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// WriteFile writes vf to path as a .vital file.
+func (vf *VitalFile) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	if err := vf.Write(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// writeOrder returns the tracks in the order they are defined in the
+// written file: Order without repeats, then the remaining tracks by name.
+func (vf *VitalFile) writeOrder() []*Track {
+	seen := make(map[string]bool, len(vf.Trks))
+	trks := make([]*Track, 0, len(vf.Trks))
+	for _, name := range vf.Order {
+		if trk, ok := vf.Trks[name]; ok && !seen[name] {
+			seen[name] = true
+			trks = append(trks, trk)
+		}
+	}
+	var rest []string
+	for name := range vf.Trks {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	for _, name := range rest {
+		trks = append(trks, vf.Trks[name])
+	}
+	return trks
+}
+
+func trkInfoPacket(tid uint16, trk *Track, did uint32) []byte {
+	// 파서가 "디바이스명/트랙명"으로 다시 붙이므로 트랙 이름만 기록
+	name := trk.Name
+	if trk.DName != "" {
+		name = strings.TrimPrefix(name, trk.DName+"/")
+	}
+	le := binary.LittleEndian
+	var p bytes.Buffer
+	binary.Write(&p, le, tid)
+	p.WriteByte(trk.Type)
+	p.WriteByte(trk.Fmt)
+	putStr(&p, name)
+	putStr(&p, trk.Unit)
+	binary.Write(&p, le, trk.Mindisp)
+	binary.Write(&p, le, trk.Maxdisp)
+	binary.Write(&p, le, trk.Col)
+	binary.Write(&p, le, trk.SRate)
+	binary.Write(&p, le, trk.Gain)
+	binary.Write(&p, le, trk.Offset)
+	p.WriteByte(trk.Montype)
+	binary.Write(&p, le, did)
+	return p.Bytes()
+}
+
+// recCursor is the next unwritten record of a track during writeRecs.
+type recCursor struct {
+	trk *Track
+	tid uint16
+	i   int
+	rec Rec
+}
+
+// recHeap orders cursors by record time, then by track ID so that the
+// output is deterministic.
+type recHeap []*recCursor
+
+func (h recHeap) Len() int { return len(h) }
+func (h recHeap) Less(i, j int) bool {
+	if h[i].rec.Dt != h[j].rec.Dt {
+		return h[i].rec.Dt < h[j].rec.Dt
+	}
+	return h[i].tid < h[j].tid
+}
+func (h recHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *recHeap) Push(x any)   { *h = append(*h, x.(*recCursor)) }
+func (h *recHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// writeRecs writes the records of trks merged by time. Each track's records
+// keep their relative order.
+func writeRecs(enc *encoder, trks []*Track) error {
+	h := make(recHeap, 0, len(trks))
+	for i, trk := range trks {
+		if trk.NumRecs() == 0 {
+			continue
+		}
+		rec, err := trk.Rec(0)
+		if err != nil {
+			return err
+		}
+		h = append(h, &recCursor{trk: trk, tid: uint16(i + 1), rec: rec})
+	}
+	heap.Init(&h)
+
+	var p bytes.Buffer
+	for h.Len() > 0 {
+		c := h[0]
+		p.Reset()
+		if err := recPacket(&p, c.tid, c.trk, c.rec); err != nil {
+			return err
+		}
+		enc.packet(1, p.Bytes())
+
+		c.i++
+		if c.i == c.trk.NumRecs() {
+			heap.Pop(&h)
+			continue
+		}
+		rec, err := c.trk.Rec(c.i)
+		if err != nil {
+			return err
+		}
+		c.rec = rec
+		heap.Fix(&h, 0)
+	}
+	return nil
+}
+
+// recPacket appends the body of the REC packet of rec to p.
+func recPacket(p *bytes.Buffer, tid uint16, trk *Track, rec Rec) error {
+	le := binary.LittleEndian
+	binary.Write(p, le, uint16(10)) // infolen: dt(8) + tid(2)
+	binary.Write(p, le, rec.Dt)
+	binary.Write(p, le, tid)
+
+	switch trk.Type {
+	case 1: // WAVE
+		samples := convertSamples(trk.Fmt, rec.Val)
+		if samples == nil {
+			return fmt.Errorf("track %q: cannot write %T samples as fmt %d", trk.Name, rec.Val, trk.Fmt)
+		}
+		binary.Write(p, le, uint32(sampleCount(samples)))
+		p.Write(encodeSamples(samples))
+	case 2: // NUMERIC
+		v, ok := rec.GetNumericValue()
+		if !ok || sampleSize(trk.Fmt) == 0 {
+			return fmt.Errorf("track %q: cannot write %T value as fmt %d", trk.Name, rec.Val, trk.Fmt)
+		}
+		p.Write(encodeSamples(convertSamples(trk.Fmt, []float64{v})))
+	case 5: // STRING
+		s, _ := rec.Val.(string)
+		binary.Write(p, le, uint32(0)) // 예약 필드
+		putStr(p, s)
+	default:
+		return fmt.Errorf("track %q: cannot write track type %d", trk.Name, trk.Type)
+	}
+	return nil
+}
+
+// convertSamples returns a WAVE chunk as the slice type of fmtcode,
+// converting the values if they are held in another type. It returns nil
+// if val is not a sample slice or fmtcode is unknown.
+func convertSamples(fmtcode uint8, val any) any {
+	if sampleSize(fmtcode) == 0 {
+		return nil
+	}
+	if samplesFmt(val) == fmtcode {
+		return val
+	}
+	vals, ok := samplesFloat64(val)
+	if !ok {
+		return nil
+	}
+	switch fmtcode {
+	case 1:
+		return convertSlice[float32](vals)
+	case 2:
+		return vals
+	case 3:
+		return convertSlice[int8](vals)
+	case 4:
+		return convertSlice[uint8](vals)
+	case 5:
+		return convertSlice[int16](vals)
+	case 6:
+		return convertSlice[uint16](vals)
+	case 7:
+		return convertSlice[int32](vals)
+	case 8:
+		return convertSlice[uint32](vals)
+	}
+	return nil
+}
+
+// samplesFmt returns the fmt code matching the Go type of a WAVE chunk, or
+// 0 if val is not a sample slice.
+func samplesFmt(val any) uint8 {
+	switch val.(type) {
+	case []float32:
+		return 1
+	case []float64:
+		return 2
+	case []int8:
+		return 3
+	case []uint8:
+		return 4
+	case []int16:
+		return 5
+	case []uint16:
+		return 6
+	case []int32:
+		return 7
+	case []uint32:
+		return 8
+	}
+	return 0
+}
+
+func convertSlice[T float32 | int8 | uint8 | int16 | uint16 | int32 | uint32](vals []float64) []T {
+	out := make([]T, len(vals))
+	for i, v := range vals {
+		out[i] = T(v)
+	}
+	return out
+}
+
+// samplesFloat64 returns a copy of a WAVE chunk as float64 values.
+func samplesFloat64(val any) ([]float64, bool) {
+	switch v := val.(type) {
+	case []float32:
+		return toFloat64s(v), true
+	case []float64:
+		return append([]float64(nil), v...), true
+	case []int8:
+		return toFloat64s(v), true
+	case []uint8:
+		return toFloat64s(v), true
+	case []int16:
+		return toFloat64s(v), true
+	case []uint16:
+		return toFloat64s(v), true
+	case []int32:
+		return toFloat64s(v), true
+	case []uint32:
+		return toFloat64s(v), true
+	}
+	return nil, false
+}
+
+func toFloat64s[T float32 | int8 | uint8 | int16 | uint16 | int32 | uint32](v []T) []float64 {
+	out := make([]float64, len(v))
+	for i, x := range v {
+		out[i] = float64(x)
+	}
+	return out
+}
+
+func putStr(p *bytes.Buffer, s string) {
+	binary.Write(p, binary.LittleEndian, uint32(len(s)))
+	p.WriteString(s)
+}
+
+// encoder writes packets and keeps the first write error.
+type encoder struct {
+	w   io.Writer
+	err error
+	hdr [5]byte
+}
+
+func (e *encoder) raw(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *encoder) u16(v uint16) {
+	var b [2]byte
+	binary.LittleEndian.PutUint16(b[:], v)
+	e.raw(b[:])
+}
+
+func (e *encoder) u32(v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	e.raw(b[:])
+}
+
+func (e *encoder) packet(typ byte, body []byte) {
+	e.hdr[0] = typ
+	binary.LittleEndian.PutUint32(e.hdr[1:], uint32(len(body)))
+	e.raw(e.hdr[:])
+	e.raw(body)
+}